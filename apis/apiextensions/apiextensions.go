@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	v1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
 	v1beta1 "github.com/crossplane/crossplane/apis/apiextensions/v1beta1"
 )
 
@@ -29,6 +30,7 @@ func init() {
 	AddToSchemes = append(AddToSchemes,
 		v1.AddToScheme,
 		v1beta1.AddToScheme,
+		v1alpha1.AddToScheme,
 	)
 }
 
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+)
+
+// Composition revision labels.
+const (
+	// LabelCompositionName is the name of the Composition a
+	// CompositionRevision was created from.
+	LabelCompositionName = "crossplane.io/composition-name"
+
+	// LabelCompositionSpecHash is a hash of the spec of the Composition a
+	// CompositionRevision was created from.
+	LabelCompositionSpecHash = "crossplane.io/composition-spec-hash"
+)
+
+// An UpdatePolicy specifies how a composite resource should be updated when a
+// new revision of its Composition is created.
+type UpdatePolicy string
+
+// Update policies.
+const (
+	// UpdateAutomatic means the composite resource should always use the
+	// latest revision of its Composition.
+	UpdateAutomatic UpdatePolicy = "Automatic"
+
+	// UpdateManual means the composite resource should continue to use the
+	// revision of its Composition it references until the reference is
+	// updated.
+	UpdateManual UpdatePolicy = "Manual"
+)
+
+// CompositionRevisionSpec specifies the desired state of the composition
+// revision.
+type CompositionRevisionSpec struct {
+	CompositionSpec `json:",inline"`
+
+	// Revision number. Newer revisions have larger numbers.
+	// +immutable
+	Revision int64 `json:"revision"`
+}
+
+// CompositionRevisionStatus shows the observed state of the composition
+// revision.
+type CompositionRevisionStatus struct {
+	xpv1.ConditionedStatus `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +genclient
+// +genclient:nonNamespaced
+
+// A CompositionRevision represents a revision in time of a Composition.
+// Revisions are created by Crossplane each time the spec of a Composition
+// changes, and should be treated as immutable.
+// +kubebuilder:printcolumn:name="REVISION",type="string",JSONPath=".spec.revision"
+// +kubebuilder:printcolumn:name="XR-KIND",type="string",JSONPath=".spec.compositeTypeRef.kind"
+// +kubebuilder:printcolumn:name="XR-APIVERSION",type="string",JSONPath=".spec.compositeTypeRef.apiVersion"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=crossplane,shortName=comprev
+type CompositionRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CompositionRevisionSpec   `json:"spec,omitempty"`
+	Status CompositionRevisionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CompositionRevisionList contains a list of CompositionRevisions.
+type CompositionRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CompositionRevision `json:"items"`
+}
+
+// Hash returns a hash of the spec of the supplied Composition, suitable for use
+// as a label value.
+func (c *Composition) Hash() string {
+	// Marshalling a CompositionSpec cannot fail; it contains only types that
+	// can be represented as JSON.
+	j, _ := json.Marshal(c.Spec)
+	h := sha256.Sum256(j)
+	return hex.EncodeToString(h[:])[:63]
+}
+
+// NewCompositionRevision returns a revision of the supplied Composition with
+// the supplied revision number. The revision is named for its Composition, the
+// hash of its spec, and its number, so that a Composition whose spec is reverted
+// to that of an older revision may have more than one revision of that spec.
+func NewCompositionRevision(c *Composition, revision int64) *CompositionRevision {
+	hash := c.Hash()
+	cr := &CompositionRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s-%d", c.GetName(), hash[:7], revision),
+			Labels: map[string]string{
+				LabelCompositionName:     c.GetName(),
+				LabelCompositionSpecHash: hash,
+			},
+		},
+		Spec: CompositionRevisionSpec{
+			CompositionSpec: *c.Spec.DeepCopy(),
+			Revision:        revision,
+		},
+	}
+	meta.AddOwnerReference(cr, meta.AsController(meta.TypedReferenceTo(c, CompositionGroupVersionKind)))
+	return cr
+}
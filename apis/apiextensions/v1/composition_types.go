@@ -17,27 +17,62 @@ limitations under the License.
 package v1
 
 import (
-	"encoding/json"
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/crossplane/pkg/expression"
 )
 
 const (
 	errMathNoMultiplier   = "no input is given"
 	errMathInputNonNumber = "input is required to be a number for math transformer"
-	errPatchSetType       = "a patch in a PatchSet cannot be of type PatchSet"
-	errRequiredField      = "%s is required by type %s"
-	errUndefinedPatchSet  = "cannot find PatchSet by name %s"
-	errInvalidPatchType   = "patch type %s is unsupported"
+	errMathDivideByZero   = "cannot divide by zero"
+
+	errStringDecodeBase64  = "cannot decode base64"
+	errStringCompileRegexp = "cannot compile regexp"
+
+	errMapDecodeValue    = "cannot decode map value"
+	errMatchDecodeValue  = "cannot decode match result"
+	errMatchNoFallback   = "input matched no pattern and no fallback value is specified"
+	errPatchSetType      = "a patch in a PatchSet cannot be of type PatchSet"
+	errRequiredField     = "%s is required by type %s"
+	errUndefinedPatchSet = "cannot find PatchSet by name %s"
+	errInvalidPatchType  = "patch type %s is unsupported"
+
+	errCombineRequiresVariables = "combine patch types require at least one variable"
+
+	errFmtRequiredFieldPath = "cannot get required field path %s"
+
+	errFmtWildcardMismatch      = "toFieldPath %s must contain as many wildcards as fromFieldPath %s"
+	errFmtWildcardNotExpandable = "cannot expand wildcard in field path %s: value is not an array or object"
+
+	errEvaluateCondition   = "cannot evaluate condition"
+	errFmtConditionNotBool = "condition must evaluate to a boolean, not %T"
+
+	errFmtRequiresComposedSource = "%s patches must be applied from a composed resource"
+	errFmtComposedNotObserved    = "cannot patch from composed resource %q that has not yet been observed"
+	errFmtRequiresItemSource     = "%s patches must be applied from the item of a forEach template"
+	errFmtRequiresEnvironment    = "%s patches must be applied from the environment"
 
 	errFmtConvertInputTypeNotSupported = "input type %s is not supported"
 	errFmtConversionPairNotSupported   = "conversion from %s to %s is not supported"
@@ -47,6 +82,25 @@ const (
 	errFmtTransformTypeFailed          = "%s transform could not resolve"
 	errFmtMapTypeNotSupported          = "type %s is not supported for map transform"
 	errFmtMapNotFound                  = "key %s is not found in map"
+	errFmtMatchTypeNotSupported        = "type %s is not supported for match transform"
+	errFmtMatchPatternTypeNotSupported = "match pattern type %s is not supported"
+	errFmtMatchPatternRequiredField    = "%s is required by match pattern type %s"
+	errFmtMatchPatternAtIndex          = "match pattern at index %d returned error"
+	errFmtMatchFallbackToNotSupported  = "match fallback %s is not supported"
+	errFmtMapFallbackToNotSupported    = "map fallback %s is not supported"
+	errFmtMathNoOperand                = "%s math transform requires a value"
+	errFmtMathTypeNotSupported         = "math transform type %s is not supported"
+	errFmtMathOutputTypeNotSupported   = "math transform output type %s is not supported"
+	errFmtMathOperandNotNumber         = "math transform operand %q is not a number"
+	errFmtStringTypeNotSupported       = "string transform type %s is not supported"
+	errFmtStringRequiredField          = "%s is required by string transform type %s"
+	errFmtStringConversionNotSupported = "string conversion type %s is not supported"
+	errFmtStringInputTypeNotSupported  = "input type %s is not supported by string transform type %s"
+	errFmtStringRegexpNoMatch          = "regexp %q had no matches"
+	errFmtStringRegexpNoGroup          = "regexp %q has no group %d"
+	errFmtCombineStrategyNotSupported  = "combine strategy %s is not supported"
+	errFmtCombineConfigMissing         = "given combine strategy %s requires configuration"
+	errFmtCombineStrategyFailed        = "%s strategy could not combine"
 )
 
 // CompositionSpec specifies the desired state of the definition.
@@ -56,6 +110,22 @@ type CompositionSpec struct {
 	// +immutable
 	CompositeTypeRef TypeReference `json:"compositeTypeRef"`
 
+	// Priority of this Composition when it is one of several Compositions
+	// that match a composite resource's compositionSelector. A Composition
+	// with a higher priority is always selected over one with a lower
+	// priority. Defaults to 0.
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+
+	// Weight of this Composition relative to the other Compositions of the
+	// same priority that match a composite resource's compositionSelector.
+	// A Composition with twice the weight of another is selected for about
+	// twice as many composite resources. The selection is deterministic for
+	// any one composite resource. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Weight *int32 `json:"weight,omitempty"`
+
 	// PatchSets define a named set of patches that may be included by
 	// any resource in this Composition.
 	// PatchSets cannot themselves refer to other PatchSets.
@@ -71,8 +141,195 @@ type CompositionSpec struct {
 	// this composition will be created.
 	// +optional
 	WriteConnectionSecretsToNamespace *string `json:"writeConnectionSecretsToNamespace,omitempty"`
+
+	// DefaultPatchPolicy is the patch policy used by any patch in this
+	// Composition that does not specify its own. Platform teams may use it to
+	// make all patches strict.
+	// +optional
+	DefaultPatchPolicy *PatchPolicy `json:"defaultPatchPolicy,omitempty"`
+
+	// RemovedResourcePolicy specifies what happens to a composed resource that
+	// no longer corresponds to any of the templates of this Composition, for
+	// example because its template was removed. Such resources are deleted by
+	// default, and are orphaned only if this policy is Orphan. Orphaned
+	// resources are no longer controlled by their composite resource, and are
+	// not deleted when it is deleted. Their own deletion policy is unchanged.
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	RemovedResourcePolicy *RemovedResourcePolicy `json:"removedResourcePolicy,omitempty"`
+
+	// Functions is a pipeline of functions that are run, in order, after the
+	// resources of this Composition are rendered. Each function is sent the
+	// observed state of the composite resource and its composed resources,
+	// along with their desired state, and may return new desired state for
+	// the composed resources.
+	// +optional
+	Functions []Function `json:"functions,omitempty"`
+
+	// Environment configures the environment in which resources are
+	// composed. The environment is formed from the data of a set of
+	// EnvironmentConfigs, and may be patched from using
+	// FromEnvironmentFieldPath patches.
+	// +optional
+	Environment *EnvironmentConfiguration `json:"environment,omitempty"`
+}
+
+// An EnvironmentConfiguration configures the environment in which resources
+// are composed.
+type EnvironmentConfiguration struct {
+	// EnvironmentConfigs selects the EnvironmentConfigs that form the
+	// environment. The data of all selected EnvironmentConfigs is merged, in
+	// order. EnvironmentConfigs that are selected by label are merged in order
+	// of their names. Later values override earlier ones.
+	// +optional
+	EnvironmentConfigs []EnvironmentSource `json:"environmentConfigs,omitempty"`
+
+	// Patches are applied between the environment and the composite resource,
+	// before any resources are composed. FromCompositeFieldPath and
+	// CombineFromComposite patches write to the environment, while
+	// ToCompositeFieldPath and CombineToComposite patches write to the
+	// composite resource.
+	// +optional
+	Patches []Patch `json:"patches,omitempty"`
+}
+
+// An EnvironmentSourceType is a way of selecting EnvironmentConfigs.
+type EnvironmentSourceType string
+
+// Environment source types.
+const (
+	// EnvironmentSourceTypeReference selects an EnvironmentConfig by name.
+	EnvironmentSourceTypeReference EnvironmentSourceType = "Reference"
+
+	// EnvironmentSourceTypeSelector selects EnvironmentConfigs by label.
+	EnvironmentSourceTypeSelector EnvironmentSourceType = "Selector"
+)
+
+// An EnvironmentSource selects one or more EnvironmentConfigs.
+type EnvironmentSource struct {
+	// Type specifies how EnvironmentConfigs are selected.
+	// +optional
+	// +kubebuilder:validation:Enum=Reference;Selector
+	// +kubebuilder:default=Reference
+	Type EnvironmentSourceType `json:"type,omitempty"`
+
+	// Ref is the name of an EnvironmentConfig. Required when type is
+	// Reference.
+	// +optional
+	Ref *EnvironmentSourceReference `json:"ref,omitempty"`
+
+	// Selector selects EnvironmentConfigs by label. Required when type is
+	// Selector.
+	// +optional
+	Selector *EnvironmentSourceSelector `json:"selector,omitempty"`
+}
+
+// An EnvironmentSourceReference references an EnvironmentConfig by name.
+type EnvironmentSourceReference struct {
+	// Name of the EnvironmentConfig.
+	Name string `json:"name"`
+}
+
+// An EnvironmentSourceSelector selects EnvironmentConfigs by label.
+type EnvironmentSourceSelector struct {
+	// MatchLabels that selected EnvironmentConfigs must have. An
+	// EnvironmentConfig must match all labels to be selected.
+	MatchLabels []EnvironmentSourceSelectorLabelMatcher `json:"matchLabels"`
+}
+
+// An EnvironmentSourceSelectorLabelMatcherType is a source of label values.
+type EnvironmentSourceSelectorLabelMatcherType string
+
+// Environment source selector label matcher types.
+const (
+	// EnvironmentSourceSelectorLabelMatcherTypeFromCompositeFieldPath reads
+	// the label value from a field of the composite resource.
+	EnvironmentSourceSelectorLabelMatcherTypeFromCompositeFieldPath EnvironmentSourceSelectorLabelMatcherType = "FromCompositeFieldPath"
+
+	// EnvironmentSourceSelectorLabelMatcherTypeValue uses a literal label
+	// value.
+	EnvironmentSourceSelectorLabelMatcherTypeValue EnvironmentSourceSelectorLabelMatcherType = "Value"
+)
+
+// An EnvironmentSourceSelectorLabelMatcher matches a label of an
+// EnvironmentConfig.
+type EnvironmentSourceSelectorLabelMatcher struct {
+	// Type specifies where the value of the label comes from.
+	// +optional
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;Value
+	// +kubebuilder:default=FromCompositeFieldPath
+	Type EnvironmentSourceSelectorLabelMatcherType `json:"type,omitempty"`
+
+	// Key of the label.
+	Key string `json:"key"`
+
+	// ValueFromFieldPath is the path of the field of the composite resource
+	// whose value is used as the label value. Required when type is
+	// FromCompositeFieldPath.
+	// +optional
+	ValueFromFieldPath *string `json:"valueFromFieldPath,omitempty"`
+
+	// Value of the label. Required when type is Value.
+	// +optional
+	Value *string `json:"value,omitempty"`
+}
+
+// A FunctionType is a type of composition function.
+type FunctionType string
+
+// Function types.
+const (
+	// FunctionTypeExec functions are executables that read a request from
+	// stdin and write a response to stdout.
+	FunctionTypeExec FunctionType = "Exec"
+)
+
+// A Function is a step in a Composition's function pipeline.
+type Function struct {
+	// Name of this function. Must be unique within its Composition.
+	Name string `json:"name"`
+
+	// Type of this function. Only Exec functions are currently supported.
+	// +kubebuilder:validation:Enum=Exec
+	Type FunctionType `json:"type"`
+
+	// Exec configures an Exec function.
+	// +optional
+	Exec *ExecFunction `json:"exec,omitempty"`
+
+	// Config is optional, arbitrary configuration that is sent to the
+	// function along with each request.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config *runtime.RawExtension `json:"config,omitempty"`
+
+	// Timeout after which the function is considered to have failed.
+	// Defaults to 20 seconds.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// An ExecFunction is an executable that reads a request from stdin and writes
+// a response to stdout.
+type ExecFunction struct {
+	// Command is the executable to run. It must be available to Crossplane.
+	Command string `json:"command"`
+
+	// Args to pass to the command.
+	// +optional
+	Args []string `json:"args,omitempty"`
 }
 
+// A RemovedResourcePolicy specifies what happens to a composed resource that no
+// longer corresponds to any template.
+type RemovedResourcePolicy string
+
+// Removed resource policies.
+const (
+	RemovedResourcePolicyDelete RemovedResourcePolicy = "Delete"
+	RemovedResourcePolicyOrphan RemovedResourcePolicy = "Orphan"
+)
+
 // InlinePatchSets dereferences PatchSets and includes their patches inline. The
 // updated CompositionSpec should not be persisted to the API server.
 func (cs *CompositionSpec) InlinePatchSets() error {
@@ -107,6 +364,34 @@ func (cs *CompositionSpec) InlinePatchSets() error {
 	return nil
 }
 
+// DefaultPatchPolicies sets the Composition's default patch policy on any
+// patch that does not specify its own. PatchSets should be inlined before
+// this is called. The updated CompositionSpec should not be persisted to the
+// API server.
+func (cs *CompositionSpec) DefaultPatchPolicies() {
+	if cs.DefaultPatchPolicy == nil {
+		return
+	}
+	for i := range cs.Resources {
+		for j := range cs.Resources[i].Patches {
+			p := &cs.Resources[i].Patches[j]
+			// Patches inlined from a PatchSet share their policy, so we
+			// default a copy rather than mutating it in place.
+			pp := &PatchPolicy{}
+			if p.Policy != nil {
+				pp = p.Policy.DeepCopy()
+			}
+			if pp.FromFieldPath == nil {
+				pp.FromFieldPath = cs.DefaultPatchPolicy.FromFieldPath
+			}
+			if pp.MergeOptions == nil && cs.DefaultPatchPolicy.MergeOptions != nil {
+				pp.MergeOptions = cs.DefaultPatchPolicy.MergeOptions.DeepCopy()
+			}
+			p.Policy = pp
+		}
+	}
+}
+
 // A PatchSet is a set of patches that can be reused from all resources within
 // a Composition.
 type PatchSet struct {
@@ -134,6 +419,40 @@ func TypeReferenceTo(gvk schema.GroupVersionKind) TypeReference {
 // ComposedTemplate is used to provide information about how the composed resource
 // should be processed.
 type ComposedTemplate struct {
+	// Name of the template. Names must be unique within a Composition. Named
+	// templates may be referred to by the patches of other templates. The
+	// resources composed by a named template are associated with it by name
+	// rather than by position, so named templates may be safely reordered,
+	// inserted, or removed. Resources composed before their template was
+	// named are associated with it by position, then annotated with its name.
+	// +optional
+	Name *string `json:"name,omitempty"`
+
+	// Condition is an expression that determines whether this resource is
+	// composed, for example 'composite.spec.parameters.enableReplica'. The
+	// composite resource is available as the variable 'composite'. The
+	// expression must evaluate to a boolean. The resource is composed only if
+	// it evaluates to true, and is deleted if it was composed previously but
+	// it no longer evaluates to true. Resources without a condition are always
+	// composed.
+	// +kubebuilder:validation:MaxLength=4096
+	// +optional
+	Condition *string `json:"condition,omitempty"`
+
+	// ForEach composes one resource from this template for each element of an
+	// array within the composite resource, rather than a single resource.
+	// ForEach templates must be named.
+	// +optional
+	ForEach *ForEach `json:"forEach,omitempty"`
+
+	// DependsOn is the names of other templates that this template depends
+	// on. Templates that depend on other templates must be named. A resource
+	// is not created until the resources composed from the templates it
+	// depends on are ready, and resources are not deleted until the resources
+	// that depend on them are gone.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
 	// Base is the target resource that the patches will be applied on.
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:EmbeddedResource
@@ -149,10 +468,56 @@ type ComposedTemplate struct {
 	ConnectionDetails []ConnectionDetail `json:"connectionDetails,omitempty"`
 
 	// ReadinessChecks allows users to define custom readiness checks. All checks
-	// have to return true in order for resource to be considered ready. The
-	// default readiness check is to have the "Ready" condition to be "True".
+	// have to return true in order for resource to be considered ready, unless
+	// the readiness check policy is AnyOf. The default readiness check is to
+	// have the "Ready" condition to be "True".
 	// +optional
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// ReadinessCheckPolicy specifies whether all of the readiness checks
+	// (AllOf) or any of them (AnyOf) have to return true in order for the
+	// resource to be considered ready. Defaults to AllOf.
+	// +optional
+	// +kubebuilder:validation:Enum=AllOf;AnyOf
+	ReadinessCheckPolicy *ReadinessCheckPolicy `json:"readinessCheckPolicy,omitempty"`
+}
+
+// ForEach configures a composed resource template to compose one resource for
+// each element of an array within the composite resource. Patches of type
+// FromItemFieldPath may read from each element.
+type ForEach struct {
+	// FromFieldPath is the path of an array within the composite resource.
+	// No resources are composed if the array does not exist.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// KeyFieldPath is the path of a field within each array element whose
+	// value uniquely identifies the element, for example 'name'. An element's
+	// key identifies the resource composed for it, so keys should not change.
+	// Elements are used as their own key if no path is specified, in which
+	// case they must be strings, numbers, or booleans.
+	// +optional
+	KeyFieldPath *string `json:"keyFieldPath,omitempty"`
+}
+
+// ConditionMet returns true if the template's condition evaluates to true for
+// the supplied composite resource, or if the template has no condition.
+func (ct *ComposedTemplate) ConditionMet(cp runtime.Object) (bool, error) {
+	if ct.Condition == nil {
+		return true, nil
+	}
+	cpMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cp)
+	if err != nil {
+		return false, err
+	}
+	out, err := expression.Evaluate(*ct.Condition, map[string]interface{}{"composite": cpMap})
+	if err != nil {
+		return false, errors.Wrap(err, errEvaluateCondition)
+	}
+	met, ok := out.(bool)
+	if !ok {
+		return false, errors.Errorf(errFmtConditionNotBool, out)
+	}
+	return met, nil
 }
 
 // TypeReadinessCheck is used for readiness check types.
@@ -160,17 +525,29 @@ type TypeReadinessCheck string
 
 // The possible values for readiness check type.
 const (
-	ReadinessCheckNonEmpty     TypeReadinessCheck = "NonEmpty"
-	ReadinessCheckMatchString  TypeReadinessCheck = "MatchString"
-	ReadinessCheckMatchInteger TypeReadinessCheck = "MatchInteger"
-	ReadinessCheckNone         TypeReadinessCheck = "None"
+	ReadinessCheckNonEmpty       TypeReadinessCheck = "NonEmpty"
+	ReadinessCheckMatchString    TypeReadinessCheck = "MatchString"
+	ReadinessCheckMatchInteger   TypeReadinessCheck = "MatchInteger"
+	ReadinessCheckMatchTrue      TypeReadinessCheck = "MatchTrue"
+	ReadinessCheckMatchFalse     TypeReadinessCheck = "MatchFalse"
+	ReadinessCheckMatchCondition TypeReadinessCheck = "MatchCondition"
+	ReadinessCheckNone           TypeReadinessCheck = "None"
+)
+
+// A ReadinessCheckPolicy determines how readiness checks are combined.
+type ReadinessCheckPolicy string
+
+// Readiness check policies.
+const (
+	ReadinessCheckPolicyAllOf ReadinessCheckPolicy = "AllOf"
+	ReadinessCheckPolicyAnyOf ReadinessCheckPolicy = "AnyOf"
 )
 
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
 // for consumption
 type ReadinessCheck struct {
 	// Type indicates the type of probe you'd like to use.
-	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"None"
+	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"MatchTrue";"MatchFalse";"MatchCondition";"NonEmpty";"None"
 	Type TypeReadinessCheck `json:"type"`
 
 	// FieldPath shows the path of the field whose value will be used.
@@ -184,6 +561,27 @@ type ReadinessCheck struct {
 	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
 	// +optional
 	MatchInteger int64 `json:"matchInteger,omitempty"`
+
+	// MatchCondition is the condition you'd like to match if you're using
+	// "MatchCondition" type. The condition is read from the status.conditions
+	// of the resource, so it may be used with resources that are not managed
+	// by Crossplane, such as Deployments.
+	// +optional
+	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+}
+
+// MatchConditionReadinessCheck is used to indicate how to tell whether a
+// resource is ready for consumption using one of its conditions.
+type MatchConditionReadinessCheck struct {
+	// Type indicates the type of condition you'd like to use.
+	// +kubebuilder:default="Ready"
+	Type xpv1.ConditionType `json:"type"`
+
+	// Status is the status of the condition you'd like to match. A resource
+	// that does not have the condition does not match, even if this status is
+	// Unknown.
+	// +kubebuilder:default="True"
+	Status corev1.ConditionStatus `json:"status"`
 }
 
 // A PatchType is a type of patch.
@@ -191,9 +589,14 @@ type PatchType string
 
 // Patch types.
 const (
-	PatchTypeFromCompositeFieldPath PatchType = "FromCompositeFieldPath" // Default
-	PatchTypePatchSet               PatchType = "PatchSet"
-	PatchTypeToCompositeFieldPath   PatchType = "ToCompositeFieldPath"
+	PatchTypeFromCompositeFieldPath   PatchType = "FromCompositeFieldPath" // Default
+	PatchTypePatchSet                 PatchType = "PatchSet"
+	PatchTypeToCompositeFieldPath     PatchType = "ToCompositeFieldPath"
+	PatchTypeCombineFromComposite     PatchType = "CombineFromComposite"
+	PatchTypeCombineToComposite       PatchType = "CombineToComposite"
+	PatchTypeFromComposedFieldPath    PatchType = "FromComposedFieldPath"
+	PatchTypeFromItemFieldPath        PatchType = "FromItemFieldPath"
+	PatchTypeFromEnvironmentFieldPath PatchType = "FromEnvironmentFieldPath"
 )
 
 // Patch objects are applied between composite and composed resources. Their
@@ -204,18 +607,42 @@ type Patch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its' own fields to be set on the Patch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromComposedFieldPath;FromItemFieldPath;FromEnvironmentFieldPath
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
 	// FromFieldPath is the path of the field on the upstream resource whose value
-	// to be used as input. Required when type is FromCompositeFieldPath.
+	// to be used as input. Required when type is FromCompositeFieldPath,
+	// ToCompositeFieldPath, FromComposedFieldPath, FromItemFieldPath or
+	// FromEnvironmentFieldPath. A FromItemFieldPath patch reads from the item
+	// of a forEach template, which has the fields 'key', 'index', and
+	// 'value'. A FromEnvironmentFieldPath patch reads from the environment
+	// of the Composition. The path may contain
+	// wildcards, for example spec.rules[*].port, in which case the patch is
+	// applied once for each array element or object field that matches it.
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromComposedResource is the name of the composed resource template to
+	// patch from. The value at FromFieldPath is read from the composed
+	// resource most recently observed for that template. Required when type
+	// is FromComposedFieldPath.
+	// +optional
+	FromComposedResource *string `json:"fromComposedResource,omitempty"`
+
+	// Combine is the patch configuration for a CombineFromComposite or
+	// CombineToComposite patch.
+	// +optional
+	Combine *Combine `json:"combine,omitempty"`
+
 	// ToFieldPath is the path of the field on the base resource whose value will
 	// be changed with the result of transforms. Leave empty if you'd like to
-	// propagate to the same path on the target resource.
+	// propagate to the same path on the target resource. Required when type is
+	// CombineFromComposite or CombineToComposite. If the fromFieldPath contains
+	// wildcards the toFieldPath must contain the same number of wildcards,
+	// which will be replaced by the indices or fields they matched in the
+	// fromFieldPath. Otherwise any wildcards in the toFieldPath match existing
+	// array elements or object fields of the target resource.
 	// +optional
 	ToFieldPath *string `json:"toFieldPath,omitempty"`
 
@@ -227,10 +654,133 @@ type Patch struct {
 	// input to be transformed.
 	// +optional
 	Transforms []Transform `json:"transforms,omitempty"`
+
+	// Policy configures the specifics of patching behaviour.
+	// +optional
+	Policy *PatchPolicy `json:"policy,omitempty"`
+}
+
+// A FromFieldPathPolicy determines how to patch from a field path.
+type FromFieldPathPolicy string
+
+// FromFieldPath patch policies.
+const (
+	FromFieldPathPolicyOptional FromFieldPathPolicy = "Optional"
+	FromFieldPathPolicyRequired FromFieldPathPolicy = "Required"
+)
+
+// A PatchPolicy configures the specifics of patching behaviour.
+type PatchPolicy struct {
+	// FromFieldPath specifies how to patch from a field path. The default is
+	// 'Optional', which means the patch will be a no-op if the specified
+	// fromFieldPath does not exist. Use 'Required' if the patch should fail
+	// if the specified path does not exist. A composed resource with a failed
+	// 'Required' patch is reported as unready and is not applied until the
+	// path exists; other composed resources are unaffected.
+	// +kubebuilder:validation:Enum=Optional;Required
+	// +optional
+	FromFieldPath *FromFieldPathPolicy `json:"fromFieldPath,omitempty"`
+
+	// MergeOptions specifies how to merge the patched value with any value
+	// that already exists at the toFieldPath. By default the existing value
+	// is replaced. When merge options are specified objects are merged,
+	// keeping any existing keys that the patched value does not set.
+	// +optional
+	MergeOptions *MergeOptions `json:"mergeOptions,omitempty"`
+}
+
+// MergeOptions specifies how a patched value is merged with an existing value.
+type MergeOptions struct {
+	// KeepMapValues specifies that existing values of a merged object should
+	// be preserved, rather than replaced by the values of the patched object.
+	// +optional
+	KeepMapValues *bool `json:"keepMapValues,omitempty"`
+
+	// AppendSlice specifies that a patched array should be appended to any
+	// existing array, rather than replacing it. Elements that already exist
+	// in the array are not appended again.
+	// +optional
+	AppendSlice *bool `json:"appendSlice,omitempty"`
+}
+
+// merge the supplied patched value into the supplied existing value.
+func (mo *MergeOptions) merge(existing, patched interface{}) interface{} {
+	switch p := patched.(type) {
+	case map[string]interface{}:
+		e, ok := existing.(map[string]interface{})
+		if !ok {
+			return patched
+		}
+		out := make(map[string]interface{}, len(e)+len(p))
+		for k, v := range e {
+			out[k] = v
+		}
+		for k, v := range p {
+			ev, ok := e[k]
+			switch {
+			case !ok:
+				out[k] = v
+			case mo.mergeable(ev, v):
+				out[k] = mo.merge(ev, v)
+			case mo.keepMapValues():
+				out[k] = ev
+			default:
+				out[k] = v
+			}
+		}
+		return out
+	case []interface{}:
+		e, ok := existing.([]interface{})
+		if !ok || !mo.appendSlice() {
+			return patched
+		}
+		out := make([]interface{}, len(e), len(e)+len(p))
+		copy(out, e)
+		for _, v := range p {
+			if !containsValue(out, v) {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	return patched
+}
+
+// mergeable returns true if the supplied values should be merged, rather than
+// one replacing the other.
+func (mo *MergeOptions) mergeable(existing, patched interface{}) bool {
+	switch patched.(type) {
+	case map[string]interface{}:
+		_, ok := existing.(map[string]interface{})
+		return ok
+	case []interface{}:
+		_, ok := existing.([]interface{})
+		return ok && mo.appendSlice()
+	}
+	return false
+}
+
+func (mo *MergeOptions) keepMapValues() bool {
+	return mo.KeepMapValues != nil && *mo.KeepMapValues
+}
+
+func (mo *MergeOptions) appendSlice() bool {
+	return mo.AppendSlice != nil && *mo.AppendSlice
+}
+
+func containsValue(s []interface{}, v interface{}) bool {
+	for i := range s {
+		if reflect.DeepEqual(s[i], v) {
+			return true
+		}
+	}
+	return false
 }
 
 // Apply executes a patching operation between the from and to resources.
-// Applies all patch types unless an 'only' filter is supplied.
+// Applies all patch types unless an 'only' filter is supplied. The from
+// resource must be the composite resource; it is made available to any
+// expression transforms.
 func (c *Patch) Apply(from, to runtime.Object, only ...PatchType) error {
 	if c.filterPatch(only...) {
 		return nil
@@ -238,15 +788,75 @@ func (c *Patch) Apply(from, to runtime.Object, only ...PatchType) error {
 
 	switch c.Type {
 	case PatchTypeFromCompositeFieldPath:
-		return c.applyFromFieldPathPatch(from, to)
+		return c.applyFromFieldPathPatch(from, to, from)
 	case PatchTypeToCompositeFieldPath:
-		return c.applyFromFieldPathPatch(to, from)
+		return c.applyFromFieldPathPatch(to, from, from)
+	case PatchTypeCombineFromComposite:
+		return c.applyCombineFromVariablesPatch(from, to, from)
+	case PatchTypeCombineToComposite:
+		return c.applyCombineFromVariablesPatch(to, from, from)
+	case PatchTypeFromComposedFieldPath:
+		return errors.Errorf(errFmtRequiresComposedSource, c.Type)
+	case PatchTypeFromItemFieldPath:
+		return errors.Errorf(errFmtRequiresItemSource, c.Type)
+	case PatchTypeFromEnvironmentFieldPath:
+		return errors.Errorf(errFmtRequiresEnvironment, c.Type)
 	case PatchTypePatchSet:
 		// Already resolved - nothing to do.
 	}
 	return errors.Errorf(errInvalidPatchType, c.Type)
 }
 
+// ApplyFromComposed executes a FromComposedFieldPath patch, patching the "to"
+// resource using a field of the "from" composed resource. The "from" resource
+// may be nil if the composed resource has not yet been observed. The composite
+// resource is made available to any expression transforms.
+func (c *Patch) ApplyFromComposed(cp, from, to runtime.Object) error {
+	if c.Type != PatchTypeFromComposedFieldPath {
+		return errors.Errorf(errInvalidPatchType, c.Type)
+	}
+	if c.FromComposedResource == nil {
+		return errors.Errorf(errRequiredField, "FromComposedResource", c.Type)
+	}
+	if from == nil {
+		if c.fromFieldPathRequired() {
+			return errors.Errorf(errFmtComposedNotObserved, *c.FromComposedResource)
+		}
+		// The composed resource we patch from may not have been created yet,
+		// for example when the composite resource is first reconciled. We
+		// treat this like patching from a field path that does not exist.
+		return nil
+	}
+	return c.applyFromFieldPathPatch(from, to, cp)
+}
+
+// ApplyFromItem executes a FromItemFieldPath patch, patching the "to" resource
+// using a field of the supplied forEach item. The item must be nil if the
+// patch's template is not a forEach template. The composite resource is made
+// available to any expression transforms.
+func (c *Patch) ApplyFromItem(cp runtime.Object, item map[string]interface{}, to runtime.Object) error {
+	if c.Type != PatchTypeFromItemFieldPath {
+		return errors.Errorf(errInvalidPatchType, c.Type)
+	}
+	if item == nil {
+		return errors.Errorf(errFmtRequiresItemSource, c.Type)
+	}
+	return c.applyFromFieldPathPatch(&unstructured.Unstructured{Object: item}, to, cp)
+}
+
+// ApplyFromEnvironment executes a FromEnvironmentFieldPath patch, patching the
+// "to" resource using a field of the supplied environment. The composite
+// resource is made available to any expression transforms.
+func (c *Patch) ApplyFromEnvironment(cp runtime.Object, env map[string]interface{}, to runtime.Object) error {
+	if c.Type != PatchTypeFromEnvironmentFieldPath {
+		return errors.Errorf(errInvalidPatchType, c.Type)
+	}
+	if env == nil {
+		return errors.Errorf(errFmtRequiresEnvironment, c.Type)
+	}
+	return c.applyFromFieldPathPatch(&unstructured.Unstructured{Object: env}, to, cp)
+}
+
 // filterPatch returns true if patch should be filtered (not applied)
 func (c *Patch) filterPatch(only ...PatchType) bool {
 	// filter does not apply if not set
@@ -265,7 +875,7 @@ func (c *Patch) filterPatch(only ...PatchType) bool {
 // applyFromFieldPathPatch patches the "to" resource, using a source field
 // on the "from" resource. Values may be transformed if any are defined on
 // the patch.
-func (c *Patch) applyFromFieldPathPatch(from, to runtime.Object) error { // nolint:gocyclo
+func (c *Patch) applyFromFieldPathPatch(from, to, cp runtime.Object) error { // nolint:gocyclo
 	// NOTE(benagricola): The cyclomatic complexity here is from error checking
 	// at each stage of the patching process, in addition to Apply methods now
 	// being responsible for checking the validity of their input fields
@@ -285,7 +895,14 @@ func (c *Patch) applyFromFieldPathPatch(from, to runtime.Object) error { // noli
 		return err
 	}
 
+	if hasWildcards(*c.FromFieldPath) {
+		return c.applyWildcardFromFieldPathPatch(fieldpath.Pave(fromMap), to, cp)
+	}
+
 	in, err := fieldpath.Pave(fromMap).GetValue(*c.FromFieldPath)
+	if fieldpath.IsNotFound(err) && c.fromFieldPathRequired() {
+		return requiredFieldPathNotFound(err, *c.FromFieldPath)
+	}
 	if fieldpath.IsNotFound(err) {
 		// A composition may want to opportunistically patch from a field path
 		// that may or may not exist in the composite, for example by patching
@@ -298,74 +915,545 @@ func (c *Patch) applyFromFieldPathPatch(from, to runtime.Object) error { // noli
 	if err != nil {
 		return err
 	}
-	out := in
-	for i, f := range c.Transforms {
-		if out, err = f.Transform(out); err != nil {
-			return errors.Wrapf(err, errFmtTransformAtIndex, i)
-		}
-	}
 
-	if u, ok := to.(interface{ UnstructuredContent() map[string]interface{} }); ok {
-		return fieldpath.Pave(u.UnstructuredContent()).SetValue(*c.ToFieldPath, out)
+	out, err := c.applyTransforms(in, cp)
+	if err != nil {
+		return err
 	}
 
-	toMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(to)
+	return patchFieldValueToObject(*c.ToFieldPath, out, to, c.mergeOptions())
+}
+
+// applyWildcardFromFieldPathPatch patches the "to" resource using each field
+// of the "from" resource that matches the patch's fromFieldPath. The wildcards
+// of the toFieldPath are replaced by the array indices or object fields that
+// the corresponding wildcards of the fromFieldPath matched.
+func (c *Patch) applyWildcardFromFieldPathPatch(from *fieldpath.Paved, to, cp runtime.Object) error {
+	fromSegments, err := fieldpath.Parse(*c.FromFieldPath)
 	if err != nil {
 		return err
 	}
-	if err := fieldpath.Pave(toMap).SetValue(*c.ToFieldPath, out); err != nil {
+	toSegments, err := fieldpath.Parse(*c.ToFieldPath)
+	if err != nil {
 		return err
 	}
-	return runtime.DefaultUnstructuredConverter.FromUnstructured(toMap, to)
+	if countWildcards(fromSegments) != countWildcards(toSegments) {
+		return errors.Errorf(errFmtWildcardMismatch, *c.ToFieldPath, *c.FromFieldPath)
+	}
+
+	matched, err := expandWildcards(from, fromSegments)
+	if fieldpath.IsNotFound(err) && c.fromFieldPathRequired() {
+		return requiredFieldPathNotFound(err, *c.FromFieldPath)
+	}
+	if fieldpath.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(matched))
+	values := make([]interface{}, 0, len(matched))
+	for _, m := range matched {
+		in, err := from.GetValue(m.String())
+		if fieldpath.IsNotFound(err) && c.fromFieldPathRequired() {
+			return requiredFieldPathNotFound(err, m.String())
+		}
+		if fieldpath.IsNotFound(err) {
+			// Like a patch from a single optional field path, we skip any
+			// matched element that does not have the field we patch from.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		out, err := c.applyTransforms(in, cp)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, replaceWildcards(toSegments, wildcardMatches(fromSegments, m)).String())
+		values = append(values, out)
+	}
+
+	mo := c.mergeOptions()
+	return patchObject(to, func(p *fieldpath.Paved) error {
+		for i := range paths {
+			if err := setValue(p, paths[i], values[i], mo); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// TransformType is type of the transform function to be chosen.
-type TransformType string
+// applyCombineFromVariablesPatch patches the "to" resource, taking a list of
+// input variables and combining them into a single output value. The single
+// output value may then be further transformed if they are defined on the
+// patch.
+func (c *Patch) applyCombineFromVariablesPatch(from, to, cp runtime.Object) error {
+	if c.Combine == nil {
+		return errors.Errorf(errRequiredField, "Combine", c.Type)
+	}
 
-// Accepted TransformTypes.
-const (
-	TransformTypeMap     TransformType = "map"
-	TransformTypeMath    TransformType = "math"
-	TransformTypeString  TransformType = "string"
-	TransformTypeConvert TransformType = "convert"
-)
+	// A combine patch reads from multiple field paths, so there is no single
+	// path we could default ToFieldPath to.
+	if c.ToFieldPath == nil {
+		return errors.Errorf(errRequiredField, "ToFieldPath", c.Type)
+	}
 
-// Transform is a unit of process whose input is transformed into an output with
-// the supplied configuration.
-type Transform struct {
+	if len(c.Combine.Variables) == 0 {
+		return errors.New(errCombineRequiresVariables)
+	}
 
-	// Type of the transform to be run.
-	Type TransformType `json:"type"`
+	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+	if err != nil {
+		return err
+	}
 
-	// Math is used to transform the input via mathematical operations such as
-	// multiplication.
-	// +optional
-	Math *MathTransform `json:"math,omitempty"`
+	in := make([]interface{}, len(c.Combine.Variables))
+	for i, v := range c.Combine.Variables {
+		iv, err := fieldpath.Pave(fromMap).GetValue(v.FromFieldPath)
+		if fieldpath.IsNotFound(err) && c.fromFieldPathRequired() {
+			return requiredFieldPathNotFound(err, v.FromFieldPath)
+		}
+		if fieldpath.IsNotFound(err) {
+			// We don't apply the patch unless every variable is present. A
+			// combine strategy typically expects a fixed number of inputs,
+			// e.g. a format string of '%s-%s-%s', so combining a partial set
+			// of variables would produce a surprising result.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		in[i] = iv
+	}
 
-	// Map uses the input as a key in the given map and returns the value.
-	// +optional
-	Map *MapTransform `json:"map,omitempty"`
+	cb, err := c.Combine.Combine(in)
+	if err != nil {
+		return err
+	}
 
-	// String is used to transform the input into a string or a different kind
-	// of string. Note that the input does not necessarily need to be a string.
-	// +optional
-	String *StringTransform `json:"string,omitempty"`
+	out, err := c.applyTransforms(cb, cp)
+	if err != nil {
+		return err
+	}
 
-	// Convert is used to cast the input into the given output type.
-	// +optional
-	Convert *ConvertTransform `json:"convert,omitempty"`
+	return patchFieldValueToObject(*c.ToFieldPath, out, to, c.mergeOptions())
 }
 
-// Transform calls the appropriate Transformer.
-func (t *Transform) Transform(input interface{}) (interface{}, error) {
+// A requiredFieldPathError indicates that a patch could not be applied because
+// a field path it requires does not exist.
+type requiredFieldPathError struct {
+	error
+}
+
+// Cause returns the underlying cause of the error.
+func (e requiredFieldPathError) Cause() error {
+	return e.error
+}
+
+func requiredFieldPathNotFound(err error, path string) error {
+	return requiredFieldPathError{errors.Wrapf(err, errFmtRequiredFieldPath, path)}
+}
+
+// IsRequiredFieldPathNotFound returns true if the supplied error indicates that
+// a patch could not be applied because a field path it requires does not
+// exist.
+func IsRequiredFieldPathNotFound(err error) bool {
+	for err != nil {
+		if _, ok := err.(requiredFieldPathError); ok {
+			return true
+		}
+		c, ok := err.(interface{ Cause() error })
+		if !ok {
+			return false
+		}
+		err = c.Cause()
+	}
+	return false
+}
+
+// fromFieldPathRequired returns true if the patch must fail when a field path
+// it patches from does not exist.
+func (c *Patch) fromFieldPathRequired() bool {
+	if c.Policy == nil || c.Policy.FromFieldPath == nil {
+		return false
+	}
+	return *c.Policy.FromFieldPath == FromFieldPathPolicyRequired
+}
+
+// mergeOptions returns the patch's merge options, if any.
+func (c *Patch) mergeOptions() *MergeOptions {
+	if c.Policy == nil {
+		return nil
+	}
+	return c.Policy.MergeOptions
+}
+
+// applyTransforms applies the patch's transforms, in order, to the supplied
+// input value. The supplied composite resource is made available to any
+// expression transforms.
+func (c *Patch) applyTransforms(in interface{}, cp runtime.Object) (interface{}, error) {
+	var cpMap map[string]interface{}
+	for _, f := range c.Transforms {
+		if f.Type != TransformTypeExpression {
+			continue
+		}
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cp)
+		if err != nil {
+			return nil, err
+		}
+		cpMap = m
+		break
+	}
+
+	var err error
+	out := in
+	for i, f := range c.Transforms {
+		if out, err = f.transform(out, cpMap); err != nil {
+			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i)
+		}
+	}
+	return out, nil
+}
+
+// patchFieldValueToObject sets the supplied value at the supplied field path
+// of the supplied object. The value is merged with any existing value if merge
+// options are supplied. Any wildcards in the field path match existing array
+// elements or object fields of the supplied object.
+func patchFieldValueToObject(path string, value interface{}, to runtime.Object, mo *MergeOptions) error {
+	return patchObject(to, func(p *fieldpath.Paved) error {
+		return setValue(p, path, value, mo)
+	})
+}
+
+// patchObject calls the supplied function with the supplied object, paved.
+func patchObject(to runtime.Object, fn func(p *fieldpath.Paved) error) error {
+	if u, ok := to.(interface{ UnstructuredContent() map[string]interface{} }); ok {
+		return fn(fieldpath.Pave(u.UnstructuredContent()))
+	}
+
+	toMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(to)
+	if err != nil {
+		return err
+	}
+	if err := fn(fieldpath.Pave(toMap)); err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(toMap, to)
+}
+
+func setValue(p *fieldpath.Paved, path string, value interface{}, mo *MergeOptions) error {
+	if !hasWildcards(path) {
+		return setOrMergeValue(p, path, value, mo)
+	}
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		return err
+	}
+	paths, err := expandWildcards(p, segments)
+	if fieldpath.IsNotFound(err) {
+		// There is nothing for the wildcards to match.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, s := range paths {
+		if err := setOrMergeValue(p, s.String(), value, mo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setOrMergeValue(p *fieldpath.Paved, path string, value interface{}, mo *MergeOptions) error {
+	if mo == nil {
+		return p.SetValue(path, value)
+	}
+	existing, err := p.GetValue(path)
+	if fieldpath.IsNotFound(err) {
+		return p.SetValue(path, value)
+	}
+	if err != nil {
+		return err
+	}
+	return p.SetValue(path, mo.merge(existing, value))
+}
+
+// wildcard is a field path segment that matches every element of an array or
+// every field of an object, e.g. spec.rules[*].port.
+const wildcard = "*"
+
+func isWildcard(s fieldpath.Segment) bool {
+	return s.Type == fieldpath.SegmentField && s.Field == wildcard
+}
+
+// hasWildcards returns true if the supplied field path contains wildcards.
+// Invalid field paths are treated as having none; parsing them will fail.
+func hasWildcards(path string) bool {
+	s, err := fieldpath.Parse(path)
+	if err != nil {
+		return false
+	}
+	return countWildcards(s) > 0
+}
+
+func countWildcards(s fieldpath.Segments) int {
+	n := 0
+	for i := range s {
+		if isWildcard(s[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// expandWildcards returns every field path of the supplied object that matches
+// the supplied field path segments. It returns an error satisfying
+// fieldpath.IsNotFound if an array or object that a wildcard would match does
+// not exist.
+func expandWildcards(p *fieldpath.Paved, s fieldpath.Segments) ([]fieldpath.Segments, error) {
+	return expandFrom(p, fieldpath.Segments{}, s)
+}
+
+func expandFrom(p *fieldpath.Paved, prefix, s fieldpath.Segments) ([]fieldpath.Segments, error) {
+	for i := range s {
+		if !isWildcard(s[i]) {
+			continue
+		}
+
+		parent := append(append(fieldpath.Segments{}, prefix...), s[:i]...)
+		var v interface{} = p.UnstructuredContent()
+		if len(parent) > 0 {
+			pv, err := p.GetValue(parent.String())
+			if err != nil {
+				return nil, err
+			}
+			v = pv
+		}
+
+		var children fieldpath.Segments
+		switch t := v.(type) {
+		case []interface{}:
+			for j := range t {
+				children = append(children, fieldpath.Segment{Type: fieldpath.SegmentIndex, Index: uint(j)})
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			// Sort the keys so that patches are applied in a stable order.
+			sort.Strings(keys)
+			for _, k := range keys {
+				children = append(children, fieldpath.Segment{Type: fieldpath.SegmentField, Field: k})
+			}
+		default:
+			return nil, errors.Errorf(errFmtWildcardNotExpandable, parent.String())
+		}
+
+		out := make([]fieldpath.Segments, 0, len(children))
+		for _, child := range children {
+			expanded, err := expandFrom(p, append(append(fieldpath.Segments{}, parent...), child), s[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, expanded...)
+		}
+		return out, nil
+	}
+	return []fieldpath.Segments{append(append(fieldpath.Segments{}, prefix...), s...)}, nil
+}
+
+// wildcardMatches returns the segments of the supplied expanded field path
+// that the wildcards of the supplied field path matched.
+func wildcardMatches(wildcarded, expanded fieldpath.Segments) fieldpath.Segments {
+	out := fieldpath.Segments{}
+	for i := range wildcarded {
+		if isWildcard(wildcarded[i]) {
+			out = append(out, expanded[i])
+		}
+	}
+	return out
+}
+
+// replaceWildcards replaces the wildcards of the supplied field path with the
+// supplied segments, in order.
+func replaceWildcards(wildcarded, matches fieldpath.Segments) fieldpath.Segments {
+	out := make(fieldpath.Segments, len(wildcarded))
+	n := 0
+	for i := range wildcarded {
+		out[i] = wildcarded[i]
+		if isWildcard(wildcarded[i]) {
+			out[i] = matches[n]
+			n++
+		}
+	}
+	return out
+}
+
+// A CombineVariable defines the source of a value that is combined with others
+// to form and patch an output value. Currently this only supports retrieving
+// values from a field path.
+type CombineVariable struct {
+	// FromFieldPath is the path of the field on the source whose value is to
+	// be used as input.
+	FromFieldPath string `json:"fromFieldPath"`
+}
+
+// A CombineStrategy determines what strategy will be applied to combine
+// variables.
+type CombineStrategy string
+
+// Accepted CombineStrategies.
+const (
+	CombineStrategyString CombineStrategy = "string"
+)
+
+// A Combine configures a patch that combines more than one input field into a
+// single output field.
+type Combine struct {
+	// Variables are the list of variables whose values will be retrieved and
+	// combined.
+	// +kubebuilder:validation:MinItems=1
+	Variables []CombineVariable `json:"variables"`
+
+	// Strategy defines the strategy to use to combine the input variable
+	// values. Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that input variables should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
+}
+
+// Combine calls the appropriate combiner.
+func (c *Combine) Combine(vars []interface{}) (interface{}, error) {
+	var combiner interface {
+		Combine(vars []interface{}) (interface{}, error)
+	}
+
+	switch c.Strategy {
+	case CombineStrategyString:
+		combiner = c.String
+	default:
+		return nil, errors.Errorf(errFmtCombineStrategyNotSupported, string(c.Strategy))
+	}
+
+	// See the NOTE in Transform about checking for nil interface values.
+	if reflect.ValueOf(combiner).IsNil() {
+		return nil, errors.Errorf(errFmtCombineConfigMissing, string(c.Strategy))
+	}
+
+	out, err := combiner.Combine(vars)
+	return out, errors.Wrapf(err, errFmtCombineStrategyFailed, string(c.Strategy))
+}
+
+// A StringCombine combines multiple input values into a single string.
+type StringCombine struct {
+	// Format the input using a Go format string. See
+	// https://golang.org/pkg/fmt/ for details.
+	Format string `json:"fmt"`
+}
+
+// Combine returns a single output by running a string format with all of its
+// input variables.
+func (s *StringCombine) Combine(vars []interface{}) (interface{}, error) {
+	return fmt.Sprintf(s.Format, vars...), nil
+}
+
+// TransformType is type of the transform function to be chosen.
+type TransformType string
+
+// Accepted TransformTypes.
+const (
+	TransformTypeMap     TransformType = "map"
+	TransformTypeMatch   TransformType = "match"
+	TransformTypeMath    TransformType = "math"
+	TransformTypeString  TransformType = "string"
+	TransformTypeConvert TransformType = "convert"
+
+	TransformTypeExpression TransformType = "expression"
+)
+
+// Transform is a unit of process whose input is transformed into an output with
+// the supplied configuration.
+type Transform struct {
+
+	// Type of the transform to be run.
+	Type TransformType `json:"type"`
+
+	// Math is used to transform the input via mathematical operations such as
+	// multiplication.
+	// +optional
+	Math *MathTransform `json:"math,omitempty"`
+
+	// Map uses the input as a key in the given map and returns the value.
+	// +optional
+	Map *MapTransform `json:"map,omitempty"`
+
+	// MapFallback determines what a map transform returns when the input
+	// matches none of the keys of its map. The transform returns an error if
+	// the input matches no key and no fallback is specified.
+	// +optional
+	MapFallback *MapFallback `json:"mapFallback,omitempty"`
+
+	// Match uses the first of the given patterns that matches the input to
+	// determine the output, falling back to a default if no pattern matches.
+	// +optional
+	Match *MatchTransform `json:"match,omitempty"`
+
+	// String is used to transform the input into a string or a different kind
+	// of string. Note that the input does not necessarily need to be a string.
+	// +optional
+	String *StringTransform `json:"string,omitempty"`
+
+	// Convert is used to cast the input into the given output type.
+	// +optional
+	Convert *ConvertTransform `json:"convert,omitempty"`
+
+	// Expression is used to compute the output by evaluating an expression
+	// against the input and the composite resource.
+	// +optional
+	Expression *ExpressionTransform `json:"expression,omitempty"`
+}
+
+// Transform calls the appropriate Transformer. Expression transforms are
+// evaluated against an empty composite resource.
+func (t *Transform) Transform(input interface{}) (interface{}, error) {
+	return t.transform(input, nil)
+}
+
+// transform calls the appropriate Transformer, making the supplied composite
+// resource available to expression transforms.
+func (t *Transform) transform(input interface{}, cp map[string]interface{}) (interface{}, error) {
+	if t.Type == TransformTypeExpression {
+		if t.Expression == nil {
+			return nil, errors.Errorf(errFmtConfigMissing, string(t.Type))
+		}
+		out, err := t.Expression.Evaluate(input, cp)
+		return out, errors.Wrapf(err, errFmtTransformTypeFailed, string(t.Type))
+	}
+	if t.Type == TransformTypeMap {
+		if t.Map == nil {
+			return nil, errors.Errorf(errFmtConfigMissing, string(t.Type))
+		}
+		out, err := t.Map.ResolveWithFallback(input, t.MapFallback)
+		return out, errors.Wrapf(err, errFmtTransformTypeFailed, string(t.Type))
+	}
+
 	var transformer interface {
 		Resolve(input interface{}) (interface{}, error)
 	}
 	switch t.Type {
 	case TransformTypeMath:
 		transformer = t.Math
-	case TransformTypeMap:
-		transformer = t.Map
+	case TransformTypeMatch:
+		transformer = t.Match
 	case TransformTypeString:
 		transformer = t.String
 	case TransformTypeConvert:
@@ -383,43 +1471,347 @@ func (t *Transform) Transform(input interface{}) (interface{}, error) {
 	return out, errors.Wrapf(err, errFmtTransformTypeFailed, string(t.Type))
 }
 
+// An ExpressionTransform computes its output by evaluating an expression.
+// Expressions may use conditionals (c ? a : b), arithmetic, comparisons,
+// boolean logic, list and map access (a.b, a["b"], a[0]), has(a.b) to test
+// whether a field exists, and the functions size, string, int, double, lower,
+// upper, trim, trimPrefix, trimSuffix, contains, startsWith, endsWith,
+// replace, split, join, substring, matches, min and max. The input value is
+// available as the variable 'input', and the composite resource as the
+// variable 'composite'. Expressions cannot loop and the cost of evaluating
+// them is bounded, so evaluation always terminates quickly.
+type ExpressionTransform struct {
+	// Expression to evaluate, for example
+	// 'composite.spec.parameters.size == "large" ? input * 2 : input'.
+	// +kubebuilder:validation:MaxLength=4096
+	Expression string `json:"expr"`
+}
+
+// Evaluate the expression against the supplied input and composite resource.
+func (e *ExpressionTransform) Evaluate(input interface{}, cp map[string]interface{}) (interface{}, error) {
+	if cp == nil {
+		cp = map[string]interface{}{}
+	}
+	return expression.Evaluate(e.Expression, map[string]interface{}{
+		"input":     input,
+		"composite": cp,
+	})
+}
+
+// A MathTransformType is a type of math transform.
+type MathTransformType string
+
+// Accepted MathTransformTypes.
+const (
+	MathTransformTypeMultiply MathTransformType = "Multiply" // Default
+	MathTransformTypeAdd      MathTransformType = "Add"
+	MathTransformTypeSubtract MathTransformType = "Subtract"
+	MathTransformTypeDivide   MathTransformType = "Divide"
+	MathTransformTypeClampMin MathTransformType = "ClampMin"
+	MathTransformTypeClampMax MathTransformType = "ClampMax"
+	MathTransformTypeRound    MathTransformType = "Round"
+)
+
+// A MathRoundingMode determines how a fractional value is rounded to an
+// integer.
+type MathRoundingMode string
+
+// Accepted MathRoundingModes.
+const (
+	MathRoundingModeRound    MathRoundingMode = "Round" // Default
+	MathRoundingModeFloor    MathRoundingMode = "Floor"
+	MathRoundingModeCeil     MathRoundingMode = "Ceil"
+	MathRoundingModeTruncate MathRoundingMode = "Truncate"
+)
+
+// A MathOperand is an integer or decimal operand of a math transform, for
+// example 2 or 0.5. It must be specified as a JSON number; the number is kept
+// as written so that integer operands are not converted to floats.
+// +kubebuilder:validation:Type=number
+type MathOperand string
+
+// UnmarshalJSON into this MathOperand.
+func (o *MathOperand) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if !isJSONNumber(s) {
+		return errors.Errorf(errFmtMathOperandNotNumber, s)
+	}
+	*o = MathOperand(s)
+	return nil
+}
+
+// MarshalJSON from this MathOperand.
+func (o MathOperand) MarshalJSON() ([]byte, error) {
+	if !isJSONNumber(string(o)) {
+		return nil, errors.Errorf(errFmtMathOperandNotNumber, string(o))
+	}
+	return []byte(o), nil
+}
+
+// value returns the operand as both an int64 and a float64, and whether the
+// operand is an integer.
+func (o MathOperand) value() (int64, float64, bool, error) {
+	if i, err := strconv.ParseInt(string(o), 10, 64); err == nil {
+		return i, float64(i), true, nil
+	}
+	if !isJSONNumber(string(o)) {
+		return 0, 0, false, errors.Errorf(errFmtMathOperandNotNumber, string(o))
+	}
+	f, err := strconv.ParseFloat(string(o), 64)
+	if err != nil {
+		return 0, 0, false, errors.Wrapf(err, errFmtMathOperandNotNumber, string(o))
+	}
+	return int64(f), f, false, nil
+}
+
+// isJSONNumber returns true if the supplied string is a finite JSON number.
+func isJSONNumber(s string) bool {
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) {
+		return false
+	}
+	f := 0.0
+	return json.Unmarshal([]byte(s), &f) == nil
+}
+
 // MathTransform conducts mathematical operations on the input with the given
-// configuration in its properties.
+// configuration in its properties. The input and operand may each be an
+// integer or a decimal number.
 type MathTransform struct {
-	// Multiply the value.
+	// Type of the math transform to be run.
+	// +optional
+	// +kubebuilder:validation:Enum=Multiply;Add;Subtract;Divide;ClampMin;ClampMax;Round
+	// +kubebuilder:default=Multiply
+	Type MathTransformType `json:"type,omitempty"`
+
+	// Multiply the value. Required when type is Multiply.
+	// +optional
+	Multiply *MathOperand `json:"multiply,omitempty"`
+
+	// Add to the value. Required when type is Add.
+	// +optional
+	Add *MathOperand `json:"add,omitempty"`
+
+	// Subtract from the value. Required when type is Subtract.
+	// +optional
+	Subtract *MathOperand `json:"subtract,omitempty"`
+
+	// Divide the value. Required when type is Divide.
+	// +optional
+	Divide *MathOperand `json:"divide,omitempty"`
+
+	// ClampMin makes sure that the value is not smaller than the given value.
+	// Required when type is ClampMin.
+	// +optional
+	ClampMin *MathOperand `json:"clampMin,omitempty"`
+
+	// ClampMax makes sure that the value is not bigger than the given value.
+	// Required when type is ClampMax.
+	// +optional
+	ClampMax *MathOperand `json:"clampMax,omitempty"`
+
+	// RoundingMode determines how a fractional value is rounded, either when
+	// type is Round or when a fractional result must be output as an integer.
+	// Defaults to Round, which rounds half away from zero.
 	// +optional
-	Multiply *int64 `json:"multiply,omitempty"`
+	// +kubebuilder:validation:Enum=Round;Floor;Ceil;Truncate
+	RoundingMode *MathRoundingMode `json:"roundingMode,omitempty"`
+
+	// OutputType is the type of the output of this transform. Defaults to int
+	// if both the input and the operand are integers, and to float64
+	// otherwise.
+	// +optional
+	// +kubebuilder:validation:Enum=int;float64
+	OutputType *string `json:"outputType,omitempty"`
+}
+
+// operand returns the operand of the transform's type.
+func (m *MathTransform) operand() (*MathOperand, error) {
+	var op *MathOperand
+	switch m.Type {
+	case "", MathTransformTypeMultiply:
+		if m.Multiply == nil {
+			return nil, errors.New(errMathNoMultiplier)
+		}
+		return m.Multiply, nil
+	case MathTransformTypeAdd:
+		op = m.Add
+	case MathTransformTypeSubtract:
+		op = m.Subtract
+	case MathTransformTypeDivide:
+		op = m.Divide
+	case MathTransformTypeClampMin:
+		op = m.ClampMin
+	case MathTransformTypeClampMax:
+		op = m.ClampMax
+	default:
+		return nil, errors.Errorf(errFmtMathTypeNotSupported, m.Type)
+	}
+	if op == nil {
+		return nil, errors.Errorf(errFmtMathNoOperand, m.Type)
+	}
+	return op, nil
 }
 
 // Resolve runs the Math transform.
-func (m *MathTransform) Resolve(input interface{}) (interface{}, error) {
-	if m.Multiply == nil {
-		return nil, errors.New(errMathNoMultiplier)
+func (m *MathTransform) Resolve(input interface{}) (interface{}, error) { // nolint:gocyclo
+	// NOTE(negz): The cyclomatic complexity here comes from the mandatory
+	// repetitiveness of the switch clauses - each operation is simple.
+
+	i, f, isInt, err := toNumber(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// A Round transform has no operand.
+	var opi int64
+	var opf float64
+	opIsInt := true
+	if m.Type != MathTransformTypeRound {
+		op, err := m.operand()
+		if err != nil {
+			return nil, err
+		}
+		if opi, opf, opIsInt, err = op.value(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Integer inputs remain integers for operations on integer operands that
+	// cannot produce a fractional result, in order to avoid losing precision
+	// to float64.
+	if isInt && opIsInt {
+		switch m.Type { // nolint:exhaustive
+		case "", MathTransformTypeMultiply:
+			return m.output(i * opi)
+		case MathTransformTypeAdd:
+			return m.output(i + opi)
+		case MathTransformTypeSubtract:
+			return m.output(i - opi)
+		case MathTransformTypeClampMin:
+			if i < opi {
+				return m.output(opi)
+			}
+			return m.output(i)
+		case MathTransformTypeClampMax:
+			if i > opi {
+				return m.output(opi)
+			}
+			return m.output(i)
+		case MathTransformTypeRound:
+			return m.output(i)
+		}
+		// Division may produce a fractional result. Handled below.
+	}
+
+	switch m.Type {
+	case "", MathTransformTypeMultiply:
+		f *= opf
+	case MathTransformTypeAdd:
+		f += opf
+	case MathTransformTypeSubtract:
+		f -= opf
+	case MathTransformTypeDivide:
+		if opf == 0 {
+			return nil, errors.New(errMathDivideByZero)
+		}
+		f /= opf
+	case MathTransformTypeClampMin:
+		f = math.Max(f, opf)
+	case MathTransformTypeClampMax:
+		f = math.Min(f, opf)
+	case MathTransformTypeRound:
+		f = float64(m.round(f))
+	}
+
+	// A fractional result of an integer input and operand remains an integer
+	// by default.
+	if isInt && opIsInt && m.OutputType == nil {
+		return m.round(f), nil
+	}
+	return m.outputFloat(f)
+}
+
+// output returns the supplied integer as the desired output type.
+func (m *MathTransform) output(i int64) (interface{}, error) {
+	if m.OutputType == nil {
+		return i, nil
+	}
+	switch *m.OutputType {
+	case ConvertTransformTypeInt:
+		return i, nil
+	case ConvertTransformTypeFloat64:
+		return float64(i), nil
+	}
+	return nil, errors.Errorf(errFmtMathOutputTypeNotSupported, *m.OutputType)
+}
+
+// outputFloat returns the supplied float as the desired output type, rounding
+// it if necessary.
+func (m *MathTransform) outputFloat(f float64) (interface{}, error) {
+	if m.OutputType == nil {
+		return f, nil
+	}
+	switch *m.OutputType {
+	case ConvertTransformTypeInt:
+		return m.round(f), nil
+	case ConvertTransformTypeFloat64:
+		return f, nil
 	}
+	return nil, errors.Errorf(errFmtMathOutputTypeNotSupported, *m.OutputType)
+}
+
+// round the supplied float to an integer per the transform's rounding mode.
+func (m *MathTransform) round(f float64) int64 {
+	mode := MathRoundingModeRound
+	if m.RoundingMode != nil {
+		mode = *m.RoundingMode
+	}
+	switch mode {
+	case MathRoundingModeFloor:
+		return int64(math.Floor(f))
+	case MathRoundingModeCeil:
+		return int64(math.Ceil(f))
+	case MathRoundingModeTruncate:
+		return int64(math.Trunc(f))
+	case MathRoundingModeRound:
+		return int64(math.Round(f))
+	}
+	return int64(math.Round(f))
+}
+
+// toNumber returns the supplied input as both an int64 and a float64, and
+// whether the input was an integer.
+func toNumber(input interface{}) (int64, float64, bool, error) {
 	switch i := input.(type) {
-	case int64:
-		return *m.Multiply * i, nil
 	case int:
-		return *m.Multiply * int64(i), nil
-	default:
-		return nil, errors.New(errMathInputNonNumber)
+		return int64(i), float64(i), true, nil
+	case int32:
+		return int64(i), float64(i), true, nil
+	case int64:
+		return i, float64(i), true, nil
+	case float32:
+		return int64(i), float64(i), false, nil
+	case float64:
+		return int64(i), i, false, nil
 	}
+	return 0, 0, false, errors.New(errMathInputNonNumber)
 }
 
 // MapTransform returns a value for the input from the given map.
 type MapTransform struct {
 	// TODO(negz): Are Pairs really optional if a MapTransform was specified?
 
-	// Pairs is the map that will be used for transform.
+	// Pairs is the map that will be used for transform. Values may be of any
+	// type, including objects and arrays.
 	// +optional
-	Pairs map[string]string `json:",inline"`
+	Pairs map[string]extv1.JSON `json:",inline"`
 }
 
 // NOTE(negz): The Kubernetes JSON decoder doesn't seem to like inlining a map
 // into a struct - doing so results in a seemingly successful unmarshal of the
 // data, but an empty map. We must keep the ,inline tag nevertheless in order to
 // trick the CRD generator into thinking MapTransform is an arbitrary map (i.e.
-// generating a validation schema with string additionalProperties), but the
+// generating a validation schema with arbitrary additionalProperties), but the
 // actual marshalling is handled by the marshal methods below.
 
 // UnmarshalJSON into this MapTransform.
@@ -432,30 +1824,392 @@ func (m MapTransform) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.Pairs)
 }
 
-// Resolve runs the Map transform.
+// Resolve runs the Map transform. Inputs that are not strings are matched by
+// their string form, e.g. the integer 3 matches the key "3". An error is
+// returned if the input matches no key.
 func (m *MapTransform) Resolve(input interface{}) (interface{}, error) {
+	return m.ResolveWithFallback(input, nil)
+}
+
+// ResolveWithFallback runs the Map transform, using the supplied fallback if
+// the input matches no key. An error is returned if the input matches no key
+// and the fallback is nil.
+func (m *MapTransform) ResolveWithFallback(input interface{}, fb *MapFallback) (interface{}, error) {
+	k, ok := matchKey(input)
+	if !ok {
+		return nil, errors.Errorf(errFmtMapTypeNotSupported, fmt.Sprintf("%T", input))
+	}
+	if val, ok := m.Pairs[k]; ok {
+		out, err := decodeJSON(val)
+		return out, errors.Wrap(err, errMapDecodeValue)
+	}
+	if fb == nil {
+		return nil, errors.Errorf(errFmtMapNotFound, k)
+	}
+
+	switch fb.To {
+	case "", MatchFallbackToValue:
+		if fb.Value == nil {
+			return nil, errors.Errorf(errFmtMapNotFound, k)
+		}
+		out, err := decodeJSON(*fb.Value)
+		return out, errors.Wrap(err, errMapDecodeValue)
+	case MatchFallbackToInput:
+		return input, nil
+	}
+	return nil, errors.Errorf(errFmtMapFallbackToNotSupported, fb.To)
+}
+
+// A MapFallback determines what a map transform returns when its input matches
+// none of the keys of its map.
+type MapFallback struct {
+	// Value is returned when the input matches no key and to is Value. The
+	// transform returns an error if the input matches no key and no value is
+	// specified.
+	// +optional
+	Value *extv1.JSON `json:"value,omitempty"`
+
+	// To determines what is returned when the input matches no key. Value
+	// returns the value, while Input returns the input unchanged.
+	// +optional
+	// +kubebuilder:validation:Enum=Value;Input
+	// +kubebuilder:default=Value
+	To MatchFallbackTo `json:"to,omitempty"`
+}
+
+// A MatchTransformPatternType is a type of match transform pattern.
+type MatchTransformPatternType string
+
+// Accepted MatchTransformPatternTypes.
+const (
+	MatchTransformPatternTypeLiteral MatchTransformPatternType = "literal" // Default
+	MatchTransformPatternTypeRegexp  MatchTransformPatternType = "regexp"
+)
+
+// A MatchFallbackTo determines what a match transform outputs when the input
+// matches none of its patterns.
+type MatchFallbackTo string
+
+// Accepted MatchFallbackTos.
+const (
+	MatchFallbackToValue MatchFallbackTo = "Value" // Default
+	MatchFallbackToInput MatchFallbackTo = "Input"
+)
+
+// MatchTransform returns the result of the first pattern that matches the
+// input, or a fallback if no pattern matches. Inputs that are not strings are
+// matched by their string form.
+type MatchTransform struct {
+	// Patterns is the list of patterns to match the input against. Patterns
+	// are evaluated in order; the result of the first match is returned.
+	// +optional
+	Patterns []MatchTransformPattern `json:"patterns,omitempty"`
+
+	// FallbackValue is the value returned when no pattern matches the input
+	// and fallbackTo is Value. The transform returns an error if no pattern
+	// matches and no fallback value is specified.
+	// +optional
+	FallbackValue *extv1.JSON `json:"fallbackValue,omitempty"`
+
+	// FallbackTo determines what is returned when no pattern matches the
+	// input. Value returns the fallbackValue, while Input returns the input
+	// unchanged.
+	// +optional
+	// +kubebuilder:validation:Enum=Value;Input
+	// +kubebuilder:default=Value
+	FallbackTo MatchFallbackTo `json:"fallbackTo,omitempty"`
+}
+
+// A MatchTransformPattern is a pattern that a match transform uses to match
+// its input, and the result to return if it does.
+type MatchTransformPattern struct {
+	// Type of the pattern.
+	// +optional
+	// +kubebuilder:validation:Enum=literal;regexp
+	// +kubebuilder:default=literal
+	Type MatchTransformPatternType `json:"type,omitempty"`
+
+	// Literal exactly matches the input. Required when type is literal.
+	// +optional
+	Literal *string `json:"literal,omitempty"`
+
+	// Regexp to match against the input. The input matches if any part of it
+	// matches the regular expression; use ^ and $ to match the whole input.
+	// Required when type is regexp. See https://github.com/google/re2/wiki/Syntax
+	// for the supported syntax.
+	// +optional
+	Regexp *string `json:"regexp,omitempty"`
+
+	// Result to return if the input matches this pattern. May be of any type,
+	// including objects and arrays.
+	Result extv1.JSON `json:"result"`
+}
+
+// Resolve runs the Match transform.
+func (m *MatchTransform) Resolve(input interface{}) (interface{}, error) {
+	k, ok := matchKey(input)
+	if !ok {
+		return nil, errors.Errorf(errFmtMatchTypeNotSupported, fmt.Sprintf("%T", input))
+	}
+	for i, p := range m.Patterns {
+		matched, err := p.matches(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtMatchPatternAtIndex, i)
+		}
+		if matched {
+			out, err := decodeJSON(p.Result)
+			return out, errors.Wrap(err, errMatchDecodeValue)
+		}
+	}
+	switch m.FallbackTo {
+	case "", MatchFallbackToValue:
+		if m.FallbackValue == nil {
+			return nil, errors.New(errMatchNoFallback)
+		}
+		out, err := decodeJSON(*m.FallbackValue)
+		return out, errors.Wrap(err, errMatchDecodeValue)
+	case MatchFallbackToInput:
+		return input, nil
+	}
+	return nil, errors.Errorf(errFmtMatchFallbackToNotSupported, m.FallbackTo)
+}
+
+func (p MatchTransformPattern) matches(input string) (bool, error) {
+	switch p.Type {
+	case "", MatchTransformPatternTypeLiteral:
+		if p.Literal == nil {
+			return false, errors.Errorf(errFmtMatchPatternRequiredField, "literal", MatchTransformPatternTypeLiteral)
+		}
+		return input == *p.Literal, nil
+	case MatchTransformPatternTypeRegexp:
+		if p.Regexp == nil {
+			return false, errors.Errorf(errFmtMatchPatternRequiredField, "regexp", MatchTransformPatternTypeRegexp)
+		}
+		re, err := regexp.Compile(*p.Regexp)
+		if err != nil {
+			return false, errors.Wrap(err, errStringCompileRegexp)
+		}
+		return re.MatchString(input), nil
+	}
+	return false, errors.Errorf(errFmtMatchPatternTypeNotSupported, p.Type)
+}
+
+// matchKey returns the string form of the supplied scalar input, and whether
+// the input could be represented as a string.
+func matchKey(input interface{}) (string, bool) {
 	switch i := input.(type) {
 	case string:
-		val, ok := m.Pairs[i]
-		if !ok {
-			return nil, errors.Errorf(errFmtMapNotFound, i)
-		}
-		return val, nil
-	default:
-		return nil, errors.Errorf(errFmtMapTypeNotSupported, reflect.TypeOf(input).String())
+		return i, true
+	case bool:
+		return strconv.FormatBool(i), true
+	case int:
+		return strconv.FormatInt(int64(i), 10), true
+	case int32:
+		return strconv.FormatInt(int64(i), 10), true
+	case int64:
+		return strconv.FormatInt(i, 10), true
+	case float32:
+		return strconv.FormatFloat(float64(i), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(i, 'f', -1, 64), true
 	}
+	return "", false
 }
 
+// decodeJSON decodes the supplied JSON into the types used by unstructured
+// objects, i.e. integers are decoded as int64 rather than float64.
+func decodeJSON(j extv1.JSON) (interface{}, error) {
+	if len(j.Raw) == 0 {
+		return nil, nil
+	}
+	var out interface{}
+	err := json.Unmarshal(j.Raw, &out)
+	return out, err
+}
+
+// A StringTransformType is a type of string transform.
+type StringTransformType string
+
+// Accepted StringTransformTypes.
+const (
+	StringTransformTypeFormat     StringTransformType = "Format" // Default
+	StringTransformTypeConvert    StringTransformType = "Convert"
+	StringTransformTypeTrimPrefix StringTransformType = "TrimPrefix"
+	StringTransformTypeTrimSuffix StringTransformType = "TrimSuffix"
+	StringTransformTypeRegexp     StringTransformType = "Regexp"
+	StringTransformTypeJoin       StringTransformType = "Join"
+	StringTransformTypeSplit      StringTransformType = "Split"
+)
+
+// A StringConversionType is a type of string conversion.
+type StringConversionType string
+
+// Accepted StringConversionTypes.
+const (
+	StringConversionTypeToUpper    StringConversionType = "ToUpper"
+	StringConversionTypeToLower    StringConversionType = "ToLower"
+	StringConversionTypeToBase64   StringConversionType = "ToBase64"
+	StringConversionTypeFromBase64 StringConversionType = "FromBase64"
+	StringConversionTypeToSHA1     StringConversionType = "ToSHA1"
+	StringConversionTypeToSHA256   StringConversionType = "ToSHA256"
+)
+
 // A StringTransform returns a string given the supplied input.
 type StringTransform struct {
+	// Type of the string transform to be run.
+	// +optional
+	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp;Join;Split
+	// +kubebuilder:default=Format
+	Type StringTransformType `json:"type,omitempty"`
+
 	// Format the input using a Go format string. See
-	// https://golang.org/pkg/fmt/ for details.
-	Format string `json:"fmt"`
+	// https://golang.org/pkg/fmt/ for details. Required when type is Format.
+	// +optional
+	Format *string `json:"fmt,omitempty"`
+
+	// Convert the input to upper or lower case, to or from base64, or to a
+	// hex encoded SHA-1 or SHA-256 hash. Required when type is Convert.
+	// +optional
+	// +kubebuilder:validation:Enum=ToUpper;ToLower;ToBase64;FromBase64;ToSHA1;ToSHA256
+	Convert *StringConversionType `json:"convert,omitempty"`
+
+	// Trim the supplied prefix or suffix from the input. Required when type is
+	// TrimPrefix or TrimSuffix.
+	// +optional
+	Trim *string `json:"trim,omitempty"`
+
+	// Regexp extracts a match from the input using a regular expression.
+	// Required when type is Regexp.
+	// +optional
+	Regexp *StringTransformRegexp `json:"regexp,omitempty"`
+
+	// Separator is used to join an array input into a string when type is
+	// Join, or to split a string input into an array when type is Split.
+	// Required when type is Join or Split.
+	// +optional
+	Separator *string `json:"separator,omitempty"`
+}
+
+// A StringTransformRegexp extracts a match from the input using a regular
+// expression.
+type StringTransformRegexp struct {
+	// Match string. May optionally include submatches, aka capture groups.
+	// See https://pkg.go.dev/regexp/ for details.
+	Match string `json:"match"`
+
+	// Group number to match. 0 (the default) matches the entire expression.
+	// +optional
+	Group *int `json:"group,omitempty"`
 }
 
 // Resolve runs the String transform.
-func (s *StringTransform) Resolve(input interface{}) (interface{}, error) {
-	return fmt.Sprintf(s.Format, input), nil
+func (s *StringTransform) Resolve(input interface{}) (interface{}, error) { // nolint:gocyclo
+	// NOTE(negz): The cyclomatic complexity here comes from checking that the
+	// configuration required by each type of transform is present.
+	switch s.Type {
+	case "", StringTransformTypeFormat:
+		if s.Format == nil {
+			return nil, errors.Errorf(errFmtStringRequiredField, "fmt", StringTransformTypeFormat)
+		}
+		return fmt.Sprintf(*s.Format, input), nil
+	case StringTransformTypeConvert:
+		if s.Convert == nil {
+			return nil, errors.Errorf(errFmtStringRequiredField, "convert", s.Type)
+		}
+		return stringConvert(*s.Convert, input)
+	case StringTransformTypeTrimPrefix:
+		if s.Trim == nil {
+			return nil, errors.Errorf(errFmtStringRequiredField, "trim", s.Type)
+		}
+		return strings.TrimPrefix(fmt.Sprintf("%v", input), *s.Trim), nil
+	case StringTransformTypeTrimSuffix:
+		if s.Trim == nil {
+			return nil, errors.Errorf(errFmtStringRequiredField, "trim", s.Type)
+		}
+		return strings.TrimSuffix(fmt.Sprintf("%v", input), *s.Trim), nil
+	case StringTransformTypeRegexp:
+		if s.Regexp == nil {
+			return nil, errors.Errorf(errFmtStringRequiredField, "regexp", s.Type)
+		}
+		return stringRegexp(*s.Regexp, input)
+	case StringTransformTypeJoin:
+		if s.Separator == nil {
+			return nil, errors.Errorf(errFmtStringRequiredField, "separator", s.Type)
+		}
+		return stringJoin(*s.Separator, input)
+	case StringTransformTypeSplit:
+		if s.Separator == nil {
+			return nil, errors.Errorf(errFmtStringRequiredField, "separator", s.Type)
+		}
+		str, ok := input.(string)
+		if !ok {
+			return nil, errors.Errorf(errFmtStringInputTypeNotSupported, reflect.TypeOf(input), s.Type)
+		}
+		out := []interface{}{}
+		for _, e := range strings.Split(str, *s.Separator) {
+			out = append(out, e)
+		}
+		return out, nil
+	}
+	return nil, errors.Errorf(errFmtStringTypeNotSupported, s.Type)
+}
+
+func stringConvert(t StringConversionType, input interface{}) (interface{}, error) {
+	str := fmt.Sprintf("%v", input)
+	switch t {
+	case StringConversionTypeToUpper:
+		return strings.ToUpper(str), nil
+	case StringConversionTypeToLower:
+		return strings.ToLower(str), nil
+	case StringConversionTypeToBase64:
+		return base64.StdEncoding.EncodeToString([]byte(str)), nil
+	case StringConversionTypeFromBase64:
+		out, err := base64.StdEncoding.DecodeString(str)
+		return string(out), errors.Wrap(err, errStringDecodeBase64)
+	case StringConversionTypeToSHA1:
+		return fmt.Sprintf("%x", sha1.Sum([]byte(str))), nil // nolint:gosec
+	case StringConversionTypeToSHA256:
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(str))), nil
+	}
+	return nil, errors.Errorf(errFmtStringConversionNotSupported, t)
+}
+
+func stringRegexp(r StringTransformRegexp, input interface{}) (interface{}, error) {
+	re, err := regexp.Compile(r.Match)
+	if err != nil {
+		return nil, errors.Wrap(err, errStringCompileRegexp)
+	}
+	groups := re.FindStringSubmatch(fmt.Sprintf("%v", input))
+
+	// An empty slice of groups means there was no match.
+	if len(groups) == 0 {
+		return nil, errors.Errorf(errFmtStringRegexpNoMatch, r.Match)
+	}
+
+	g := 0
+	if r.Group != nil {
+		g = *r.Group
+	}
+	if g < 0 || g >= len(groups) {
+		return nil, errors.Errorf(errFmtStringRegexpNoGroup, r.Match, g)
+	}
+	return groups[g], nil
+}
+
+func stringJoin(sep string, input interface{}) (interface{}, error) {
+	var elems []string
+	switch i := input.(type) {
+	case []interface{}:
+		elems = make([]string, len(i))
+		for n, e := range i {
+			elems[n] = fmt.Sprintf("%v", e)
+		}
+	case []string:
+		elems = i
+	default:
+		return nil, errors.Errorf(errFmtStringInputTypeNotSupported, reflect.TypeOf(input), StringTransformTypeJoin)
+	}
+	return strings.Join(elems, sep), nil
 }
 
 // The list of supported ConvertTransform input and output types.
@@ -548,15 +2302,34 @@ func (s *ConvertTransform) Resolve(input interface{}) (interface{}, error) {
 type ConnectionDetail struct {
 	// Name of the connection secret key that will be propagated to the
 	// connection secret of the composition instance. Leave empty if you'd like
-	// to use the same key name.
+	// to use the same key name. Required when type is FromFieldPath,
+	// FromValue, or Combine.
 	// +optional
 	Name *string `json:"name,omitempty"`
 
+	// Type sets the connection detail fetching behaviour to be used. Each
+	// connection detail type may require its own fields to be set on the
+	// ConnectionDetail object. If the type is omitted it is inferred from
+	// which of value, combine, fromFieldPath, and fromConnectionSecretKey is
+	// set, in that order of precedence.
+	// +optional
+	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue;Combine
+	Type *ConnectionDetailType `json:"type,omitempty"`
+
 	// FromConnectionSecretKey is the key that will be used to fetch the value
 	// from the given target resource.
 	// +optional
 	FromConnectionSecretKey *string `json:"fromConnectionSecretKey,omitempty"`
 
+	// FromFieldPath is the path of the field on the composed resource whose
+	// value will be propagated, for example status.atProvider.endpoint. It
+	// allows resources that do not publish a connection secret to contribute
+	// connection details. String values are propagated as is, numbers and
+	// booleans are formatted as strings, and objects and arrays are encoded
+	// as JSON. Fields that do not yet exist are ignored.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
+
 	// Value that will be propagated to the connection secret of the composition
 	// instance. Typically you should use FromConnectionSecretKey instead, but
 	// an explicit value may be set to inject a fixed, non-sensitive connection
@@ -564,11 +2337,78 @@ type ConnectionDetail struct {
 	// FromConnectionSecretKey when set.
 	// +optional
 	Value *string `json:"value,omitempty"`
+
+	// Combine several connection secret keys or fields of the composed
+	// resource into a single value, for example a database URL. Required when
+	// type is Combine. The connection detail is ignored until all of its
+	// variables exist.
+	// +optional
+	Combine *ConnectionDetailCombine `json:"combine,omitempty"`
+
+	// Transforms are the list of functions that are used as a FIFO pipe for
+	// the value of the connection detail before it is propagated. Values read
+	// from a connection secret are transformed as strings.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
 }
 
+// A ConnectionDetailCombine combines more than one connection detail value into
+// a single value.
+type ConnectionDetailCombine struct {
+	// Variables are the list of variables whose values will be retrieved and
+	// combined.
+	// +kubebuilder:validation:MinItems=1
+	Variables []ConnectionDetailVariable `json:"variables"`
+
+	// Strategy defines the strategy to use to combine the input variable
+	// values. Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that input variables should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
+}
+
+// Combine calls the appropriate combiner.
+func (c *ConnectionDetailCombine) Combine(vars []interface{}) (interface{}, error) {
+	return (&Combine{Strategy: c.Strategy, String: c.String}).Combine(vars)
+}
+
+// A ConnectionDetailVariable is a value that is combined into a connection
+// detail. Exactly one of its fields should be set.
+type ConnectionDetailVariable struct {
+	// FromConnectionSecretKey is the connection secret key of the composed
+	// resource whose value will be used as input.
+	// +optional
+	FromConnectionSecretKey *string `json:"fromConnectionSecretKey,omitempty"`
+
+	// FromFieldPath is the path of the field on the composed resource whose
+	// value will be used as input.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
+}
+
+// A ConnectionDetailType is a type of connection detail.
+type ConnectionDetailType string
+
+// Connection detail types.
+const (
+	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey"
+	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
+	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeCombine                 ConnectionDetailType = "Combine"
+)
+
 // CompositionStatus shows the observed state of the composition.
 type CompositionStatus struct {
 	xpv1.ConditionedStatus `json:",inline"`
+
+	// LatestRevision of this Composition, i.e. the CompositionRevision that
+	// corresponds to its current spec.
+	// +optional
+	LatestRevision *corev1.LocalObjectReference `json:"latestRevision,omitempty"`
 }
 
 // +kubebuilder:object:root=true
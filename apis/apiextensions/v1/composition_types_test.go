@@ -23,9 +23,13 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/utils/pointer"
 
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 )
@@ -141,9 +145,9 @@ func TestPatchTypeReplacement(t *testing.T) {
 									Transforms: []Transform{{
 										Type: TransformTypeMap,
 										Map: &MapTransform{
-											Pairs: map[string]string{
-												"k-1": "v-1",
-												"k-2": "v-2",
+											Pairs: map[string]extv1.JSON{
+												"k-1": {Raw: []byte(`"v-1"`)},
+												"k-2": {Raw: []byte(`"v-2"`)},
 											},
 										},
 									}},
@@ -194,9 +198,9 @@ func TestPatchTypeReplacement(t *testing.T) {
 								Transforms: []Transform{{
 									Type: TransformTypeMap,
 									Map: &MapTransform{
-										Pairs: map[string]string{
-											"k-1": "v-1",
-											"k-2": "v-2",
+										Pairs: map[string]extv1.JSON{
+											"k-1": {Raw: []byte(`"v-1"`)},
+											"k-2": {Raw: []byte(`"v-2"`)},
 										},
 									},
 								}},
@@ -246,66 +250,144 @@ func TestPatchTypeReplacement(t *testing.T) {
 	}
 }
 
-func TestMapResolve(t *testing.T) {
-	type args struct {
-		m map[string]string
-		i interface{}
-	}
-	type want struct {
-		o   interface{}
-		err error
-	}
+func TestDefaultPatchPolicies(t *testing.T) {
+	optional := FromFieldPathPolicyOptional
+	required := FromFieldPathPolicyRequired
 
 	cases := map[string]struct {
-		args
-		want
+		reason string
+		comp   CompositionSpec
+		want   []ComposedTemplate
 	}{
-		"NonStringInput": {
-			args: args{
-				i: 5,
+		"NoDefaultPolicy": {
+			reason: "Patches should be unchanged when the Composition has no default patch policy",
+			comp: CompositionSpec{
+				Resources: []ComposedTemplate{{
+					Patches: []Patch{{FromFieldPath: pointer.StringPtr("spec.a")}},
+				}},
 			},
-			want: want{
-				err: errors.Errorf(errFmtMapTypeNotSupported, "int"),
+			want: []ComposedTemplate{{
+				Patches: []Patch{{FromFieldPath: pointer.StringPtr("spec.a")}},
+			}},
+		},
+		"DefaultPolicy": {
+			reason: "Patches without a policy should use the default policy, while patches with a policy should keep it",
+			comp: CompositionSpec{
+				DefaultPatchPolicy: &PatchPolicy{FromFieldPath: &required},
+				Resources: []ComposedTemplate{{
+					Patches: []Patch{
+						{FromFieldPath: pointer.StringPtr("spec.a")},
+						{FromFieldPath: pointer.StringPtr("spec.b"), Policy: &PatchPolicy{}},
+						{FromFieldPath: pointer.StringPtr("spec.c"), Policy: &PatchPolicy{FromFieldPath: &optional}},
+					},
+				}},
 			},
+			want: []ComposedTemplate{{
+				Patches: []Patch{
+					{FromFieldPath: pointer.StringPtr("spec.a"), Policy: &PatchPolicy{FromFieldPath: &required}},
+					{FromFieldPath: pointer.StringPtr("spec.b"), Policy: &PatchPolicy{FromFieldPath: &required}},
+					{FromFieldPath: pointer.StringPtr("spec.c"), Policy: &PatchPolicy{FromFieldPath: &optional}},
+				},
+			}},
 		},
-		"KeyNotFound": {
-			args: args{
-				i: "ola",
+		"DefaultMergeOptions": {
+			reason: "Patches without merge options should use the default merge options, while patches with merge options should keep them",
+			comp: CompositionSpec{
+				DefaultPatchPolicy: &PatchPolicy{MergeOptions: &MergeOptions{AppendSlice: pointer.BoolPtr(true)}},
+				Resources: []ComposedTemplate{{
+					Patches: []Patch{
+						{FromFieldPath: pointer.StringPtr("spec.a"), Policy: &PatchPolicy{FromFieldPath: &required}},
+						{FromFieldPath: pointer.StringPtr("spec.b"), Policy: &PatchPolicy{MergeOptions: &MergeOptions{}}},
+					},
+				}},
 			},
+			want: []ComposedTemplate{{
+				Patches: []Patch{
+					{FromFieldPath: pointer.StringPtr("spec.a"), Policy: &PatchPolicy{
+						FromFieldPath: &required,
+						MergeOptions:  &MergeOptions{AppendSlice: pointer.BoolPtr(true)},
+					}},
+					{FromFieldPath: pointer.StringPtr("spec.b"), Policy: &PatchPolicy{MergeOptions: &MergeOptions{}}},
+				},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.comp.DefaultPatchPolicies()
+			if diff := cmp.Diff(tc.want, tc.comp.Resources); diff != "" {
+				t.Errorf("\n%s\nDefaultPatchPolicies(): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConditionMet(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	cp := &fake.Composite{
+		ObjectMeta:                          metav1.ObjectMeta{Labels: map[string]string{"replica": "true"}},
+		ConnectionDetailsLastPublishedTimer: fake.ConnectionDetailsLastPublishedTimer{Time: &now},
+	}
+
+	type want struct {
+		met bool
+		err error
+	}
+	cases := map[string]struct {
+		reason    string
+		condition *string
+		want      want
+	}{
+		"NoCondition": {
+			reason: "A template without a condition should always be composed",
+			want:   want{met: true},
+		},
+		"ConditionMet": {
+			reason:    "A template should be composed if its condition evaluates to true",
+			condition: pointer.StringPtr(`composite.objectMeta.labels.replica == "true"`),
+			want:      want{met: true},
+		},
+		"ConditionNotMet": {
+			reason:    "A template should not be composed if its condition evaluates to false",
+			condition: pointer.StringPtr(`has(composite.objectMeta.labels.missing)`),
+			want:      want{met: false},
+		},
+		"EvaluateError": {
+			reason:    "Errors evaluating a condition should be returned",
+			condition: pointer.StringPtr(`composite.objectMeta.labels.missing`),
 			want: want{
-				err: errors.Errorf(errFmtMapNotFound, "ola"),
+				err: errors.Wrap(errors.New(`cannot evaluate expression: no such key "missing"`), errEvaluateCondition),
 			},
 		},
-		"Success": {
-			args: args{
-				m: map[string]string{"ola": "voila"},
-				i: "ola",
-			},
+		"NotBool": {
+			reason:    "A condition that does not evaluate to a boolean should return an error",
+			condition: pointer.StringPtr(`composite.objectMeta.labels.replica`),
 			want: want{
-				o: "voila",
+				err: errors.Errorf(errFmtConditionNotBool, "true"),
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&MapTransform{Pairs: tc.m}).Resolve(tc.i)
-
-			if diff := cmp.Diff(tc.want.o, got); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			ct := &ComposedTemplate{Condition: tc.condition}
+			met, err := ct.ConditionMet(cp)
+			if diff := cmp.Diff(tc.want.met, met); diff != "" {
+				t.Errorf("\n%s\nConditionMet(...): -want, +got:\n%s", tc.reason, diff)
 			}
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+				t.Errorf("\n%s\nConditionMet(...): -want error, +got error:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestMathResolve(t *testing.T) {
-	m := int64(2)
-
+func TestMapResolve(t *testing.T) {
 	type args struct {
-		multiplier *int64
-		i          interface{}
+		m  map[string]extv1.JSON
+		fv *extv1.JSON
+		to MatchFallbackTo
+		i  interface{}
 	}
 	type want struct {
 		o   interface{}
@@ -316,45 +398,142 @@ func TestMathResolve(t *testing.T) {
 		args
 		want
 	}{
-		"NoMultiplier": {
+		"UnsupportedInput": {
 			args: args{
-				i: 25,
+				i: []interface{}{"ola"},
 			},
 			want: want{
-				err: errors.New(errMathNoMultiplier),
+				err: errors.Errorf(errFmtMapTypeNotSupported, "[]interface {}"),
 			},
 		},
-		"NonNumberInput": {
+		"KeyNotFound": {
 			args: args{
-				multiplier: &m,
-				i:          "ola",
+				i: "ola",
 			},
 			want: want{
-				err: errors.New(errMathInputNonNumber),
+				err: errors.Errorf(errFmtMapNotFound, "ola"),
 			},
 		},
 		"Success": {
 			args: args{
-				multiplier: &m,
-				i:          3,
+				m: map[string]extv1.JSON{"ola": {Raw: []byte(`"voila"`)}},
+				i: "ola",
+			},
+			want: want{
+				o: "voila",
+			},
+		},
+		"IntegerInput": {
+			args: args{
+				m: map[string]extv1.JSON{"5": {Raw: []byte(`"five"`)}},
+				i: 5,
 			},
 			want: want{
-				o: 3 * m,
+				o: "five",
 			},
 		},
-		"SuccessInt64": {
+		"FloatInput": {
+			args: args{
+				m: map[string]extv1.JSON{"1.5": {Raw: []byte(`"one and a half"`)}},
+				i: 1.5,
+			},
+			want: want{
+				o: "one and a half",
+			},
+		},
+		"BooleanInput": {
+			args: args{
+				m: map[string]extv1.JSON{"true": {Raw: []byte(`"yes"`)}},
+				i: true,
+			},
+			want: want{
+				o: "yes",
+			},
+		},
+		"IntegerValue": {
+			args: args{
+				m: map[string]extv1.JSON{"ola": {Raw: []byte(`42`)}},
+				i: "ola",
+			},
+			want: want{
+				o: int64(42),
+			},
+		},
+		"ObjectValue": {
+			args: args{
+				m: map[string]extv1.JSON{"ola": {Raw: []byte(`{"size":"large","replicas":[1,2]}`)}},
+				i: "ola",
+			},
+			want: want{
+				o: map[string]interface{}{"size": "large", "replicas": []interface{}{int64(1), int64(2)}},
+			},
+		},
+		"InvalidValue": {
+			args: args{
+				m: map[string]extv1.JSON{"ola": {Raw: []byte(`{`)}},
+				i: "ola",
+			},
+			want: want{
+				err: errors.Wrap(errors.New("unexpected EOF"), errMapDecodeValue),
+			},
+		},
+		"KeyNotFoundFallbackValue": {
+			args: args{
+				m:  map[string]extv1.JSON{"ola": {Raw: []byte(`"voila"`)}},
+				fv: &extv1.JSON{Raw: []byte(`{"size":"small"}`)},
+				i:  "hello",
+			},
+			want: want{
+				o: map[string]interface{}{"size": "small"},
+			},
+		},
+		"KeyNotFoundFallbackToValueWithoutValue": {
+			args: args{
+				m:  map[string]extv1.JSON{"ola": {Raw: []byte(`"voila"`)}},
+				to: MatchFallbackToValue,
+				i:  "hello",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapNotFound, "hello"),
+			},
+		},
+		"KeyNotFoundFallbackToInput": {
+			args: args{
+				m:  map[string]extv1.JSON{"ola": {Raw: []byte(`"voila"`)}},
+				to: MatchFallbackToInput,
+				i:  5,
+			},
+			want: want{
+				o: 5,
+			},
+		},
+		"KeyFoundIgnoresFallback": {
+			args: args{
+				m:  map[string]extv1.JSON{"ola": {Raw: []byte(`"voila"`)}},
+				fv: &extv1.JSON{Raw: []byte(`"fallback"`)},
+				i:  "ola",
+			},
+			want: want{
+				o: "voila",
+			},
+		},
+		"UnsupportedFallbackTo": {
 			args: args{
-				multiplier: &m,
-				i:          int64(3),
+				to: "Nope",
+				i:  "ola",
 			},
 			want: want{
-				o: 3 * m,
+				err: errors.Errorf(errFmtMapFallbackToNotSupported, "Nope"),
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&MathTransform{Multiply: tc.multiplier}).Resolve(tc.i)
+			var fb *MapFallback
+			if tc.fv != nil || tc.to != "" {
+				fb = &MapFallback{Value: tc.fv, To: tc.to}
+			}
+			got, err := (&MapTransform{Pairs: tc.m}).ResolveWithFallback(tc.i, fb)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
 				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
@@ -366,125 +545,127 @@ func TestMathResolve(t *testing.T) {
 	}
 }
 
-func TestStringResolve(t *testing.T) {
-
-	type args struct {
-		fmts string
-		i    interface{}
-	}
+func TestMapTransformJSON(t *testing.T) {
 	type want struct {
-		o   interface{}
+		m   MapTransform
 		err error
 	}
 
 	cases := map[string]struct {
-		args
-		want
+		reason string
+		b      string
+		want   want
 	}{
-		"FmtString": {
-			args: args{
-				fmts: "verycool%s",
-				i:    "thing",
-			},
-			want: want{
-				o: "verycoolthing",
-			},
+		"PairsOnly": {
+			reason: "A map should unmarshal to pairs.",
+			b:      `{"a":"b","c":1}`,
+			want: want{m: MapTransform{Pairs: map[string]extv1.JSON{
+				"a": {Raw: []byte(`"b"`)},
+				"c": {Raw: []byte(`1`)},
+			}}},
 		},
-		"FmtInteger": {
-			args: args{
-				fmts: "the largest %d",
-				i:    8,
-			},
-			want: want{
-				o: "the largest 8",
-			},
+		"FallbackKeys": {
+			reason: "Keys named like fallback settings should unmarshal to pairs like any other key.",
+			b:      `{"fallbackValue":{"d":"e"},"fallbackTo":"Input"}`,
+			want: want{m: MapTransform{Pairs: map[string]extv1.JSON{
+				"fallbackValue": {Raw: []byte(`{"d":"e"}`)},
+				"fallbackTo":    {Raw: []byte(`"Input"`)},
+			}}},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&StringTransform{Format: tc.fmts}).Resolve(tc.i)
+			got := MapTransform{}
+			err := json.Unmarshal([]byte(tc.b), &got)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUnmarshalJSON(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.m, got); diff != "" {
+				t.Errorf("\n%s\nUnmarshalJSON(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
 
-			if diff := cmp.Diff(tc.want.o, got); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			// The transform should survive a round trip.
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("\n%s\nMarshalJSON(...): %v", tc.reason, err)
 			}
-			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			rt := MapTransform{}
+			if err := json.Unmarshal(b, &rt); err != nil {
+				t.Fatalf("\n%s\nUnmarshalJSON(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(got, rt); diff != "" {
+				t.Errorf("\n%s\nMarshalJSON(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestConvertResolve(t *testing.T) {
-	type args struct {
-		ot string
-		i  interface{}
-	}
+func TestMapTransformFallback(t *testing.T) {
 	type want struct {
 		o   interface{}
 		err error
 	}
 
 	cases := map[string]struct {
-		args
-		want
+		reason string
+		b      string
+		i      interface{}
+		want   want
 	}{
-		"StringToBool": {
-			args: args{
-				i:  "true",
-				ot: ConvertTransformTypeBool,
-			},
-			want: want{
-				o: true,
-			},
+		"FallbackToValue": {
+			reason: "A map transform should return its fallback value when the input matches no key.",
+			b:      `{"type":"map","map":{"a":"b"},"mapFallback":{"value":{"size":"small"}}}`,
+			i:      "c",
+			want:   want{o: map[string]interface{}{"size": "small"}},
 		},
-		"InputTypeNotSupported": {
-			args: args{
-				i:  []int{64},
-				ot: ConvertTransformTypeString,
-			},
-			want: want{
-				err: errors.Errorf(errFmtConvertInputTypeNotSupported, reflect.TypeOf([]int{}).Kind().String()),
-			},
+		"FallbackToInput": {
+			reason: "A map transform should return its input when the input matches no key and it falls back to its input.",
+			b:      `{"type":"map","map":{"a":"b"},"mapFallback":{"to":"Input"}}`,
+			i:      "c",
+			want:   want{o: "c"},
 		},
-		"ConversionPairNotSupported": {
-			args: args{
-				i:  "[64]",
-				ot: "[]int",
-			},
-			want: want{
-				err: errors.Errorf(errFmtConversionPairNotSupported, "string", "[]int"),
-			},
+		"NoFallback": {
+			reason: "A map transform should return an error when the input matches no key and there is no fallback.",
+			b:      `{"type":"map","map":{"a":"b"}}`,
+			i:      "c",
+			want:   want{err: errors.Wrapf(errors.Errorf(errFmtMapNotFound, "c"), errFmtTransformTypeFailed, string(TransformTypeMap))},
+		},
+		"MissingMap": {
+			reason: "A map transform should return an error when it has a fallback but no map.",
+			b:      `{"type":"map","mapFallback":{"to":"Input"}}`,
+			i:      "c",
+			want:   want{err: errors.Errorf(errFmtConfigMissing, string(TransformTypeMap))},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&ConvertTransform{ToType: tc.args.ot}).Resolve(tc.i)
-
-			if diff := cmp.Diff(tc.want.o, got); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			tr := Transform{}
+			if err := json.Unmarshal([]byte(tc.b), &tr); err != nil {
+				t.Fatalf("\n%s\nUnmarshalJSON(...): %v", tc.reason, err)
 			}
+			got, err := tr.Transform(tc.i)
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+				t.Errorf("\n%s\nTransform(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("\n%s\nTransform(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestPatchApply(t *testing.T) {
-	now := metav1.NewTime(time.Unix(0, 0))
-	lpt := fake.ConnectionDetailsLastPublishedTimer{
-		Time: &now,
-	}
+func TestMatchResolve(t *testing.T) {
+	re := MatchTransformPatternTypeRegexp
 
 	type args struct {
-		patch Patch
-		cp    *fake.Composite
-		cd    *fake.Composed
-		only  []PatchType
+		m MatchTransform
+		i interface{}
 	}
 	type want struct {
-		cp  *fake.Composite
-		cd  *fake.Composed
+		o   interface{}
 		err error
 	}
 
@@ -493,236 +674,2050 @@ func TestPatchApply(t *testing.T) {
 		args
 		want
 	}{
-		"InvalidCompositeFieldPathPatch": {
-			reason: "Should return error when required fields not passed to applyFromFieldPathPatch",
+		"UnsupportedInput": {
+			reason: "Inputs that have no string form should return an error",
 			args: args{
-				patch: Patch{
-					Type: PatchTypeFromCompositeFieldPath,
-				},
-				cp: &fake.Composite{
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				i: map[string]interface{}{},
 			},
 			want: want{
-				err: errors.Errorf(errRequiredField, "FromFieldPath", PatchTypeFromCompositeFieldPath),
+				err: errors.Errorf(errFmtMatchTypeNotSupported, "map[string]interface {}"),
 			},
 		},
-		"InvalidPatchType": {
-			reason: "Should return an error if an invalid patch type is specified",
+		"LiteralMatch": {
+			reason: "The result of the first matching pattern should be returned",
 			args: args{
-				patch: Patch{
-					Type: "invalid-patchtype",
-				},
-				cp: &fake.Composite{
-					ConnectionDetailsLastPublishedTimer: lpt,
+				m: MatchTransform{
+					Patterns: []MatchTransformPattern{
+						{Literal: pointer.StringPtr("us-west"), Result: extv1.JSON{Raw: []byte(`"west"`)}},
+						{Literal: pointer.StringPtr("us-east"), Result: extv1.JSON{Raw: []byte(`"east"`)}},
+						{Literal: pointer.StringPtr("us-east"), Result: extv1.JSON{Raw: []byte(`"other"`)}},
+					},
 				},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				i: "us-east",
 			},
 			want: want{
-				err: errors.Errorf(errInvalidPatchType, "invalid-patchtype"),
+				o: "east",
 			},
 		},
-		"ValidCompositeFieldPathPatch": {
-			reason: "Should correctly apply a CompositeFieldPathPatch with valid settings",
+		"RegexpMatch": {
+			reason: "Regexp patterns should match any part of the input",
 			args: args{
-				patch: Patch{
-					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
-				},
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
+				m: MatchTransform{
+					Patterns: []MatchTransformPattern{
+						{Type: re, Regexp: pointer.StringPtr("^eu-"), Result: extv1.JSON{Raw: []byte(`{"zone":"eu"}`)}},
 					},
-					ConnectionDetailsLastPublishedTimer: lpt,
 				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				i: "eu-central-1",
+			},
+			want: want{
+				o: map[string]interface{}{"zone": "eu"},
+			},
+		},
+		"IntegerInput": {
+			reason: "Non-string inputs should be matched by their string form",
+			args: args{
+				m: MatchTransform{
+					Patterns: []MatchTransformPattern{
+						{Literal: pointer.StringPtr("3"), Result: extv1.JSON{Raw: []byte(`"three"`)}},
+					},
 				},
+				i: int64(3),
 			},
 			want: want{
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
+				o: "three",
+			},
+		},
+		"FallbackValue": {
+			reason: "The fallback value should be returned if no pattern matches",
+			args: args{
+				m: MatchTransform{
+					Patterns: []MatchTransformPattern{
+						{Literal: pointer.StringPtr("us-west"), Result: extv1.JSON{Raw: []byte(`"west"`)}},
+					},
+					FallbackValue: &extv1.JSON{Raw: []byte(`10`)},
+				},
+				i: "ap-south",
+			},
+			want: want{
+				o: int64(10),
+			},
+		},
+		"FallbackToInput": {
+			reason: "The input should be returned unchanged if no pattern matches and fallbackTo is Input",
+			args: args{
+				m: MatchTransform{
+					FallbackTo:    MatchFallbackToInput,
+					FallbackValue: &extv1.JSON{Raw: []byte(`10`)},
+				},
+				i: 7,
+			},
+			want: want{
+				o: 7,
+			},
+		},
+		"NoFallback": {
+			reason: "An error should be returned if no pattern matches and there is no fallback value",
+			args: args{
+				i: "ap-south",
+			},
+			want: want{
+				err: errors.New(errMatchNoFallback),
+			},
+		},
+		"UnsupportedFallbackTo": {
+			reason: "An error should be returned for an unknown fallbackTo",
+			args: args{
+				m: MatchTransform{FallbackTo: "Nowhere"},
+				i: "ap-south",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMatchFallbackToNotSupported, "Nowhere"),
+			},
+		},
+		"MissingLiteral": {
+			reason: "Literal patterns require a literal",
+			args: args{
+				m: MatchTransform{
+					Patterns: []MatchTransformPattern{{Result: extv1.JSON{Raw: []byte(`"west"`)}}},
+				},
+				i: "us-west",
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtMatchPatternRequiredField, "literal", MatchTransformPatternTypeLiteral), errFmtMatchPatternAtIndex, 0),
+			},
+		},
+		"InvalidRegexp": {
+			reason: "Invalid regular expressions should return an error",
+			args: args{
+				m: MatchTransform{
+					Patterns: []MatchTransformPattern{{Type: re, Regexp: pointer.StringPtr("("), Result: extv1.JSON{Raw: []byte(`"west"`)}}},
+				},
+				i: "us-west",
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrap(errors.New("error parsing regexp: missing closing ): `(`"), errStringCompileRegexp), errFmtMatchPatternAtIndex, 0),
+			},
+		},
+		"UnsupportedPatternType": {
+			reason: "Unknown pattern types should return an error",
+			args: args{
+				m: MatchTransform{
+					Patterns: []MatchTransformPattern{{Type: "glob", Result: extv1.JSON{Raw: []byte(`"west"`)}}},
+				},
+				i: "us-west",
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtMatchPatternTypeNotSupported, "glob"), errFmtMatchPatternAtIndex, 0),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.args.m.Resolve(tc.args.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("\n%s\nResolve(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nResolve(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMathResolve(t *testing.T) {
+	m := MathOperand("2")
+	zero := MathOperand("0")
+	half := MathOperand("0.5")
+	nan := MathOperand("NaN")
+	floor := MathRoundingModeFloor
+	toInt := ConvertTransformTypeInt
+	toFloat := ConvertTransformTypeFloat64
+
+	type args struct {
+		m MathTransform
+		i interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"NoMultiplier": {
+			args: args{
+				i: 25,
+			},
+			want: want{
+				err: errors.New(errMathNoMultiplier),
+			},
+		},
+		"NonNumberInput": {
+			args: args{
+				m: MathTransform{Multiply: &m},
+				i: "ola",
+			},
+			want: want{
+				err: errors.New(errMathInputNonNumber),
+			},
+		},
+		"Success": {
+			args: args{
+				m: MathTransform{Multiply: &m},
+				i: 3,
+			},
+			want: want{
+				o: int64(6),
+			},
+		},
+		"SuccessInt64": {
+			args: args{
+				m: MathTransform{Multiply: &m},
+				i: int64(3),
+			},
+			want: want{
+				o: int64(6),
+			},
+		},
+		"MultiplyFloat": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeMultiply, Multiply: &m},
+				i: 1.5,
+			},
+			want: want{
+				o: float64(3),
+			},
+		},
+		"MultiplyFloatToInt": {
+			args: args{
+				m: MathTransform{Multiply: &m, OutputType: &toInt},
+				i: 1.3,
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"NoOperand": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeAdd},
+				i: 1,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathNoOperand, MathTransformTypeAdd),
+			},
+		},
+		"UnsupportedType": {
+			args: args{
+				m: MathTransform{Type: "Exponent"},
+				i: 1.0,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathTypeNotSupported, "Exponent"),
+			},
+		},
+		"Add": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeAdd, Add: &m},
+				i: 3,
+			},
+			want: want{
+				o: int64(5),
+			},
+		},
+		"AddToFloat": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeAdd, Add: &m, OutputType: &toFloat},
+				i: 3,
+			},
+			want: want{
+				o: float64(5),
+			},
+		},
+		"Subtract": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeSubtract, Subtract: &m},
+				i: 2.5,
+			},
+			want: want{
+				o: float64(0.5),
+			},
+		},
+		"DivideInt": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeDivide, Divide: &m},
+				i: 5,
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"DivideIntFloor": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeDivide, Divide: &m, RoundingMode: &floor},
+				i: 5,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"DivideIntToFloat": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeDivide, Divide: &m, OutputType: &toFloat},
+				i: 5,
+			},
+			want: want{
+				o: float64(2.5),
+			},
+		},
+		"DivideByZero": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeDivide, Divide: &zero},
+				i: 5,
+			},
+			want: want{
+				err: errors.New(errMathDivideByZero),
+			},
+		},
+		"ClampMin": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeClampMin, ClampMin: &m},
+				i: 1,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMinFloat": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeClampMin, ClampMin: &m},
+				i: 2.5,
+			},
+			want: want{
+				o: float64(2.5),
+			},
+		},
+		"ClampMax": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeClampMax, ClampMax: &m},
+				i: 3,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMaxFloat": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeClampMax, ClampMax: &m},
+				i: 2.5,
+			},
+			want: want{
+				o: float64(2),
+			},
+		},
+		"Round": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeRound},
+				i: 2.5,
+			},
+			want: want{
+				o: float64(3),
+			},
+		},
+		"MultiplyIntByDecimal": {
+			args: args{
+				m: MathTransform{Multiply: &half},
+				i: 5,
+			},
+			want: want{
+				o: float64(2.5),
+			},
+		},
+		"MultiplyIntByDecimalToInt": {
+			args: args{
+				m: MathTransform{Multiply: &half, OutputType: &toInt, RoundingMode: &floor},
+				i: 5,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"AddDecimal": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeAdd, Add: &half},
+				i: 1.25,
+			},
+			want: want{
+				o: float64(1.75),
+			},
+		},
+		"ClampMinDecimal": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeClampMin, ClampMin: &half},
+				i: 0,
+			},
+			want: want{
+				o: float64(0.5),
+			},
+		},
+		"DivideByDecimal": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeDivide, Divide: &half},
+				i: 3,
+			},
+			want: want{
+				o: float64(6),
+			},
+		},
+		"InvalidOperand": {
+			args: args{
+				m: MathTransform{Multiply: &nan},
+				i: 3,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathOperandNotNumber, "NaN"),
+			},
+		},
+		"RoundFloorToInt": {
+			args: args{
+				m: MathTransform{Type: MathTransformTypeRound, RoundingMode: &floor, OutputType: &toInt},
+				i: 2.5,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.m.Resolve(tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMathOperandUnmarshalJSON(t *testing.T) {
+	type want struct {
+		o   MathOperand
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		b      string
+		want   want
+	}{
+		"Integer": {
+			reason: "An integer should be accepted.",
+			b:      `2`,
+			want:   want{o: MathOperand("2")},
+		},
+		"Decimal": {
+			reason: "A decimal number should be accepted.",
+			b:      `-0.5`,
+			want:   want{o: MathOperand("-0.5")},
+		},
+		"Exponent": {
+			reason: "A number with an exponent should be accepted.",
+			b:      `1.5e2`,
+			want:   want{o: MathOperand("1.5e2")},
+		},
+		"String": {
+			reason: "A string should be rejected, even if it contains a number, because the schema only allows numbers.",
+			b:      `"1.5"`,
+			want:   want{err: errors.Errorf(errFmtMathOperandNotNumber, `"1.5"`)},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got MathOperand
+			err := got.UnmarshalJSON([]byte(tc.b))
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUnmarshalJSON(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("\n%s\nUnmarshalJSON(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestStringResolve(t *testing.T) {
+	toUpper := StringConversionTypeToUpper
+	toLower := StringConversionTypeToLower
+	toBase64 := StringConversionTypeToBase64
+	fromBase64 := StringConversionTypeFromBase64
+	toSHA1 := StringConversionTypeToSHA1
+	toSHA256 := StringConversionTypeToSHA256
+	one := 1
+
+	type args struct {
+		s StringTransform
+		i interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"FmtString": {
+			args: args{
+				s: StringTransform{Format: pointer.StringPtr("verycool%s")},
+				i: "thing",
+			},
+			want: want{
+				o: "verycoolthing",
+			},
+		},
+		"FmtInteger": {
+			args: args{
+				s: StringTransform{Format: pointer.StringPtr("the largest %d")},
+				i: 8,
+			},
+			want: want{
+				o: "the largest 8",
+			},
+		},
+		"FmtMissing": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeFormat},
+				i: "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringRequiredField, "fmt", StringTransformTypeFormat),
+			},
+		},
+		"UnsupportedType": {
+			args: args{
+				s: StringTransform{Type: "Reverse"},
+				i: "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringTypeNotSupported, "Reverse"),
+			},
+		},
+		"ConvertToUpper": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeConvert, Convert: &toUpper},
+				i: "crossplane",
+			},
+			want: want{
+				o: "CROSSPLANE",
+			},
+		},
+		"ConvertToLower": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeConvert, Convert: &toLower},
+				i: "CrossPlane",
+			},
+			want: want{
+				o: "crossplane",
+			},
+		},
+		"ConvertToBase64": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeConvert, Convert: &toBase64},
+				i: "crossplane",
+			},
+			want: want{
+				o: "Y3Jvc3NwbGFuZQ==",
+			},
+		},
+		"ConvertFromBase64": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeConvert, Convert: &fromBase64},
+				i: "Y3Jvc3NwbGFuZQ==",
+			},
+			want: want{
+				o: "crossplane",
+			},
+		},
+		"ConvertFromInvalidBase64": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeConvert, Convert: &fromBase64},
+				i: "!!!",
+			},
+			want: want{
+				o:   "",
+				err: errors.Wrap(errors.New("illegal base64 data at input byte 0"), errStringDecodeBase64),
+			},
+		},
+		"ConvertToSHA1": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeConvert, Convert: &toSHA1},
+				i: "crossplane",
+			},
+			want: want{
+				o: "acc45ad381f6cec9e5b31e520d841a57932febd8",
+			},
+		},
+		"ConvertToSHA256": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeConvert, Convert: &toSHA256},
+				i: "crossplane",
+			},
+			want: want{
+				o: "de727ce20dac518d68733542fa57d4b82bed9714d6567a9cfd1cffabd6725830",
+			},
+		},
+		"TrimPrefix": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeTrimPrefix, Trim: pointer.StringPtr("cross")},
+				i: "crossplane",
+			},
+			want: want{
+				o: "plane",
+			},
+		},
+		"TrimSuffix": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeTrimSuffix, Trim: pointer.StringPtr("plane")},
+				i: "crossplane",
+			},
+			want: want{
+				o: "cross",
+			},
+		},
+		"Regexp": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "^arn:aws:iam::(\\d+):.*"}},
+				i: "arn:aws:iam::42:example",
+			},
+			want: want{
+				o: "arn:aws:iam::42:example",
+			},
+		},
+		"RegexpGroup": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "^arn:aws:iam::(\\d+):.*", Group: &one}},
+				i: "arn:aws:iam::42:example",
+			},
+			want: want{
+				o: "42",
+			},
+		},
+		"RegexpNoMatch": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "^foo$"}},
+				i: "bar",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringRegexpNoMatch, "^foo$"),
+			},
+		},
+		"RegexpNoGroup": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeRegexp, Regexp: &StringTransformRegexp{Match: "^foo$", Group: &one}},
+				i: "foo",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringRegexpNoGroup, "^foo$", 1),
+			},
+		},
+		"Join": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeJoin, Separator: pointer.StringPtr(",")},
+				i: []interface{}{"a", "b", 3},
+			},
+			want: want{
+				o: "a,b,3",
+			},
+		},
+		"JoinNonArray": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeJoin, Separator: pointer.StringPtr(",")},
+				i: "a",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringInputTypeNotSupported, reflect.TypeOf(""), StringTransformTypeJoin),
+			},
+		},
+		"Split": {
+			args: args{
+				s: StringTransform{Type: StringTransformTypeSplit, Separator: pointer.StringPtr(",")},
+				i: "a,b,c",
+			},
+			want: want{
+				o: []interface{}{"a", "b", "c"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.s.Resolve(tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertResolve(t *testing.T) {
+	type args struct {
+		ot string
+		i  interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"StringToBool": {
+			args: args{
+				i:  "true",
+				ot: ConvertTransformTypeBool,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"InputTypeNotSupported": {
+			args: args{
+				i:  []int{64},
+				ot: ConvertTransformTypeString,
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertInputTypeNotSupported, reflect.TypeOf([]int{}).Kind().String()),
+			},
+		},
+		"ConversionPairNotSupported": {
+			args: args{
+				i:  "[64]",
+				ot: "[]int",
+			},
+			want: want{
+				err: errors.Errorf(errFmtConversionPairNotSupported, "string", "[]int"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := (&ConvertTransform{ToType: tc.args.ot}).Resolve(tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPatchApply(t *testing.T) {
+	required := FromFieldPathPolicyRequired
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{
+		Time: &now,
+	}
+
+	type args struct {
+		patch Patch
+		cp    *fake.Composite
+		cd    *fake.Composed
+		only  []PatchType
+	}
+	type want struct {
+		cp  *fake.Composite
+		cd  *fake.Composed
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"InvalidCompositeFieldPathPatch": {
+			reason: "Should return error when required fields not passed to applyFromFieldPathPatch",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeFromCompositeFieldPath,
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errRequiredField, "FromFieldPath", PatchTypeFromCompositeFieldPath),
+			},
+		},
+		"InvalidPatchType": {
+			reason: "Should return an error if an invalid patch type is specified",
+			args: args{
+				patch: Patch{
+					Type: "invalid-patchtype",
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errInvalidPatchType, "invalid-patchtype"),
+			},
+		},
+		"ValidCompositeFieldPathPatch": {
+			reason: "Should correctly apply a CompositeFieldPathPatch with valid settings",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+		"FilterExcludeCompositeFieldPathPatch": {
+			reason: "Should not apply the patch as the PatchType is not present in filter.",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				only: []PatchType{PatchTypePatchSet},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+					},
+				},
+				err: nil,
+			},
+		},
+		"FilterIncludeCompositeFieldPathPatch": {
+			reason: "Should apply the patch as the PatchType is present in filter.",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				only: []PatchType{PatchTypeFromCompositeFieldPath},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						}},
+				},
+				err: nil,
+			},
+		},
+		"DefaultToFieldCompositeFieldPathPatch": {
+			reason: "Should correctly default the ToFieldPath value if not specified.",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						}},
+				},
+				err: nil,
+			},
+		},
+		"ValidToCompositeFieldPathPatch": {
+			reason: "Should correctly apply a ToCompositeFieldPath patch with valid settings",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeToCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
 						Name: "cd",
 						Labels: map[string]string{
 							"Test": "blah",
+						}},
+				},
+				err: nil,
+			},
+		},
+		"MissingOptionalFieldPath": {
+			reason: "Should not apply a patch, or return an error, when an optional FromFieldPath does not exist",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels.missing"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+		},
+		"MissingRequiredFieldPath": {
+			reason: "Should return an error when a required FromFieldPath does not exist",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels.missing"),
+					Policy:        &PatchPolicy{FromFieldPath: &required},
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err: requiredFieldPathNotFound(errors.New("objectMeta.labels: no such field"), "objectMeta.labels.missing"),
+			},
+		},
+		"CombineFromCompositeMissingRequiredVariable": {
+			reason: "Should return an error when a required variable of a CombineFromComposite patch does not exist",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.name"},
+							{FromFieldPath: "objectMeta.namespace"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+					Policy:      &PatchPolicy{FromFieldPath: &required},
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: requiredFieldPathNotFound(errors.New("objectMeta.namespace: no such field"), "objectMeta.namespace"),
+			},
+		},
+		"CombineFromCompositeMissingCombine": {
+			reason: "Should return an error if a CombineFromComposite patch has no Combine configuration",
+			args: args{
+				patch: Patch{
+					Type:        PatchTypeCombineFromComposite,
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errRequiredField, "Combine", PatchTypeCombineFromComposite),
+			},
+		},
+		"CombineFromCompositeMissingToFieldPath": {
+			reason: "Should return an error if a CombineFromComposite patch has no ToFieldPath",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{{FromFieldPath: "objectMeta.labels.source1"}},
+						Strategy:  CombineStrategyString,
+						String:    &StringCombine{Format: "%s"},
+					},
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errRequiredField, "ToFieldPath", PatchTypeCombineFromComposite),
+			},
+		},
+		"CombineFromCompositeNoVariables": {
+			reason: "Should return an error if a CombineFromComposite patch has no variables",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.New(errCombineRequiresVariables),
+			},
+		},
+		"CombineFromCompositeMissingVariable": {
+			reason: "Should not apply a CombineFromComposite patch if any of its variables are missing",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"source1": "foo",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+		},
+		"ValidCombineFromComposite": {
+			reason: "Should correctly combine, transform, and apply a CombineFromComposite patch",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+					Transforms: []Transform{{
+						Type:   TransformTypeString,
+						String: &StringTransform{Format: pointer.StringPtr("prefix-%s")},
+					}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"source1": "foo",
+							"source2": "bar",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"destination": "prefix-foo-bar",
+						},
+					},
+				},
+			},
+		},
+		"ValidCombineToComposite": {
+			reason: "Should correctly combine and apply a CombineToComposite patch",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineToComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"source1": "foo",
+							"source2": "bar",
+						},
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"destination": "foo-bar",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+			},
+		},
+		"ExpressionTransformFromComposite": {
+			reason: "Expression transforms should be evaluated against the input and the composite resource",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels.size"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels.tier"),
+					Transforms: []Transform{{
+						Type: TransformTypeExpression,
+						Expression: &ExpressionTransform{
+							Expression: `input == "large" ? "premium-" + composite.objectMeta.name : "standard"`,
+						},
+					}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"size": "large"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"tier": "premium-cp"},
+					},
+				},
+			},
+		},
+		"ExpressionTransformToComposite": {
+			reason: "Expression transforms of patches to the composite resource should also be evaluated against the composite resource",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeToCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.name"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels.composed"),
+					Transforms: []Transform{{
+						Type: TransformTypeExpression,
+						Expression: &ExpressionTransform{
+							Expression: `composite.objectMeta.labels.prefix + "-" + upper(input)`,
 						},
+					}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"prefix": "x"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"prefix": "x", "composed": "x-CD"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+			},
+		},
+		"MergeOptionsPatch": {
+			reason: "Should merge the patched value with the existing value when merge options are specified",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					Policy:        &PatchPolicy{MergeOptions: &MergeOptions{}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"team": "a", "env": "prod"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cd",
+					Labels: map[string]string{"env": "dev", "base": "true"},
+				}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cd",
+					Labels: map[string]string{"team": "a", "env": "prod", "base": "true"},
+				}},
+			},
+		},
+		"WildcardObjectPatch": {
+			reason: "Should patch each field of an object matched by a wildcard",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels[*]"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.annotations[*]"),
+					Transforms: []Transform{{
+						Type:   TransformTypeString,
+						String: &StringTransform{Format: pointer.StringPtr("%s-suffix")},
+					}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"a": "1", "b": "2"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:        "cd",
+					Annotations: map[string]string{"a": "1-suffix", "b": "2-suffix"},
+				}},
+			},
+		},
+		"WildcardArrayPatch": {
+			reason: "Should patch the corresponding element of the target array for each element of the source array matched by a wildcard",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.ownerReferences[*].name"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.finalizers[*]"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						OwnerReferences: []metav1.OwnerReference{{Name: "a"}, {Name: "b"}},
 					},
+					ConnectionDetailsLastPublishedTimer: lpt,
 				},
-				err: nil,
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:       "cd",
+					Finalizers: []string{"a", "b"},
+				}},
+			},
+		},
+		"WildcardToFieldPathPatch": {
+			reason: "Should patch every existing element of the target array matched by a wildcard",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.name"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.ownerReferences[*].name"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:            "cd",
+					OwnerReferences: []metav1.OwnerReference{{Kind: "A"}, {Kind: "B"}},
+				}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:            "cd",
+					OwnerReferences: []metav1.OwnerReference{{Kind: "A", Name: "cp"}, {Kind: "B", Name: "cp"}},
+				}},
+			},
+		},
+		"WildcardMismatchPatch": {
+			reason: "Should return an error if the toFieldPath does not have as many wildcards as the fromFieldPath",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels[*]"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.annotations.a"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Labels: map[string]string{"a": "1"}},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtWildcardMismatch, "objectMeta.annotations.a", "objectMeta.labels[*]"),
+			},
+		},
+		"WildcardMissingOptionalPatch": {
+			reason: "Should not patch if there is nothing for an optional wildcard fromFieldPath to match",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels[*]"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+		},
+		"WildcardMissingRequiredPatch": {
+			reason: "Should return an error if there is nothing for a required wildcard fromFieldPath to match",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels[*]"),
+					Policy:        &PatchPolicy{FromFieldPath: &required},
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err: requiredFieldPathNotFound(errors.New("objectMeta.labels: no such field"), "objectMeta.labels[*]"),
+			},
+		},
+		"FromComposedFieldPathPatch": {
+			reason: "Should return an error if a patch from a composed resource is applied from the composite",
+			args: args{
+				patch: Patch{
+					Type:                 PatchTypeFromComposedFieldPath,
+					FromComposedResource: pointer.StringPtr("vpc"),
+					FromFieldPath:        pointer.StringPtr("objectMeta.name"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtRequiresComposedSource, PatchTypeFromComposedFieldPath),
+			},
+		},
+		"FromItemFieldPathPatch": {
+			reason: "Should return an error if a patch from a forEach item is applied without an item",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromItemFieldPath,
+					FromFieldPath: pointer.StringPtr("value"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtRequiresItemSource, PatchTypeFromItemFieldPath),
+			},
+		},
+		"FromEnvironmentFieldPathPatch": {
+			reason: "Should return an error if a patch from the environment is applied without an environment",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromEnvironmentFieldPath,
+					FromFieldPath: pointer.StringPtr("region"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtRequiresEnvironment, PatchTypeFromEnvironmentFieldPath),
+			},
+		},
+		"ExpressionTransformError": {
+			reason: "Errors evaluating an expression transform should be returned",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.name"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.name"),
+					Transforms: []Transform{{
+						Type:       TransformTypeExpression,
+						Expression: &ExpressionTransform{Expression: "input / 0"},
+					}},
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errors.New(`cannot evaluate expression: operator / does not support string and int`), errFmtTransformTypeFailed, TransformTypeExpression), errFmtTransformAtIndex, 0),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ncp := tc.args.cp.DeepCopyObject()
+			err := tc.args.patch.Apply(ncp, tc.args.cd, tc.args.only...)
+
+			if tc.want.cp != nil {
+				if diff := cmp.Diff(tc.want.cp, ncp); diff != "" {
+					t.Errorf("\n%s\nApply(cp): -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+			if tc.want.cd != nil {
+				if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+					t.Errorf("\n%s\nApply(cd): -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApply(err): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPatchApplyFromComposed(t *testing.T) {
+	required := FromFieldPathPolicyRequired
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{
+		Time: &now,
+	}
+
+	type args struct {
+		patch Patch
+		from  runtime.Object
+		cd    *fake.Composed
+	}
+	type want struct {
+		cd  *fake.Composed
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"InvalidPatchType": {
+			reason: "Should return an error if the patch does not read from a composed resource",
+			args: args{
+				patch: Patch{Type: PatchTypeFromCompositeFieldPath},
+				cd:    &fake.Composed{},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Errorf(errInvalidPatchType, PatchTypeFromCompositeFieldPath),
+			},
+		},
+		"MissingFromComposedResource": {
+			reason: "Should return an error if the composed resource to patch from is not specified",
+			args: args{
+				patch: Patch{Type: PatchTypeFromComposedFieldPath},
+				cd:    &fake.Composed{},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Errorf(errRequiredField, "FromComposedResource", PatchTypeFromComposedFieldPath),
+			},
+		},
+		"NotObservedOptional": {
+			reason: "Should skip an optional patch from a composed resource that has not been observed",
+			args: args{
+				patch: Patch{
+					Type:                 PatchTypeFromComposedFieldPath,
+					FromComposedResource: pointer.StringPtr("vpc"),
+					FromFieldPath:        pointer.StringPtr("objectMeta.name"),
+				},
+				cd: &fake.Composed{},
+			},
+			want: want{
+				cd: &fake.Composed{},
+			},
+		},
+		"NotObservedRequired": {
+			reason: "Should return an error if a required patch reads from a composed resource that has not been observed",
+			args: args{
+				patch: Patch{
+					Type:                 PatchTypeFromComposedFieldPath,
+					FromComposedResource: pointer.StringPtr("vpc"),
+					FromFieldPath:        pointer.StringPtr("objectMeta.name"),
+					Policy:               &PatchPolicy{FromFieldPath: &required},
+				},
+				cd: &fake.Composed{},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Errorf(errFmtComposedNotObserved, "vpc"),
+			},
+		},
+		"Success": {
+			reason: "Should patch from one composed resource to another",
+			args: args{
+				patch: Patch{
+					Type:                 PatchTypeFromComposedFieldPath,
+					FromComposedResource: pointer.StringPtr("vpc"),
+					FromFieldPath:        pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:          pointer.StringPtr("objectMeta.labels"),
+				},
+				from: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "vpc",
+					Labels: map[string]string{"test": "blah"},
+				}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cd",
+					Labels: map[string]string{"test": "blah"},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cp := &fake.Composite{ConnectionDetailsLastPublishedTimer: lpt}
+			err := tc.args.patch.ApplyFromComposed(cp, tc.args.from, tc.args.cd)
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nApplyFromComposed(cd): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyFromComposed(err): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMergeOptionsMerge(t *testing.T) {
+	type args struct {
+		mo       *MergeOptions
+		existing interface{}
+		patched  interface{}
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want interface{}
+	}{
+		"MergeObjects": {
+			reason: "Objects should be merged, keeping existing keys and replacing conflicting values",
+			args: args{
+				mo:       &MergeOptions{},
+				existing: map[string]interface{}{"a": "1", "b": "2"},
+				patched:  map[string]interface{}{"b": "3", "c": "4"},
+			},
+			want: map[string]interface{}{"a": "1", "b": "3", "c": "4"},
+		},
+		"MergeObjectsKeepValues": {
+			reason: "Existing values of merged objects should be kept if requested",
+			args: args{
+				mo:       &MergeOptions{KeepMapValues: pointer.BoolPtr(true)},
+				existing: map[string]interface{}{"a": "1", "b": "2"},
+				patched:  map[string]interface{}{"b": "3", "c": "4"},
+			},
+			want: map[string]interface{}{"a": "1", "b": "2", "c": "4"},
+		},
+		"MergeNestedObjects": {
+			reason: "Nested objects should be merged",
+			args: args{
+				mo:       &MergeOptions{},
+				existing: map[string]interface{}{"a": map[string]interface{}{"b": "1"}},
+				patched:  map[string]interface{}{"a": map[string]interface{}{"c": "2"}},
+			},
+			want: map[string]interface{}{"a": map[string]interface{}{"b": "1", "c": "2"}},
+		},
+		"ReplaceSlice": {
+			reason: "Arrays should be replaced unless appending is requested",
+			args: args{
+				mo:       &MergeOptions{},
+				existing: []interface{}{"a"},
+				patched:  []interface{}{"b"},
+			},
+			want: []interface{}{"b"},
+		},
+		"AppendSlice": {
+			reason: "Arrays should be appended to, omitting elements that already exist",
+			args: args{
+				mo:       &MergeOptions{AppendSlice: pointer.BoolPtr(true)},
+				existing: []interface{}{"a", map[string]interface{}{"b": "c"}},
+				patched:  []interface{}{map[string]interface{}{"b": "c"}, "d"},
+			},
+			want: []interface{}{"a", map[string]interface{}{"b": "c"}, "d"},
+		},
+		"AppendNestedSlice": {
+			reason: "Arrays nested within merged objects should be appended to",
+			args: args{
+				mo:       &MergeOptions{AppendSlice: pointer.BoolPtr(true), KeepMapValues: pointer.BoolPtr(true)},
+				existing: map[string]interface{}{"a": []interface{}{"b"}},
+				patched:  map[string]interface{}{"a": []interface{}{"c"}},
+			},
+			want: map[string]interface{}{"a": []interface{}{"b", "c"}},
+		},
+		"MismatchedTypes": {
+			reason: "Values of different types should be replaced",
+			args: args{
+				mo:       &MergeOptions{AppendSlice: pointer.BoolPtr(true)},
+				existing: map[string]interface{}{"a": "b"},
+				patched:  []interface{}{"c"},
+			},
+			want: []interface{}{"c"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.args.mo.merge(tc.args.existing, tc.args.patched)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nmerge(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExpandWildcards(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"ports": []interface{}{80, 443}},
+				map[string]interface{}{"ports": []interface{}{8080}},
+			},
+			"name": "cool",
+		},
+	}
+	_, errNotFound := fieldpath.Pave(obj).GetValue("spec.missing")
+
+	type want struct {
+		paths []string
+		err   error
+	}
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   want
+	}{
+		"NoWildcards": {
+			reason: "A field path without wildcards should be returned unchanged",
+			path:   "spec.name",
+			want:   want{paths: []string{"spec.name"}},
+		},
+		"NestedWildcards": {
+			reason: "Nested wildcards should expand to every matching field path",
+			path:   "spec.rules[*].ports[*]",
+			want:   want{paths: []string{"spec.rules[0].ports[0]", "spec.rules[0].ports[1]", "spec.rules[1].ports[0]"}},
+		},
+		"ObjectWildcard": {
+			reason: "A wildcard should match every field of an object",
+			path:   "spec[*]",
+			want:   want{paths: []string{"spec.name", "spec.rules"}},
+		},
+		"NotFound": {
+			reason: "A wildcard that matches a field that does not exist should return a not found error",
+			path:   "spec.missing[*]",
+			want:   want{err: errNotFound},
+		},
+		"NotExpandable": {
+			reason: "A wildcard that matches a value that is neither an array nor an object should return an error",
+			path:   "spec.name[*]",
+			want:   want{err: errors.Errorf(errFmtWildcardNotExpandable, "spec.name")},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := fieldpath.Parse(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expanded, err := expandWildcards(fieldpath.Pave(obj), s)
+			var paths []string
+			for _, e := range expanded {
+				paths = append(paths, e.String())
+			}
+			if diff := cmp.Diff(tc.want.paths, paths); diff != "" {
+				t.Errorf("\n%s\nexpandWildcards(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nexpandWildcards(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPatchApplyFromItem(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{
+		Time: &now,
+	}
+
+	type args struct {
+		patch Patch
+		item  map[string]interface{}
+		cd    *fake.Composed
+	}
+	type want struct {
+		cd  *fake.Composed
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"InvalidPatchType": {
+			reason: "Should return an error if the patch does not read from a forEach item",
+			args: args{
+				patch: Patch{Type: PatchTypeFromCompositeFieldPath},
+				cd:    &fake.Composed{},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Errorf(errInvalidPatchType, PatchTypeFromCompositeFieldPath),
 			},
 		},
-		"FilterExcludeCompositeFieldPathPatch": {
-			reason: "Should not apply the patch as the PatchType is not present in filter.",
+		"NoItem": {
+			reason: "Should return an error if there is no forEach item to patch from",
 			args: args{
-				patch: Patch{
-					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
-				},
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
-				},
-				only: []PatchType{PatchTypePatchSet},
+				patch: Patch{Type: PatchTypeFromItemFieldPath, FromFieldPath: pointer.StringPtr("key")},
+				cd:    &fake.Composed{},
 			},
 			want: want{
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-					},
-				},
-				err: nil,
+				cd:  &fake.Composed{},
+				err: errors.Errorf(errFmtRequiresItemSource, PatchTypeFromItemFieldPath),
 			},
 		},
-		"FilterIncludeCompositeFieldPathPatch": {
-			reason: "Should apply the patch as the PatchType is present in filter.",
+		"Success": {
+			reason: "Should patch from the supplied forEach item",
 			args: args{
 				patch: Patch{
-					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
-				},
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
+					Type:          PatchTypeFromItemFieldPath,
+					FromFieldPath: pointer.StringPtr("value.size"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels[size]"),
 				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				item: map[string]interface{}{
+					"key":   "a",
+					"index": int64(0),
+					"value": map[string]interface{}{"size": "large"},
 				},
-				only: []PatchType{PatchTypeFromCompositeFieldPath},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
 			},
 			want: want{
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						}},
-				},
-				err: nil,
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cd",
+					Labels: map[string]string{"size": "large"},
+				}},
 			},
 		},
-		"DefaultToFieldCompositeFieldPathPatch": {
-			reason: "Should correctly default the ToFieldPath value if not specified.",
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cp := &fake.Composite{ConnectionDetailsLastPublishedTimer: lpt}
+			err := tc.args.patch.ApplyFromItem(cp, tc.args.item, tc.args.cd)
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nApplyFromItem(cd): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyFromItem(err): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPatchApplyFromEnvironment(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{
+		Time: &now,
+	}
+
+	type args struct {
+		patch Patch
+		env   map[string]interface{}
+		cd    *fake.Composed
+	}
+	type want struct {
+		cd  *fake.Composed
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"InvalidPatchType": {
+			reason: "Should return an error if the patch does not read from the environment",
 			args: args{
-				patch: Patch{
-					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-				},
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-					},
-				},
+				patch: Patch{Type: PatchTypeFromCompositeFieldPath},
+				cd:    &fake.Composed{},
 			},
 			want: want{
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						}},
-				},
-				err: nil,
+				cd:  &fake.Composed{},
+				err: errors.Errorf(errInvalidPatchType, PatchTypeFromCompositeFieldPath),
 			},
 		},
-		"ValidToCompositeFieldPathPatch": {
-			reason: "Should correctly apply a ToCompositeFieldPath patch with valid settings",
+		"NoEnvironment": {
+			reason: "Should return an error if there is no environment to patch from",
+			args: args{
+				patch: Patch{Type: PatchTypeFromEnvironmentFieldPath, FromFieldPath: pointer.StringPtr("region")},
+				cd:    &fake.Composed{},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Errorf(errFmtRequiresEnvironment, PatchTypeFromEnvironmentFieldPath),
+			},
+		},
+		"Success": {
+			reason: "Should patch from the supplied environment",
 			args: args{
 				patch: Patch{
-					Type:          PatchTypeToCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+					Type:          PatchTypeFromEnvironmentFieldPath,
+					FromFieldPath: pointer.StringPtr("network.region"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels[region]"),
 				},
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
+				env: map[string]interface{}{
+					"network": map[string]interface{}{"region": "us-east-1"},
 				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
 			},
 			want: want{
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cd",
+					Labels: map[string]string{"region": "us-east-1"},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cp := &fake.Composite{ConnectionDetailsLastPublishedTimer: lpt}
+			err := tc.args.patch.ApplyFromEnvironment(cp, tc.args.env, tc.args.cd)
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nApplyFromEnvironment(cd): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyFromEnvironment(err): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	type args struct {
+		c    Combine
+		vars []interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"UnsupportedStrategy": {
+			args: args{
+				c: Combine{Strategy: "nope"},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCombineStrategyNotSupported, "nope"),
+			},
+		},
+		"MissingConfig": {
+			args: args{
+				c: Combine{Strategy: CombineStrategyString},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCombineConfigMissing, CombineStrategyString),
+			},
+		},
+		"String": {
+			args: args{
+				c: Combine{
+					Strategy: CombineStrategyString,
+					String:   &StringCombine{Format: "%s-%d"},
 				},
-				err: nil,
+				vars: []interface{}{"a", 1},
+			},
+			want: want{
+				o: "a-1",
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			ncp := tc.args.cp.DeepCopyObject()
-			err := tc.args.patch.Apply(ncp, tc.args.cd, tc.args.only...)
+			got, err := tc.c.Combine(tc.vars)
 
-			if tc.want.cp != nil {
-				if diff := cmp.Diff(tc.want.cp, ncp); diff != "" {
-					t.Errorf("\n%s\nApply(cp): -want, +got:\n%s", tc.reason, diff)
-				}
-			}
-			if tc.want.cd != nil {
-				if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
-					t.Errorf("\n%s\nApply(cd): -want, +got:\n%s", tc.reason, diff)
-				}
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Combine(...): -want, +got:\n%s", diff)
 			}
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nApply(err): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("Combine(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsRequiredFieldPathNotFound(t *testing.T) {
+	notFound := requiredFieldPathNotFound(errors.New("spec.missing: no such field"), "spec.missing")
+
+	cases := map[string]struct {
+		reason string
+		err    error
+		want   bool
+	}{
+		"Nil": {
+			reason: "A nil error is not a required field path error.",
+		},
+		"OtherError": {
+			reason: "An unrelated error is not a required field path error.",
+			err:    errors.New("boom"),
+		},
+		"RequiredFieldPath": {
+			reason: "An error returned for a missing required field path should be identified.",
+			err:    notFound,
+			want:   true,
+		},
+		"WrappedRequiredFieldPath": {
+			reason: "A wrapped error returned for a missing required field path should be identified.",
+			err:    errors.Wrap(errors.Wrap(notFound, "cannot apply patch"), "cannot render"),
+			want:   true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := IsRequiredFieldPathNotFound(tc.err)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nIsRequiredFieldPathNotFound(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
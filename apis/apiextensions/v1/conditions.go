@@ -43,6 +43,11 @@ const (
 	ReasonTerminatingClaim     xpv1.ConditionReason = "TerminatingCompositeResourceClaim"
 )
 
+// Reasons a composite resource or claim is or is not synced.
+const (
+	ReasonReconcilePaused xpv1.ConditionReason = "ReconcilePaused"
+)
+
 // WatchingComposite indicates that Crossplane has defined and is watching for a
 // new kind of composite resource.
 func WatchingComposite() xpv1.Condition {
@@ -86,3 +91,14 @@ func TerminatingClaim() xpv1.Condition {
 		Reason:             ReasonTerminatingClaim,
 	}
 }
+
+// ReconcilePaused indicates that Crossplane has paused reconciliation of a
+// composite resource or claim, because it is annotated as paused.
+func ReconcilePaused() xpv1.Condition {
+	return xpv1.Condition{
+		Type:               xpv1.TypeSynced,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonReconcilePaused,
+	}
+}
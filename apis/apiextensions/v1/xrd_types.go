@@ -64,6 +64,14 @@ type CompositeResourceDefinitionSpec struct {
 	// +immutable
 	EnforcedCompositionRef *xpv1.Reference `json:"enforcedCompositionRef,omitempty"`
 
+	// DefaultCompositeDeletionPolicy is the deletion policy of composite
+	// resources that do not specify their own. Composite resources with the
+	// Orphan deletion policy orphan their composed resources when they are
+	// deleted. Defaults to Delete.
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	DefaultCompositeDeletionPolicy *xpv1.DeletionPolicy `json:"defaultCompositeDeletionPolicy,omitempty"`
+
 	// Versions is the list of all API versions of the defined composite
 	// resource. Version names are used to compute the order in which served
 	// versions are listed in API discovery. If the version string is
@@ -148,6 +156,13 @@ type CompositeResourceDefinitionControllerStatus struct {
 	// version. Note that clients may interact with any served type; this is
 	// simply the type that Crossplane interacts with.
 	CompositeResourceClaimTypeRef TypeReference `json:"compositeResourceClaimType,omitempty"`
+
+	// The ComposedResourceTypeRefs are the types of composed resource that the
+	// composite resource controller is currently watching. Changes to composed
+	// resources of these types trigger a reconcile of the composite resource
+	// that controls them.
+	// +optional
+	ComposedResourceTypeRefs []TypeReference `json:"composedResourceTypes,omitempty"`
 }
 
 // +kubebuilder:object:root=true
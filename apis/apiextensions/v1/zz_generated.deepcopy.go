@@ -22,13 +22,75 @@ package v1
 
 import (
 	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Combine) DeepCopyInto(out *Combine) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]CombineVariable, len(*in))
+		copy(*out, *in)
+	}
+	if in.String != nil {
+		in, out := &in.String, &out.String
+		*out = new(StringCombine)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Combine.
+func (in *Combine) DeepCopy() *Combine {
+	if in == nil {
+		return nil
+	}
+	out := new(Combine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CombineVariable) DeepCopyInto(out *CombineVariable) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CombineVariable.
+func (in *CombineVariable) DeepCopy() *CombineVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(CombineVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(string)
+		**out = **in
+	}
+	if in.ForEach != nil {
+		in, out := &in.ForEach, &out.ForEach
+		*out = new(ForEach)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	in.Base.DeepCopyInto(&out.Base)
 	if in.Patches != nil {
 		in, out := &in.Patches, &out.Patches
@@ -47,7 +109,14 @@ func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 	if in.ReadinessChecks != nil {
 		in, out := &in.ReadinessChecks, &out.ReadinessChecks
 		*out = make([]ReadinessCheck, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReadinessCheckPolicy != nil {
+		in, out := &in.ReadinessCheckPolicy, &out.ReadinessCheckPolicy
+		*out = new(ReadinessCheckPolicy)
+		**out = **in
 	}
 }
 
@@ -93,6 +162,11 @@ func (in *CompositeResourceDefinitionControllerStatus) DeepCopyInto(out *Composi
 	*out = *in
 	out.CompositeResourceTypeRef = in.CompositeResourceTypeRef
 	out.CompositeResourceClaimTypeRef = in.CompositeResourceClaimTypeRef
+	if in.ComposedResourceTypeRefs != nil {
+		in, out := &in.ComposedResourceTypeRefs, &out.ComposedResourceTypeRefs
+		*out = make([]TypeReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositeResourceDefinitionControllerStatus.
@@ -161,6 +235,11 @@ func (in *CompositeResourceDefinitionSpec) DeepCopyInto(out *CompositeResourceDe
 		*out = new(commonv1.Reference)
 		**out = **in
 	}
+	if in.DefaultCompositeDeletionPolicy != nil {
+		in, out := &in.DefaultCompositeDeletionPolicy, &out.DefaultCompositeDeletionPolicy
+		*out = new(commonv1.DeletionPolicy)
+		**out = **in
+	}
 	if in.Versions != nil {
 		in, out := &in.Versions, &out.Versions
 		*out = make([]CompositeResourceDefinitionVersion, len(*in))
@@ -184,7 +263,7 @@ func (in *CompositeResourceDefinitionSpec) DeepCopy() *CompositeResourceDefiniti
 func (in *CompositeResourceDefinitionStatus) DeepCopyInto(out *CompositeResourceDefinitionStatus) {
 	*out = *in
 	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
-	out.Controllers = in.Controllers
+	in.Controllers.DeepCopyInto(&out.Controllers)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositeResourceDefinitionStatus.
@@ -297,10 +376,111 @@ func (in *CompositionList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevision) DeepCopyInto(out *CompositionRevision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevision.
+func (in *CompositionRevision) DeepCopy() *CompositionRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CompositionRevision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevisionList) DeepCopyInto(out *CompositionRevisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CompositionRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevisionList.
+func (in *CompositionRevisionList) DeepCopy() *CompositionRevisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CompositionRevisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevisionSpec) DeepCopyInto(out *CompositionRevisionSpec) {
+	*out = *in
+	in.CompositionSpec.DeepCopyInto(&out.CompositionSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevisionSpec.
+func (in *CompositionRevisionSpec) DeepCopy() *CompositionRevisionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevisionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositionRevisionStatus) DeepCopyInto(out *CompositionRevisionStatus) {
+	*out = *in
+	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionRevisionStatus.
+func (in *CompositionRevisionStatus) DeepCopy() *CompositionRevisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositionRevisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CompositionSpec) DeepCopyInto(out *CompositionSpec) {
 	*out = *in
 	out.CompositeTypeRef = in.CompositeTypeRef
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
 	if in.PatchSets != nil {
 		in, out := &in.PatchSets, &out.PatchSets
 		*out = make([]PatchSet, len(*in))
@@ -320,6 +500,28 @@ func (in *CompositionSpec) DeepCopyInto(out *CompositionSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.DefaultPatchPolicy != nil {
+		in, out := &in.DefaultPatchPolicy, &out.DefaultPatchPolicy
+		*out = new(PatchPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemovedResourcePolicy != nil {
+		in, out := &in.RemovedResourcePolicy, &out.RemovedResourcePolicy
+		*out = new(RemovedResourcePolicy)
+		**out = **in
+	}
+	if in.Functions != nil {
+		in, out := &in.Functions, &out.Functions
+		*out = make([]Function, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Environment != nil {
+		in, out := &in.Environment, &out.Environment
+		*out = new(EnvironmentConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionSpec.
@@ -336,6 +538,11 @@ func (in *CompositionSpec) DeepCopy() *CompositionSpec {
 func (in *CompositionStatus) DeepCopyInto(out *CompositionStatus) {
 	*out = *in
 	in.ConditionedStatus.DeepCopyInto(&out.ConditionedStatus)
+	if in.LatestRevision != nil {
+		in, out := &in.LatestRevision, &out.LatestRevision
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionStatus.
@@ -356,16 +563,38 @@ func (in *ConnectionDetail) DeepCopyInto(out *ConnectionDetail) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(ConnectionDetailType)
+		**out = **in
+	}
 	if in.FromConnectionSecretKey != nil {
 		in, out := &in.FromConnectionSecretKey, &out.FromConnectionSecretKey
 		*out = new(string)
 		**out = **in
 	}
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(string)
+		**out = **in
+	}
 	if in.Value != nil {
 		in, out := &in.Value, &out.Value
 		*out = new(string)
 		**out = **in
 	}
+	if in.Combine != nil {
+		in, out := &in.Combine, &out.Combine
+		*out = new(ConnectionDetailCombine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]Transform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetail.
@@ -378,6 +607,58 @@ func (in *ConnectionDetail) DeepCopy() *ConnectionDetail {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailCombine) DeepCopyInto(out *ConnectionDetailCombine) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]ConnectionDetailVariable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.String != nil {
+		in, out := &in.String, &out.String
+		*out = new(StringCombine)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailCombine.
+func (in *ConnectionDetailCombine) DeepCopy() *ConnectionDetailCombine {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailCombine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailVariable) DeepCopyInto(out *ConnectionDetailVariable) {
+	*out = *in
+	if in.FromConnectionSecretKey != nil {
+		in, out := &in.FromConnectionSecretKey, &out.FromConnectionSecretKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailVariable.
+func (in *ConnectionDetailVariable) DeepCopy() *ConnectionDetailVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConvertTransform) DeepCopyInto(out *ConvertTransform) {
 	*out = *in
@@ -393,14 +674,235 @@ func (in *ConvertTransform) DeepCopy() *ConvertTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentConfiguration) DeepCopyInto(out *EnvironmentConfiguration) {
+	*out = *in
+	if in.EnvironmentConfigs != nil {
+		in, out := &in.EnvironmentConfigs, &out.EnvironmentConfigs
+		*out = make([]EnvironmentSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]Patch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentConfiguration.
+func (in *EnvironmentConfiguration) DeepCopy() *EnvironmentConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSource) DeepCopyInto(out *EnvironmentSource) {
+	*out = *in
+	if in.Ref != nil {
+		in, out := &in.Ref, &out.Ref
+		*out = new(EnvironmentSourceReference)
+		**out = **in
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(EnvironmentSourceSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSource.
+func (in *EnvironmentSource) DeepCopy() *EnvironmentSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSourceReference) DeepCopyInto(out *EnvironmentSourceReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSourceReference.
+func (in *EnvironmentSourceReference) DeepCopy() *EnvironmentSourceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSourceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSourceSelector) DeepCopyInto(out *EnvironmentSourceSelector) {
+	*out = *in
+	if in.MatchLabels != nil {
+		in, out := &in.MatchLabels, &out.MatchLabels
+		*out = make([]EnvironmentSourceSelectorLabelMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSourceSelector.
+func (in *EnvironmentSourceSelector) DeepCopy() *EnvironmentSourceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSourceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSourceSelectorLabelMatcher) DeepCopyInto(out *EnvironmentSourceSelectorLabelMatcher) {
+	*out = *in
+	if in.ValueFromFieldPath != nil {
+		in, out := &in.ValueFromFieldPath, &out.ValueFromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSourceSelectorLabelMatcher.
+func (in *EnvironmentSourceSelectorLabelMatcher) DeepCopy() *EnvironmentSourceSelectorLabelMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSourceSelectorLabelMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExecFunction) DeepCopyInto(out *ExecFunction) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecFunction.
+func (in *ExecFunction) DeepCopy() *ExecFunction {
+	if in == nil {
+		return nil
+	}
+	out := new(ExecFunction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpressionTransform) DeepCopyInto(out *ExpressionTransform) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpressionTransform.
+func (in *ExpressionTransform) DeepCopy() *ExpressionTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpressionTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForEach) DeepCopyInto(out *ForEach) {
+	*out = *in
+	if in.KeyFieldPath != nil {
+		in, out := &in.KeyFieldPath, &out.KeyFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForEach.
+func (in *ForEach) DeepCopy() *ForEach {
+	if in == nil {
+		return nil
+	}
+	out := new(ForEach)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Function) DeepCopyInto(out *Function) {
+	*out = *in
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ExecFunction)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Function.
+func (in *Function) DeepCopy() *Function {
+	if in == nil {
+		return nil
+	}
+	out := new(Function)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapFallback) DeepCopyInto(out *MapFallback) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapFallback.
+func (in *MapFallback) DeepCopy() *MapFallback {
+	if in == nil {
+		return nil
+	}
+	out := new(MapFallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MapTransform) DeepCopyInto(out *MapTransform) {
 	*out = *in
 	if in.Pairs != nil {
 		in, out := &in.Pairs, &out.Pairs
-		*out = make(map[string]string, len(*in))
+		*out = make(map[string]apiextensionsv1.JSON, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 }
@@ -415,12 +917,115 @@ func (in *MapTransform) DeepCopy() *MapTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchConditionReadinessCheck) DeepCopyInto(out *MatchConditionReadinessCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchConditionReadinessCheck.
+func (in *MatchConditionReadinessCheck) DeepCopy() *MatchConditionReadinessCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchConditionReadinessCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchTransform) DeepCopyInto(out *MatchTransform) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]MatchTransformPattern, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FallbackValue != nil {
+		in, out := &in.FallbackValue, &out.FallbackValue
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchTransform.
+func (in *MatchTransform) DeepCopy() *MatchTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchTransformPattern) DeepCopyInto(out *MatchTransformPattern) {
+	*out = *in
+	if in.Literal != nil {
+		in, out := &in.Literal, &out.Literal
+		*out = new(string)
+		**out = **in
+	}
+	if in.Regexp != nil {
+		in, out := &in.Regexp, &out.Regexp
+		*out = new(string)
+		**out = **in
+	}
+	in.Result.DeepCopyInto(&out.Result)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchTransformPattern.
+func (in *MatchTransformPattern) DeepCopy() *MatchTransformPattern {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchTransformPattern)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 	*out = *in
 	if in.Multiply != nil {
 		in, out := &in.Multiply, &out.Multiply
-		*out = new(int64)
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Subtract != nil {
+		in, out := &in.Subtract, &out.Subtract
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.Divide != nil {
+		in, out := &in.Divide, &out.Divide
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.ClampMin != nil {
+		in, out := &in.ClampMin, &out.ClampMin
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.ClampMax != nil {
+		in, out := &in.ClampMax, &out.ClampMax
+		*out = new(MathOperand)
+		**out = **in
+	}
+	if in.RoundingMode != nil {
+		in, out := &in.RoundingMode, &out.RoundingMode
+		*out = new(MathRoundingMode)
+		**out = **in
+	}
+	if in.OutputType != nil {
+		in, out := &in.OutputType, &out.OutputType
+		*out = new(string)
 		**out = **in
 	}
 }
@@ -435,6 +1040,31 @@ func (in *MathTransform) DeepCopy() *MathTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeOptions) DeepCopyInto(out *MergeOptions) {
+	*out = *in
+	if in.KeepMapValues != nil {
+		in, out := &in.KeepMapValues, &out.KeepMapValues
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AppendSlice != nil {
+		in, out := &in.AppendSlice, &out.AppendSlice
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeOptions.
+func (in *MergeOptions) DeepCopy() *MergeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Patch) DeepCopyInto(out *Patch) {
 	*out = *in
@@ -443,6 +1073,16 @@ func (in *Patch) DeepCopyInto(out *Patch) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FromComposedResource != nil {
+		in, out := &in.FromComposedResource, &out.FromComposedResource
+		*out = new(string)
+		**out = **in
+	}
+	if in.Combine != nil {
+		in, out := &in.Combine, &out.Combine
+		*out = new(Combine)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ToFieldPath != nil {
 		in, out := &in.ToFieldPath, &out.ToFieldPath
 		*out = new(string)
@@ -460,6 +1100,11 @@ func (in *Patch) DeepCopyInto(out *Patch) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(PatchPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Patch.
@@ -472,6 +1117,31 @@ func (in *Patch) DeepCopy() *Patch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchPolicy) DeepCopyInto(out *PatchPolicy) {
+	*out = *in
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(FromFieldPathPolicy)
+		**out = **in
+	}
+	if in.MergeOptions != nil {
+		in, out := &in.MergeOptions, &out.MergeOptions
+		*out = new(MergeOptions)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchPolicy.
+func (in *PatchPolicy) DeepCopy() *PatchPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PatchPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PatchSet) DeepCopyInto(out *PatchSet) {
 	*out = *in
@@ -497,6 +1167,11 @@ func (in *PatchSet) DeepCopy() *PatchSet {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 	*out = *in
+	if in.MatchCondition != nil {
+		in, out := &in.MatchCondition, &out.MatchCondition
+		*out = new(MatchConditionReadinessCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
@@ -509,9 +1184,49 @@ func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringCombine) DeepCopyInto(out *StringCombine) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringCombine.
+func (in *StringCombine) DeepCopy() *StringCombine {
+	if in == nil {
+		return nil
+	}
+	out := new(StringCombine)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 	*out = *in
+	if in.Format != nil {
+		in, out := &in.Format, &out.Format
+		*out = new(string)
+		**out = **in
+	}
+	if in.Convert != nil {
+		in, out := &in.Convert, &out.Convert
+		*out = new(StringConversionType)
+		**out = **in
+	}
+	if in.Trim != nil {
+		in, out := &in.Trim, &out.Trim
+		*out = new(string)
+		**out = **in
+	}
+	if in.Regexp != nil {
+		in, out := &in.Regexp, &out.Regexp
+		*out = new(StringTransformRegexp)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Separator != nil {
+		in, out := &in.Separator, &out.Separator
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransform.
@@ -524,6 +1239,26 @@ func (in *StringTransform) DeepCopy() *StringTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformRegexp) DeepCopyInto(out *StringTransformRegexp) {
+	*out = *in
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformRegexp.
+func (in *StringTransformRegexp) DeepCopy() *StringTransformRegexp {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformRegexp)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Transform) DeepCopyInto(out *Transform) {
 	*out = *in
@@ -537,16 +1272,31 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(MapTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MapFallback != nil {
+		in, out := &in.MapFallback, &out.MapFallback
+		*out = new(MapFallback)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = new(MatchTransform)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.String != nil {
 		in, out := &in.String, &out.String
 		*out = new(StringTransform)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Convert != nil {
 		in, out := &in.Convert, &out.Convert
 		*out = new(ConvertTransform)
 		**out = **in
 	}
+	if in.Expression != nil {
+		in, out := &in.Expression, &out.Expression
+		*out = new(ExpressionTransform)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transform.
@@ -30,6 +30,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane/apis"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
 	"github.com/crossplane/crossplane/internal/controller/pkg"
 	"github.com/crossplane/crossplane/internal/xpkg"
 )
@@ -41,6 +42,9 @@ type Command struct {
 	CacheDir       string
 	LeaderElection bool
 	Sync           time.Duration
+
+	EnableCompositionFunctions bool
+	CompositionFunctionDir     string
 }
 
 // FromKingpin produces the core Crossplane command from a Kingpin command.
@@ -50,6 +54,8 @@ func FromKingpin(cmd *kingpin.CmdClause) *Command {
 	cmd.Flag("cache-dir", "Directory used for caching package images.").Short('c').Default("/cache").OverrideDefaultFromEnvar("CACHE_DIR").ExistingDirVar(&c.CacheDir)
 	cmd.Flag("sync", "Controller manager sync period duration such as 300ms, 1.5h or 2h45m").Short('s').Default("1h").DurationVar(&c.Sync)
 	cmd.Flag("leader-election", "Use leader election for the conroller manager.").Short('l').Default("false").OverrideDefaultFromEnvar("LEADER_ELECTION").BoolVar(&c.LeaderElection)
+	cmd.Flag("enable-composition-functions", "Enable support for alpha Exec composition functions.").Default("false").BoolVar(&c.EnableCompositionFunctions)
+	cmd.Flag("composition-function-dir", "Directory containing the only commands that Exec composition functions may run.").Default("/functions").StringVar(&c.CompositionFunctionDir)
 	return c
 }
 
@@ -89,7 +95,15 @@ func (c *Command) Run(log logging.Logger) error {
 		return errors.Wrap(err, "Cannot add core Crossplane APIs to scheme")
 	}
 
-	if err := apiextensions.Setup(mgr, log); err != nil {
+	// Exec composition functions run arbitrary commands within Crossplane's
+	// container, so they must be explicitly enabled by its operator.
+	var xo []composite.ReconcilerOption
+	if c.EnableCompositionFunctions {
+		log.Info("Alpha feature enabled", "flag", "enable-composition-functions", "dir", c.CompositionFunctionDir)
+		xo = append(xo, composite.WithPipelineRenderer(composite.NewFunctionPipelineRenderer(composite.NewExecFunctionRunner(c.CompositionFunctionDir))))
+	}
+
+	if err := apiextensions.Setup(mgr, log, xo...); err != nil {
 		return errors.Wrap(err, "Cannot setup API extension controllers")
 	}
 
@@ -21,19 +21,22 @@ import (
 
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composition"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/definition"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/offered"
 )
 
-// Setup API extensions controllers.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+// Setup API extensions controllers. The supplied options configure the
+// reconcilers of composite resources.
+func Setup(mgr ctrl.Manager, l logging.Logger, xo ...composite.ReconcilerOption) error {
 	for _, setup := range []func(ctrl.Manager, logging.Logger) error{
-		definition.Setup,
+		composition.Setup,
 		offered.Setup,
 	} {
 		if err := setup(mgr, l); err != nil {
 			return err
 		}
 	}
-	return nil
+	return definition.Setup(mgr, l, xo...)
 }
@@ -38,6 +38,9 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/claim"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
 const (
@@ -65,6 +68,7 @@ const (
 	reasonCompositeConfigure event.Reason = "ConfigureCompositeResource"
 	reasonClaimConfigure     event.Reason = "ConfigureClaim"
 	reasonPropagate          event.Reason = "PropagateConnectionSecret"
+	reasonPaused             event.Reason = "ReconciliationPaused"
 )
 
 // ControllerName returns the recommended name for controllers that use this
@@ -286,6 +290,26 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		"external-name", meta.GetExternalName(cm),
 	)
 
+	// We don't touch a paused claim or its composite resource unless it is
+	// being deleted. We'll be queued again when the pause annotation is
+	// removed. We only record an event and update our status when we become
+	// paused, not each time we're reconciled while paused.
+	if cm.GetAnnotations()[xcrd.AnnotationKeyReconciliationPaused] == "true" && !meta.WasDeleted(cm) {
+		log.Debug("Reconciliation is paused")
+		if cm.GetCondition(xpv1.TypeSynced).Reason == v1.ReasonReconcilePaused {
+			return reconcile.Result{}, nil
+		}
+		record.Event(cm, event.Normal(reasonPaused, "Reconciliation is paused via the pause annotation"))
+		cm.SetConditions(v1.ReconcilePaused())
+		return reconcile.Result{}, errors.Wrap(r.client.Status().Update(ctx, cm), errUpdateClaimStatus)
+	}
+
+	// Reconciliation is no longer paused. Our status will be updated once
+	// we're done reconciling.
+	if cm.GetCondition(xpv1.TypeSynced).Reason == v1.ReasonReconcilePaused {
+		cm.SetConditions(xpv1.ReconcileSuccess())
+	}
+
 	cp := r.newComposite()
 	if ref := cm.GetResourceReference(); ref != nil {
 		record = record.WithAnnotations("composite-name", cm.GetResourceReference().Name)
@@ -36,6 +36,9 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/claim"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
 func TestReconcile(t *testing.T) {
@@ -73,6 +76,102 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{},
 			},
 		},
+		"ReconciliationPaused": {
+			reason: "We should not touch our composite resource, but should report that reconciliation is paused, when the claim is annotated as paused.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								switch o := obj.(type) {
+								case *claim.Unstructured:
+									o.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+									o.SetResourceReference(&corev1.ObjectReference{})
+								case *composite.Unstructured:
+									t.Errorf("Get(...): unexpected call for the composite resource of a paused claim")
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := claim.New(claim.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+								want.SetResourceReference(&corev1.ObjectReference{})
+								want.SetConditions(v1.ReconcilePaused())
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"ReconciliationAlreadyPaused": {
+			reason: "We should not update our status when reconciliation of the claim is already reported as paused.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								switch o := obj.(type) {
+								case *claim.Unstructured:
+									o.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+									o.SetConditions(v1.ReconcilePaused())
+								case *composite.Unstructured:
+									t.Errorf("Get(...): unexpected call for the composite resource of a paused claim")
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								t.Errorf("StatusUpdate(...): unexpected call for an already paused claim")
+								return nil
+							}),
+						},
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"DeletePausedClaim": {
+			reason: "We should delete the bound composite resource of a deleted claim even if it is annotated as paused.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if o, ok := obj.(*claim.Unstructured); ok {
+									now := metav1.Now()
+									o.SetDeletionTimestamp(&now)
+									o.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+									o.SetResourceReference(&corev1.ObjectReference{})
+								}
+								return nil
+							}),
+							MockDelete: test.NewMockDeleteFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								t.Errorf("StatusUpdate(...): unexpected call for a deleted claim")
+								return nil
+							}),
+						},
+					}),
+					WithClaimFinalizer(resource.FinalizerFns{
+						RemoveFinalizerFn: func(ctx context.Context, obj resource.Object) error { return nil },
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
 		"GetCompositeError": {
 			reason: "We should requeue after a short wait if we encounter an error while getting the referenced composite resource",
 			args: args{
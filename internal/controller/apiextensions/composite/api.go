@@ -18,18 +18,27 @@ package composite
 
 import (
 	"context"
-	"math/rand"
-	"time"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
@@ -44,9 +53,14 @@ const (
 
 	errNoCompatibleComposition  = "no compatible composition has been found"
 	errListCompositions         = "cannot list compositions"
+	errParseSelector            = "cannot parse composition selector"
 	errUpdateComposite          = "cannot update composite resource"
 	errCompositionNotCompatible = "referenced composition is not compatible with this composite resource"
 	errGetXRD                   = "cannot get composite resource definition"
+	errGetCompRev               = "cannot get CompositionRevision"
+
+	errFmtPinnedRevNotFound = "composite resource is pinned to CompositionRevision %q, which does not exist"
+	errFmtPinnedRevDeleted  = "composite resource is pinned to CompositionRevision %q, which is being deleted"
 )
 
 // Event reasons.
@@ -135,43 +149,48 @@ func (r *CompositionSelectorChain) SelectComposition(ctx context.Context, cp res
 }
 
 // NewAPILabelSelectorResolver returns a SelectorResolver for composite resource.
-func NewAPILabelSelectorResolver(c client.Client) *APILabelSelectorResolver {
-	return &APILabelSelectorResolver{client: c}
+func NewAPILabelSelectorResolver(c client.Client, r event.Recorder) *APILabelSelectorResolver {
+	return &APILabelSelectorResolver{client: c, recorder: r}
 }
 
 // APILabelSelectorResolver is used to resolve the composition selector on the instance
 // to composition reference.
 type APILabelSelectorResolver struct {
-	client client.Client
+	client   client.Client
+	recorder event.Recorder
 }
 
 // SelectComposition resolves selector to a reference if it doesn't exist.
+// Compatible Compositions with the highest priority are candidates for
+// selection. If there are several candidates one is chosen by weighted
+// rendezvous hashing of the composite resource's UID, so that the same
+// composite resource always selects the same Composition.
 func (r *APILabelSelectorResolver) SelectComposition(ctx context.Context, cp resource.Composite) error {
 	// TODO(muvaf): need to block the deletion of composition via finalizer once
 	// it's selected since it's integral to this resource.
-	// TODO(muvaf): We don't rely on UID in practice. It should not be there
-	// because it will make confusion if the resource is backed up and restored
-	// to another cluster
 	if cp.GetCompositionReference() != nil {
 		return nil
 	}
-	labels := map[string]string{}
-	sel := cp.GetCompositionSelector()
-	if sel != nil {
-		labels = sel.MatchLabels
+	ls := labels.Everything()
+	if sel := cp.GetCompositionSelector(); sel != nil {
+		s, err := metav1.LabelSelectorAsSelector(sel)
+		if err != nil {
+			return errors.Wrap(err, errParseSelector)
+		}
+		ls = s
 	}
 	list := &v1.CompositionList{}
-	if err := r.client.List(ctx, list, client.MatchingLabels(labels)); err != nil {
+	if err := r.client.List(ctx, list, client.MatchingLabelsSelector{Selector: ls}); err != nil {
 		return errors.Wrap(err, errListCompositions)
 	}
 
-	candidates := make([]string, 0, len(list.Items))
+	candidates := make([]v1.Composition, 0, len(list.Items))
 	v, k := cp.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
 
 	for _, comp := range list.Items {
 		if comp.Spec.CompositeTypeRef.APIVersion == v && comp.Spec.CompositeTypeRef.Kind == k {
 			// This composition is compatible with our composite resource.
-			candidates = append(candidates, comp.Name)
+			candidates = append(candidates, comp)
 		}
 	}
 
@@ -179,11 +198,62 @@ func (r *APILabelSelectorResolver) SelectComposition(ctx context.Context, cp res
 		return errors.New(errNoCompatibleComposition)
 	}
 
-	// We don't need this choice to be cryptographically random.
-	random := rand.New(rand.NewSource(time.Now().UnixNano())) // nolint:gosec
-	selected := candidates[random.Intn(len(candidates))]
-	cp.SetCompositionReference(&corev1.ObjectReference{Name: selected})
-	return errors.Wrap(r.client.Update(ctx, cp), errUpdateComposite)
+	selected := selectComposition(string(cp.GetUID()), candidates)
+	cp.SetCompositionReference(&corev1.ObjectReference{Name: selected.Name})
+	if err := r.client.Update(ctx, cp); err != nil {
+		return errors.Wrap(err, errUpdateComposite)
+	}
+
+	considered := make([]string, len(candidates))
+	for i := range candidates {
+		considered[i] = fmt.Sprintf("%s (priority %d, weight %d)", candidates[i].Name, priorityOf(candidates[i]), weightOf(candidates[i]))
+	}
+	sort.Strings(considered)
+	r.recorder.Event(cp, event.Normal(reasonCompositionSelection,
+		fmt.Sprintf("Selected composition %s from %d compatible candidates by priority, then by weighted hash of the composite resource's UID", selected.Name, len(candidates)),
+		"candidates", strings.Join(considered, ", ")))
+	return nil
+}
+
+// selectComposition deterministically selects one of the supplied candidates
+// for the supplied key. Candidates with the highest priority are always
+// preferred. Ties are broken by weighted rendezvous hashing: each candidate is
+// scored by hashing the key with its name, and the highest score wins. A
+// candidate with twice the weight of another wins about twice as often.
+func selectComposition(key string, candidates []v1.Composition) v1.Composition {
+	var selected v1.Composition
+	var best float64
+	for i, comp := range candidates {
+		p, sp := priorityOf(comp), priorityOf(selected)
+		score := rendezvousScore(key, comp.Name, weightOf(comp))
+		if i == 0 || p > sp || (p == sp && (score > best || (score == best && comp.Name < selected.Name))) {
+			selected, best = comp, score
+		}
+	}
+	return selected
+}
+
+// rendezvousScore returns the weighted rendezvous hashing score of the
+// supplied key and name.
+func rendezvousScore(key, name string, weight int32) float64 {
+	sum := sha256.Sum256([]byte(key + "/" + name))
+	// Map the hash uniformly onto the open interval (0, 1).
+	u := (float64(binary.BigEndian.Uint64(sum[:8])>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(u)
+}
+
+func priorityOf(comp v1.Composition) int32 {
+	if comp.Spec.Priority == nil {
+		return 0
+	}
+	return *comp.Spec.Priority
+}
+
+func weightOf(comp v1.Composition) int32 {
+	if comp.Spec.Weight == nil || *comp.Spec.Weight < 1 {
+		return 1
+	}
+	return *comp.Spec.Weight
 }
 
 // NewAPIDefaultCompositionSelector returns a APIDefaultCompositionSelector.
@@ -248,6 +318,120 @@ func (s *EnforcedCompositionSelector) SelectComposition(_ context.Context, cp re
 	return nil
 }
 
+// NewAPIRevisionFetcher returns a RevisionFetcher that fetches the
+// CompositionRevision a composite resource should use.
+func NewAPIRevisionFetcher(c client.Client) *APIRevisionFetcher {
+	return &APIRevisionFetcher{client: c}
+}
+
+// An APIRevisionFetcher fetches the CompositionRevision a composite resource
+// should use.
+type APIRevisionFetcher struct {
+	client client.Client
+}
+
+// FetchRevision returns the supplied Composition at the revision the supplied
+// composite resource should use. Composite resources with an Automatic update
+// policy always use, and are updated to reference, the Composition's latest
+// revision. Composite resources with a Manual update policy continue to use the
+// revision they reference, and return an error naming it if it does not exist
+// or is being deleted.
+func (f *APIRevisionFetcher) FetchRevision(ctx context.Context, cp resource.Composite, comp *v1.Composition) (*v1.Composition, error) {
+	pinned := getCompositionRevisionRef(cp)
+	if pinned != "" && getCompositionUpdatePolicy(cp) == v1.UpdateManual {
+		rev := &v1.CompositionRevision{}
+		err := f.client.Get(ctx, types.NamespacedName{Name: pinned}, rev)
+		if kerrors.IsNotFound(err) {
+			return nil, errors.Errorf(errFmtPinnedRevNotFound, pinned)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetCompRev)
+		}
+		if meta.WasDeleted(rev) {
+			return nil, errors.Errorf(errFmtPinnedRevDeleted, pinned)
+		}
+		// The composite resource may have been pointed at a different
+		// Composition since it was pinned to this revision.
+		if rev.GetLabels()[v1.LabelCompositionName] == comp.GetName() {
+			return atRevision(comp, rev), nil
+		}
+	}
+
+	// A Composition that has not yet been revised, for example because it
+	// predates revisions, is used as is.
+	if comp.Status.LatestRevision == nil {
+		return comp, nil
+	}
+
+	latest := comp.Status.LatestRevision.Name
+	rev := &v1.CompositionRevision{}
+	if err := f.client.Get(ctx, types.NamespacedName{Name: latest}, rev); err != nil {
+		return nil, errors.Wrap(err, errGetCompRev)
+	}
+
+	if pinned != latest {
+		if err := setCompositionRevisionRef(cp, latest); err != nil {
+			return nil, errors.Wrap(err, errUpdateComposite)
+		}
+		if err := f.client.Update(ctx, cp); err != nil {
+			return nil, errors.Wrap(err, errUpdateComposite)
+		}
+	}
+
+	return atRevision(comp, rev), nil
+}
+
+// atRevision returns the supplied Composition with the spec of the supplied
+// revision.
+func atRevision(comp *v1.Composition, rev *v1.CompositionRevision) *v1.Composition {
+	out := comp.DeepCopy()
+	out.Spec = *rev.Spec.CompositionSpec.DeepCopy()
+	return out
+}
+
+func getCompositionRevisionRef(cp resource.Composite) string {
+	u, ok := cp.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return ""
+	}
+	name, _ := fieldpath.Pave(u.UnstructuredContent()).GetString("spec.compositionRevisionRef.name")
+	return name
+}
+
+func setCompositionRevisionRef(cp resource.Composite, name string) error {
+	u, ok := cp.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return nil
+	}
+	return fieldpath.Pave(u.UnstructuredContent()).SetValue("spec.compositionRevisionRef.name", name)
+}
+
+func getCompositionUpdatePolicy(cp resource.Composite) v1.UpdatePolicy {
+	u, ok := cp.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return v1.UpdateAutomatic
+	}
+	p, _ := fieldpath.Pave(u.UnstructuredContent()).GetString("spec.compositionUpdatePolicy")
+	if p == "" {
+		return v1.UpdateAutomatic
+	}
+	return v1.UpdatePolicy(p)
+}
+
+// getDeletionPolicy returns the deletion policy of the supplied composite
+// resource, which defaults to Delete.
+func getDeletionPolicy(cp resource.Composite) xpv1.DeletionPolicy {
+	u, ok := cp.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return xpv1.DeletionDelete
+	}
+	p, _ := fieldpath.Pave(u.UnstructuredContent()).GetString("spec.deletionPolicy")
+	if p == "" {
+		return xpv1.DeletionDelete
+	}
+	return xpv1.DeletionPolicy(p)
+}
+
 // NewConfiguratorChain returns a new *ConfiguratorChain.
 func NewConfiguratorChain(l ...Configurator) *ConfiguratorChain {
 	return &ConfiguratorChain{list: l}
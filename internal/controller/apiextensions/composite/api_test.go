@@ -17,22 +17,27 @@ package composite
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
@@ -251,6 +256,20 @@ func TestSelectorResolver(t *testing.T) {
 		},
 	}
 	sel := &metav1.LabelSelector{MatchLabels: map[string]string{"select": "me"}}
+	exprSel := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "select", Operator: metav1.LabelSelectorOpIn, Values: []string{"me"}},
+	}}
+	badSel := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "select", Operator: "Bogus"},
+	}}
+	_, badSelErr := metav1.LabelSelectorAsSelector(badSel)
+
+	high := comp.DeepCopy()
+	high.SetName("high")
+	high.Spec.Priority = pointer.Int32Ptr(10)
+	low := comp.DeepCopy()
+	low.SetName("low")
+	low.Spec.Priority = pointer.Int32Ptr(-10)
 
 	type args struct {
 		kube client.Client
@@ -339,10 +358,92 @@ func TestSelectorResolver(t *testing.T) {
 				},
 			},
 		},
+		"InvalidSelector": {
+			reason: "Should fail if the composition selector cannot be parsed",
+			args: args{
+				cp: &fake.Composite{
+					CompositionSelector: fake.CompositionSelector{Sel: badSel},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					CompositionSelector: fake.CompositionSelector{Sel: badSel},
+				},
+				err: errors.Wrap(badSelErr, errParseSelector),
+			},
+		},
+		"MatchExpressions": {
+			reason: "Should list Compositions using the label expressions of the selector",
+			args: args{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockList: func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+						lo := &client.ListOptions{}
+						lo.ApplyOptions(opts)
+						if diff := cmp.Diff("select in (me)", lo.LabelSelector.String()); diff != "" {
+							t.Errorf("List(...): -want, +got:\n%s", diff)
+						}
+						(&v1.CompositionList{Items: []v1.Composition{*comp}}).DeepCopyInto(obj.(*v1.CompositionList))
+						return nil
+					}},
+				cp: &fake.Composite{
+					CompositionSelector: fake.CompositionSelector{Sel: exprSel},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					CompositionReferencer: fake.CompositionReferencer{Ref: &corev1.ObjectReference{Name: comp.Name}},
+					CompositionSelector:   fake.CompositionSelector{Sel: exprSel},
+				},
+			},
+		},
+		"SelectedHighestPriority": {
+			reason: "Should select the compatible Composition with the highest priority",
+			args: args{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(nil),
+					MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+						(&v1.CompositionList{Items: []v1.Composition{*comp, *high, *low}}).DeepCopyInto(obj.(*v1.CompositionList))
+						return nil
+					}},
+				cp: &fake.Composite{
+					ObjectMeta:          metav1.ObjectMeta{UID: "some-uid"},
+					CompositionSelector: fake.CompositionSelector{Sel: sel},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta:            metav1.ObjectMeta{UID: "some-uid"},
+					CompositionReferencer: fake.CompositionReferencer{Ref: &corev1.ObjectReference{Name: high.Name}},
+					CompositionSelector:   fake.CompositionSelector{Sel: sel},
+				},
+			},
+		},
+		"UpdateFailed": {
+			reason: "Should fail if the composite resource cannot be updated",
+			args: args{
+				kube: &test.MockClient{
+					MockUpdate: test.NewMockUpdateFn(errBoom),
+					MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+						(&v1.CompositionList{Items: []v1.Composition{*comp}}).DeepCopyInto(obj.(*v1.CompositionList))
+						return nil
+					}},
+				cp: &fake.Composite{
+					CompositionSelector: fake.CompositionSelector{Sel: sel},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					CompositionReferencer: fake.CompositionReferencer{Ref: &corev1.ObjectReference{Name: comp.Name}},
+					CompositionSelector:   fake.CompositionSelector{Sel: sel},
+				},
+				err: errors.Wrap(errBoom, errUpdateComposite),
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			c := NewAPILabelSelectorResolver(tc.args.kube)
+			c := NewAPILabelSelectorResolver(tc.args.kube, event.NewNopRecorder())
 			err := c.SelectComposition(context.Background(), tc.args.cp)
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
 				t.Errorf("\n%s\nSelectComposition(...): -want, +got:\n%s", tc.reason, diff)
@@ -354,6 +455,38 @@ func TestSelectorResolver(t *testing.T) {
 	}
 }
 
+func TestSelectComposition(t *testing.T) {
+	a := v1.Composition{ObjectMeta: metav1.ObjectMeta{Name: "a"}}
+	b := v1.Composition{ObjectMeta: metav1.ObjectMeta{Name: "b"}}
+	heavy := v1.Composition{ObjectMeta: metav1.ObjectMeta{Name: "heavy"}, Spec: v1.CompositionSpec{Weight: pointer.Int32Ptr(3)}}
+
+	t.Run("Deterministic", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("uid-%d", i)
+			forward := selectComposition(key, []v1.Composition{a, b, heavy})
+			reverse := selectComposition(key, []v1.Composition{heavy, b, a})
+			if diff := cmp.Diff(forward.Name, reverse.Name); diff != "" {
+				t.Errorf("selectComposition(%q, ...): selection depends on candidate order: -forward, +reverse:\n%s", key, diff)
+			}
+		}
+	})
+
+	t.Run("Weighted", func(t *testing.T) {
+		n := 2000
+		got := 0
+		for i := 0; i < n; i++ {
+			if selectComposition(fmt.Sprintf("uid-%d", i), []v1.Composition{a, heavy}).Name == heavy.Name {
+				got++
+			}
+		}
+		// A Composition with weight 3 should win about 75% of the time
+		// against one with weight 1.
+		if ratio := float64(got) / float64(n); ratio < 0.7 || ratio > 0.8 {
+			t.Errorf("selectComposition(...): want heavy Composition selected about 75%% of the time, got %.2f", ratio)
+		}
+	})
+}
+
 func TestAPIDefaultCompositionSelector(t *testing.T) {
 	a, k := schema.EmptyObjectKind.GroupVersionKind().ToAPIVersionAndKind()
 	tref := v1.TypeReference{APIVersion: a, Kind: k}
@@ -619,3 +752,203 @@ func TestAPINamingConfigurator(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIRevisionFetcher(t *testing.T) {
+	comp := &v1.Composition{
+		ObjectMeta: metav1.ObjectMeta{Name: "cool-composition"},
+		Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XR"},
+		},
+		Status: v1.CompositionStatus{
+			LatestRevision: &corev1.LocalObjectReference{Name: "cool-composition-new"},
+		},
+	}
+
+	old := comp.DeepCopy()
+	old.Spec.WriteConnectionSecretsToNamespace = pointer.StringPtr("old")
+
+	// The revision a composite resource could be pinned to.
+	pinned := v1.NewCompositionRevision(old, 1)
+	pinned.SetName("cool-composition-old")
+
+	latest := v1.NewCompositionRevision(comp, 2)
+	latest.SetName("cool-composition-new")
+
+	now := metav1.Now()
+	deleted := pinned.DeepCopy()
+	deleted.SetDeletionTimestamp(&now)
+
+	other := pinned.DeepCopy()
+	other.SetLabels(map[string]string{v1.LabelCompositionName: "other-composition"})
+
+	xr := func(p v1.UpdatePolicy, rev string) *composite.Unstructured {
+		cp := composite.New()
+		if p != "" {
+			_ = fieldpath.Pave(cp.Object).SetValue("spec.compositionUpdatePolicy", string(p))
+		}
+		if rev != "" {
+			_ = fieldpath.Pave(cp.Object).SetValue("spec.compositionRevisionRef.name", rev)
+		}
+		return cp
+	}
+
+	getRevs := func(revs ...*v1.CompositionRevision) test.MockGetFn {
+		return func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			for _, rev := range revs {
+				if rev.GetName() == key.Name {
+					rev.DeepCopyInto(obj.(*v1.CompositionRevision))
+					return nil
+				}
+			}
+			return errBoom
+		}
+	}
+
+	type args struct {
+		kube client.Client
+		cp   resource.Composite
+		comp *v1.Composition
+	}
+	type want struct {
+		cp   resource.Composite
+		comp *v1.Composition
+		err  error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NotRevised": {
+			reason: "A Composition that has not yet been revised should be used as is.",
+			args: args{
+				cp:   xr("", ""),
+				comp: &v1.Composition{},
+			},
+			want: want{
+				cp:   xr("", ""),
+				comp: &v1.Composition{},
+			},
+		},
+		"GetLatestError": {
+			reason: "We should return any error encountered while getting the latest revision.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cp:   xr("", ""),
+				comp: comp,
+			},
+			want: want{
+				cp:  xr("", ""),
+				err: errors.Wrap(errBoom, errGetCompRev),
+			},
+		},
+		"UpdateCompositeError": {
+			reason: "We should return any error encountered while updating the composite resource to reference the latest revision.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    getRevs(latest),
+					MockUpdate: test.NewMockUpdateFn(errBoom),
+				},
+				cp:   xr("", ""),
+				comp: comp,
+			},
+			want: want{
+				cp:  xr("", "cool-composition-new"),
+				err: errors.Wrap(errBoom, errUpdateComposite),
+			},
+		},
+		"AutomaticUpdate": {
+			reason: "A composite resource with an Automatic update policy should be updated to use the latest revision.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    getRevs(pinned, latest),
+					MockUpdate: test.NewMockUpdateFn(nil),
+				},
+				cp:   xr(v1.UpdateAutomatic, "cool-composition-old"),
+				comp: comp,
+			},
+			want: want{
+				cp:   xr(v1.UpdateAutomatic, "cool-composition-new"),
+				comp: comp,
+			},
+		},
+		"GetPinnedError": {
+			reason: "We should return any error encountered while getting the revision a composite resource is pinned to.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cp:   xr(v1.UpdateManual, "cool-composition-old"),
+				comp: comp,
+			},
+			want: want{
+				cp:  xr(v1.UpdateManual, "cool-composition-old"),
+				err: errors.Wrap(errBoom, errGetCompRev),
+			},
+		},
+		"ManualPinnedNotFound": {
+			reason: "We should return an error naming the revision a composite resource is pinned to if it does not exist.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, ""))},
+				cp:   xr(v1.UpdateManual, "cool-composition-old"),
+				comp: comp,
+			},
+			want: want{
+				cp:  xr(v1.UpdateManual, "cool-composition-old"),
+				err: errors.Errorf(errFmtPinnedRevNotFound, "cool-composition-old"),
+			},
+		},
+		"ManualPinnedDeleted": {
+			reason: "We should return an error naming the revision a composite resource is pinned to if it is being deleted.",
+			args: args{
+				kube: &test.MockClient{MockGet: getRevs(deleted, latest)},
+				cp:   xr(v1.UpdateManual, "cool-composition-old"),
+				comp: comp,
+			},
+			want: want{
+				cp:  xr(v1.UpdateManual, "cool-composition-old"),
+				err: errors.Errorf(errFmtPinnedRevDeleted, "cool-composition-old"),
+			},
+		},
+		"ManualPinned": {
+			reason: "A composite resource with a Manual update policy should continue to use the revision it references.",
+			args: args{
+				kube: &test.MockClient{MockGet: getRevs(pinned, latest)},
+				cp:   xr(v1.UpdateManual, "cool-composition-old"),
+				comp: comp,
+			},
+			want: want{
+				cp:   xr(v1.UpdateManual, "cool-composition-old"),
+				comp: old,
+			},
+		},
+		"ManualOtherComposition": {
+			reason: "A composite resource pinned to a revision of a different Composition should be updated to use the latest revision.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet:    getRevs(other, latest),
+					MockUpdate: test.NewMockUpdateFn(nil),
+				},
+				cp:   xr(v1.UpdateManual, "cool-composition-old"),
+				comp: comp,
+			},
+			want: want{
+				cp:   xr(v1.UpdateManual, "cool-composition-new"),
+				comp: comp,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := NewAPIRevisionFetcher(tc.args.kube)
+			got, err := f.FetchRevision(context.Background(), tc.args.cp, tc.args.comp)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetchRevision(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.comp, got); diff != "" {
+				t.Errorf("\n%s\nFetchRevision(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cp, tc.args.cp); diff != "" {
+				t.Errorf("\n%s\nFetchRevision(...): -want composite, +got composite:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -19,10 +19,13 @@ package composite
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -47,6 +50,24 @@ const (
 	errGetSecret   = "cannot get connection secret of composed resource"
 	errNamePrefix  = "name prefix is not found in labels"
 	errName        = "cannot use dry-run create to name composed resource"
+	errGetComposed = "cannot get composed resource"
+	errDeleteCD    = "cannot delete composed resource"
+	errOrphanCD    = "cannot orphan composed resource"
+
+	errPaveComposed           = "cannot convert composed resource to unstructured"
+	errFmtConnDetail          = "cannot get connection detail %q"
+	errFmtConnDetailVar       = "cannot get variable at index %d"
+	errFmtConnDetailTransform = "cannot apply transform at index %d of connection detail %q"
+
+	errFmtDuplicateTemplate = "more than one composed resource template is named %q"
+	errFmtUnknownTemplate   = "cannot patch from unknown composed resource template %q"
+
+	errForEachName         = "forEach templates must be named"
+	errFmtForEachNotArray  = "forEach field path %s is not an array"
+	errFmtForEachNotObject = "forEach item at index %d is not an object"
+	errFmtForEachKey       = "cannot get the key of forEach item at index %d"
+	errFmtForEachKeyType   = "key of forEach item at index %d must be a string, number, or boolean, not %T"
+	errFmtForEachDuplicate = "more than one forEach item has key %q"
 )
 
 // Observation is the result of composed reconciliation.
@@ -64,6 +85,322 @@ func (c RenderFn) Render(cp resource.Composite, cd resource.Composed, t v1.Compo
 	return c(cp, cd, t)
 }
 
+// PatchSources are the sources, other than the composite resource, that the
+// patches of a composed resource template may read from.
+type PatchSources struct {
+	// Composed resources that have been observed, keyed by the name of the
+	// template they were composed from.
+	Composed map[string]resource.Composed
+
+	// Item is the forEach item the composed resource is rendered for, if its
+	// template is a forEach template.
+	Item *ForEachItem
+
+	// Environment is the environment of the Composition, formed from the
+	// data of its EnvironmentConfigs.
+	Environment map[string]interface{}
+}
+
+// A ForEachItem is an element of the composite resource array that a forEach
+// template iterates over.
+type ForEachItem struct {
+	// Key uniquely identifies the item.
+	Key string
+
+	// Index of the item within the array.
+	Index int
+
+	// Value of the item.
+	Value interface{}
+}
+
+// patchSource returns the object FromItemFieldPath patches read from.
+func (i *ForEachItem) patchSource() map[string]interface{} {
+	return map[string]interface{}{
+		"key":   i.Key,
+		"index": int64(i.Index),
+		"value": i.Value,
+	}
+}
+
+// ForEachItems returns the items of the array within the supplied composite
+// resource that the supplied forEach template iterates over.
+func ForEachItems(cp resource.Composite, t v1.ComposedTemplate) ([]ForEachItem, error) { // nolint:gocyclo
+	// The cyclomatic complexity here comes from validating each item.
+	if t.ForEach == nil {
+		return nil, nil
+	}
+	if t.Name == nil {
+		return nil, errors.New(errForEachName)
+	}
+
+	cpMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cp)
+	if err != nil {
+		return nil, err
+	}
+	v, err := fieldpath.Pave(cpMap).GetValue(t.ForEach.FromFieldPath)
+	if fieldpath.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.Errorf(errFmtForEachNotArray, t.ForEach.FromFieldPath)
+	}
+
+	items := make([]ForEachItem, len(arr))
+	seen := map[string]bool{}
+	for i := range arr {
+		k := arr[i]
+		if t.ForEach.KeyFieldPath != nil {
+			m, ok := arr[i].(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf(errFmtForEachNotObject, i)
+			}
+			if k, err = fieldpath.Pave(m).GetValue(*t.ForEach.KeyFieldPath); err != nil {
+				return nil, errors.Wrapf(err, errFmtForEachKey, i)
+			}
+		}
+		key, ok := forEachKey(k)
+		if !ok {
+			return nil, errors.Errorf(errFmtForEachKeyType, i, k)
+		}
+		if seen[key] {
+			return nil, errors.Errorf(errFmtForEachDuplicate, key)
+		}
+		seen[key] = true
+		items[i] = ForEachItem{Key: key, Index: i, Value: arr[i]}
+	}
+	return items, nil
+}
+
+// forEachKey returns the supplied value as a key, if it can be used as one.
+func forEachKey(v interface{}) (string, bool) {
+	switch k := v.(type) {
+	case string:
+		return k, true
+	case bool:
+		return strconv.FormatBool(k), true
+	case int64:
+		return strconv.FormatInt(k, 10), true
+	case float64:
+		return strconv.FormatFloat(k, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// An APIComposedFetcher fetches composed resources from an API server.
+type APIComposedFetcher struct {
+	client client.Reader
+}
+
+// NewAPIComposedFetcher returns a ComposedFetcher that fetches composed
+// resources from an API server.
+func NewAPIComposedFetcher(c client.Reader) *APIComposedFetcher {
+	return &APIComposedFetcher{client: c}
+}
+
+// FetchComposed fetches the referenced composed resources. The returned slice
+// corresponds to the supplied references; it contains nil for any reference
+// that is empty or that refers to a resource that does not exist.
+func (f *APIComposedFetcher) FetchComposed(ctx context.Context, refs []corev1.ObjectReference) ([]resource.Composed, error) {
+	out := make([]resource.Composed, len(refs))
+	for i, ref := range refs {
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		err := f.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetComposed)
+		}
+		out[i] = cd
+	}
+	return out, nil
+}
+
+// An APIPatchSourceFetcher fetches patch sources from an API server.
+type APIPatchSourceFetcher struct {
+	client client.Reader
+}
+
+// NewAPIPatchSourceFetcher returns a PatchSourceFetcher that fetches patch
+// sources from an API server.
+func NewAPIPatchSourceFetcher(c client.Reader) *APIPatchSourceFetcher {
+	return &APIPatchSourceFetcher{client: c}
+}
+
+// FetchPatchSources fetches the composed resources that the supplied templates
+// patch from. Only composed resources that have already been created are
+// fetched; the supplied references must correspond to the supplied templates.
+func (f *APIPatchSourceFetcher) FetchPatchSources(ctx context.Context, ts []v1.ComposedTemplate, refs []corev1.ObjectReference) (PatchSources, error) {
+	src := PatchSources{Composed: map[string]resource.Composed{}}
+
+	idx := map[string]int{}
+	for i, t := range ts {
+		if t.Name == nil {
+			continue
+		}
+		if _, ok := idx[*t.Name]; ok {
+			return PatchSources{}, errors.Errorf(errFmtDuplicateTemplate, *t.Name)
+		}
+		idx[*t.Name] = i
+	}
+
+	for _, t := range ts {
+		for _, p := range t.Patches {
+			if p.Type != v1.PatchTypeFromComposedFieldPath || p.FromComposedResource == nil {
+				continue
+			}
+			name := *p.FromComposedResource
+			if _, ok := src.Composed[name]; ok {
+				continue
+			}
+			i, ok := idx[name]
+			if !ok {
+				return PatchSources{}, errors.Errorf(errFmtUnknownTemplate, name)
+			}
+			if i >= len(refs) || refs[i].Name == "" {
+				// This composed resource has not yet been created.
+				continue
+			}
+			cd := composed.New(composed.FromReference(refs[i]))
+			err := f.client.Get(ctx, types.NamespacedName{Namespace: refs[i].Namespace, Name: refs[i].Name}, cd)
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return PatchSources{}, errors.Wrap(err, errGetComposed)
+			}
+			src.Composed[name] = cd
+		}
+	}
+
+	return src, nil
+}
+
+// An APIComposedDeleter deletes composed resources from an API server.
+type APIComposedDeleter struct {
+	client client.Client
+}
+
+// NewAPIComposedDeleter returns a ComposedDeleter that deletes composed
+// resources from an API server.
+func NewAPIComposedDeleter(c client.Client) *APIComposedDeleter {
+	return &APIComposedDeleter{client: c}
+}
+
+// DeleteComposed deletes the referenced composed resource, if it exists and is
+// controlled by the supplied composite resource. Resources that are controlled
+// by another resource are left untouched. It returns true if it deleted the
+// resource.
+func (d *APIComposedDeleter) DeleteComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error) {
+	if ref.Name == "" {
+		return false, nil
+	}
+	cd := composed.New(composed.FromReference(ref))
+	err := d.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, errGetComposed)
+	}
+	if !metav1.IsControlledBy(cd, cp) {
+		return false, nil
+	}
+	if err := d.client.Delete(ctx, cd); err != nil {
+		return false, errors.Wrap(resource.IgnoreNotFound(err), errDeleteCD)
+	}
+	return true, nil
+}
+
+// An APIComposedOrphaner orphans composed resources using an API server.
+type APIComposedOrphaner struct {
+	client client.Client
+}
+
+// NewAPIComposedOrphaner returns a ComposedOrphaner that orphans composed
+// resources using an API server.
+func NewAPIComposedOrphaner(c client.Client) *APIComposedOrphaner {
+	return &APIComposedOrphaner{client: c}
+}
+
+// OrphanComposed removes the supplied composite resource's owner references
+// from the referenced composed resource, if it exists and is controlled by the
+// supplied composite resource. Resources that are controlled by another
+// resource are left untouched. Composed resources that have a deletion policy,
+// such as managed resources, have it set to Orphan so that the external
+// resource they represent survives their deletion. It returns true if it
+// orphaned the resource.
+func (o *APIComposedOrphaner) OrphanComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error) {
+	return release(ctx, o.client, cp, ref, true)
+}
+
+// An APIComposedReleaser releases composed resources using an API server.
+type APIComposedReleaser struct {
+	client client.Client
+}
+
+// NewAPIComposedReleaser returns a ComposedReleaser that releases composed
+// resources using an API server.
+func NewAPIComposedReleaser(c client.Client) *APIComposedReleaser {
+	return &APIComposedReleaser{client: c}
+}
+
+// ReleaseComposed removes the supplied composite resource's owner references
+// from the referenced composed resource, if it exists and is controlled by the
+// supplied composite resource. Resources that are controlled by another
+// resource are left untouched. Unlike OrphanComposed it does not change the
+// deletion policy of the composed resource, so deleting it later deletes any
+// external resource it represents. It returns true if it released the
+// resource.
+func (r *APIComposedReleaser) ReleaseComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error) {
+	return release(ctx, r.client, cp, ref, false)
+}
+
+// release removes the supplied composite resource's owner references from the
+// referenced composed resource, optionally setting its deletion policy to
+// Orphan. It returns true if it released the resource.
+func release(ctx context.Context, c client.Client, cp resource.Composite, ref corev1.ObjectReference, orphanExternal bool) (bool, error) {
+	if ref.Name == "" {
+		return false, nil
+	}
+	cd := composed.New(composed.FromReference(ref))
+	err := c.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, errGetComposed)
+	}
+	if !metav1.IsControlledBy(cd, cp) {
+		return false, nil
+	}
+	owners := make([]metav1.OwnerReference, 0, len(cd.GetOwnerReferences()))
+	for _, or := range cd.GetOwnerReferences() {
+		if or.UID != cp.GetUID() {
+			owners = append(owners, or)
+		}
+	}
+	cd.SetOwnerReferences(owners)
+	if orphanExternal {
+		p := fieldpath.Pave(cd.UnstructuredContent())
+		if _, err := p.GetString("spec.deletionPolicy"); err == nil {
+			_ = p.SetString("spec.deletionPolicy", string(xpv1.DeletionOrphan))
+		}
+	}
+	if err := c.Update(ctx, cd); err != nil {
+		return false, errors.Wrap(resource.IgnoreNotFound(err), errOrphanCD)
+	}
+	return true, nil
+}
+
 // An APIDryRunRenderer renders composed resources. It may perform a dry-run
 // create against an API server in order to name and validate the rendered
 // resource.
@@ -78,10 +415,10 @@ func NewAPIDryRunRenderer(c client.Client) *APIDryRunRenderer {
 	return &APIDryRunRenderer{client: c}
 }
 
-// Render the supplied composed resource using the supplied composite resource
-// and template. The rendered resource may be submitted to an API server via a
-// dry run create in order to name and validate it.
-func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+// Render the supplied composed resource using the supplied composite resource,
+// template, and patch sources. The rendered resource may be submitted to an
+// API server via a dry run create in order to name and validate it.
+func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, src PatchSources) error {
 	// Any existing name will be overwritten when we unmarshal the template. We
 	// store it here so that we can reset it after unmarshalling.
 	name := cd.GetName()
@@ -93,11 +430,7 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 		return errors.New(errNamePrefix)
 	}
 	// This label will be used if composed resource is yet another composite.
-	meta.AddLabels(cd, map[string]string{
-		xcrd.LabelKeyNamePrefixForComposed: cp.GetLabels()[xcrd.LabelKeyNamePrefixForComposed],
-		xcrd.LabelKeyClaimName:             cp.GetLabels()[xcrd.LabelKeyClaimName],
-		xcrd.LabelKeyClaimNamespace:        cp.GetLabels()[xcrd.LabelKeyClaimNamespace],
-	})
+	meta.AddLabels(cd, composedLabels(cp))
 	// Unmarshalling the template will overwrite any existing fields, so we must
 	// restore the existing name, if any. We also set generate name in case we
 	// haven't yet named this composed resource.
@@ -105,15 +438,14 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 	cd.SetName(name)
 	cd.SetNamespace(namespace)
 	for i, p := range t.Patches {
-		if err := p.Apply(cp, cd); err != nil {
+		if err := applyPatch(p, cp, cd, src); err != nil {
 			return errors.Wrapf(err, errFmtPatch, i)
 		}
 	}
 
 	// We do this last to ensure that a Composition cannot influence owner (and
 	// especially controller) references.
-	or := meta.AsController(meta.TypedReferenceTo(cp, cp.GetObjectKind().GroupVersionKind()))
-	cd.SetOwnerReferences([]metav1.OwnerReference{or})
+	cd.SetOwnerReferences([]metav1.OwnerReference{controllerRef(cp)})
 
 	// We don't want to dry-run create a resource that can't be named by the API
 	// server due to a missing generate name. We also don't want to create one
@@ -133,10 +465,50 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 	return errors.Wrap(r.client.Create(ctx, cd, client.DryRunAll), errName)
 }
 
+// composedLabels returns the labels that a resource composed by the supplied
+// composite resource inherits from it.
+func composedLabels(cp resource.Composite) map[string]string {
+	return map[string]string{
+		xcrd.LabelKeyNamePrefixForComposed: cp.GetLabels()[xcrd.LabelKeyNamePrefixForComposed],
+		xcrd.LabelKeyClaimName:             cp.GetLabels()[xcrd.LabelKeyClaimName],
+		xcrd.LabelKeyClaimNamespace:        cp.GetLabels()[xcrd.LabelKeyClaimNamespace],
+	}
+}
+
+// controllerRef returns a controller reference to the supplied composite
+// resource.
+func controllerRef(cp resource.Composite) metav1.OwnerReference {
+	return meta.AsController(meta.TypedReferenceTo(cp, cp.GetObjectKind().GroupVersionKind()))
+}
+
+// applyPatch applies the supplied patch to the supplied composed resource,
+// reading from the supplied patch sources if necessary.
+func applyPatch(p v1.Patch, cp resource.Composite, cd resource.Composed, src PatchSources) error {
+	switch p.Type { // nolint:exhaustive
+	case v1.PatchTypeFromComposedFieldPath:
+		var from runtime.Object
+		if p.FromComposedResource != nil {
+			if o, ok := src.Composed[*p.FromComposedResource]; ok {
+				from = o
+			}
+		}
+		return p.ApplyFromComposed(cp, from, cd)
+	case v1.PatchTypeFromItemFieldPath:
+		var item map[string]interface{}
+		if src.Item != nil {
+			item = src.Item.patchSource()
+		}
+		return p.ApplyFromItem(cp, item, cd)
+	case v1.PatchTypeFromEnvironmentFieldPath:
+		return p.ApplyFromEnvironment(cp, src.Environment, cd)
+	}
+	return p.Apply(cp, cd)
+}
+
 // RenderComposite renders the supplied composite resource using the supplied composed
 // resource and template.
-func RenderComposite(_ context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-	onlyPatches := []v1.PatchType{v1.PatchTypeToCompositeFieldPath}
+func RenderComposite(_ context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+	onlyPatches := []v1.PatchType{v1.PatchTypeToCompositeFieldPath, v1.PatchTypeCombineToComposite}
 	for i, p := range t.Patches {
 		if err := p.Apply(cp, cd, onlyPatches...); err != nil {
 			return errors.Wrapf(err, errFmtPatch, i)
@@ -160,46 +532,173 @@ func NewAPIConnectionDetailsFetcher(c client.Client) *APIConnectionDetailsFetche
 
 // FetchConnectionDetails of the supplied composed resource, if any.
 func (cdf *APIConnectionDetailsFetcher) FetchConnectionDetails(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-	sref := cd.GetWriteConnectionSecretToReference()
-	if sref == nil {
-		return nil, nil
-	}
-
-	conn := managed.ConnectionDetails{}
-
 	// It's possible that the composed resource does want to write a
 	// connection secret but has not yet. We presume this isn't an issue and
 	// that we'll propagate any connection details during a future
-	// iteration.
+	// iteration. Connection details that are not read from the connection
+	// secret are propagated regardless.
 	s := &corev1.Secret{}
-	nn := types.NamespacedName{Namespace: sref.Namespace, Name: sref.Name}
-	if err := cdf.client.Get(ctx, nn, s); client.IgnoreNotFound(err) != nil {
-		return nil, errors.Wrap(err, errGetSecret)
+	if sref := cd.GetWriteConnectionSecretToReference(); sref != nil {
+		nn := types.NamespacedName{Namespace: sref.Namespace, Name: sref.Name}
+		if err := cdf.client.Get(ctx, nn, s); client.IgnoreNotFound(err) != nil {
+			return nil, errors.Wrap(err, errGetSecret)
+		}
 	}
 
+	if len(t.ConnectionDetails) == 0 {
+		return nil, nil
+	}
+
+	conn := managed.ConnectionDetails{}
+	src := &connectionDetailSource{secret: s.Data, cd: cd}
 	for _, d := range t.ConnectionDetails {
-		if d.Name != nil && d.Value != nil {
-			conn[*d.Name] = []byte(*d.Value)
+		var key string
+		var v interface{}
+		var ok bool
+		var err error
+
+		switch connectionDetailType(d) {
+		case v1.ConnectionDetailTypeFromValue:
+			if d.Name == nil || d.Value == nil {
+				continue
+			}
+			key, v, ok = *d.Name, *d.Value, true
+		case v1.ConnectionDetailTypeFromFieldPath:
+			if d.Name == nil || d.FromFieldPath == nil {
+				continue
+			}
+			key = *d.Name
+			v, ok, err = src.fromFieldPath(*d.FromFieldPath)
+		case v1.ConnectionDetailTypeCombine:
+			if d.Name == nil || d.Combine == nil {
+				continue
+			}
+			key = *d.Name
+			v, ok, err = src.combine(d.Combine)
+		case v1.ConnectionDetailTypeFromConnectionSecretKey:
+			if d.FromConnectionSecretKey == nil {
+				continue
+			}
+			key = *d.FromConnectionSecretKey
+			if d.Name != nil {
+				key = *d.Name
+			}
+			v, ok = src.fromConnectionSecretKey(*d.FromConnectionSecretKey)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConnDetail, key)
+		}
+		if !ok {
 			continue
 		}
 
-		if d.FromConnectionSecretKey == nil {
-			continue
+		for i, tr := range d.Transforms {
+			if v, err = tr.Transform(v); err != nil {
+				return nil, errors.Wrapf(err, errFmtConnDetailTransform, i, key)
+			}
 		}
 
-		if len(s.Data[*d.FromConnectionSecretKey]) == 0 {
-			continue
+		b, err := connectionDetailValue(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConnDetail, key)
 		}
+		conn[key] = b
+	}
 
-		key := *d.FromConnectionSecretKey
-		if d.Name != nil {
-			key = *d.Name
+	return conn, nil
+}
+
+// A connectionDetailSource is a source of connection detail values; the
+// connection secret and fields of a composed resource.
+type connectionDetailSource struct {
+	secret map[string][]byte
+	cd     resource.Composed
+	paved  *fieldpath.Paved
+}
+
+// fromConnectionSecretKey returns the value of the supplied connection secret
+// key as a string. It returns false if the key does not exist or is empty.
+func (s *connectionDetailSource) fromConnectionSecretKey(key string) (interface{}, bool) {
+	if len(s.secret[key]) == 0 {
+		return nil, false
+	}
+	return string(s.secret[key]), true
+}
+
+// fromFieldPath returns the value of the supplied field path of the composed
+// resource. It returns false if the field does not exist or is null.
+func (s *connectionDetailSource) fromFieldPath(path string) (interface{}, bool, error) {
+	if s.paved == nil {
+		p, err := fieldpath.PaveObject(s.cd)
+		if err != nil {
+			return nil, false, errors.Wrap(err, errPaveComposed)
+		}
+		s.paved = p
+	}
+	v, err := s.paved.GetValue(path)
+	if fieldpath.IsNotFound(err) {
+		return nil, false, nil
+	}
+	return v, err == nil && v != nil, err
+}
+
+// combine returns the combined value of the supplied variables. It returns
+// false if any of the variables does not exist.
+func (s *connectionDetailSource) combine(c *v1.ConnectionDetailCombine) (interface{}, bool, error) {
+	vars := make([]interface{}, len(c.Variables))
+	for i, cv := range c.Variables {
+		var ok bool
+		var err error
+		switch {
+		case cv.FromConnectionSecretKey != nil:
+			vars[i], ok = s.fromConnectionSecretKey(*cv.FromConnectionSecretKey)
+		case cv.FromFieldPath != nil:
+			vars[i], ok, err = s.fromFieldPath(*cv.FromFieldPath)
+		}
+		if err != nil {
+			return nil, false, errors.Wrapf(err, errFmtConnDetailVar, i)
 		}
+		if !ok {
+			return nil, false, nil
+		}
+	}
+	out, err := c.Combine(vars)
+	return out, err == nil, err
+}
 
-		conn[key] = s.Data[*d.FromConnectionSecretKey]
+// connectionDetailType returns the type of the supplied connection detail,
+// inferring it from the fields that are set if no type was specified.
+func connectionDetailType(d v1.ConnectionDetail) v1.ConnectionDetailType {
+	switch {
+	case d.Type != nil:
+		return *d.Type
+	case d.Value != nil:
+		return v1.ConnectionDetailTypeFromValue
+	case d.Combine != nil:
+		return v1.ConnectionDetailTypeCombine
+	case d.FromFieldPath != nil:
+		return v1.ConnectionDetailTypeFromFieldPath
+	default:
+		return v1.ConnectionDetailTypeFromConnectionSecretKey
 	}
+}
 
-	return conn, nil
+// connectionDetailValue returns the connection detail value of the supplied
+// field value. Strings are returned as is, numbers and booleans are formatted
+// as strings, and anything else is encoded as JSON.
+func connectionDetailValue(v interface{}) ([]byte, error) {
+	switch tv := v.(type) {
+	case string:
+		return []byte(tv), nil
+	case int64:
+		return []byte(strconv.FormatInt(tv, 10)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(tv, 'f', -1, 64)), nil
+	case bool:
+		return []byte(strconv.FormatBool(tv)), nil
+	default:
+		return json.Marshal(tv)
+	}
 }
 
 // IsReady returns whether the composed resource is ready.
@@ -219,6 +718,7 @@ func IsReady(_ context.Context, cd resource.Composed, t v1.ComposedTemplate) (bo
 	}
 	paved := fieldpath.Pave(u.UnstructuredContent())
 
+	anyOf := t.ReadinessCheckPolicy != nil && *t.ReadinessCheckPolicy == v1.ReadinessCheckPolicyAnyOf
 	for i, check := range t.ReadinessChecks {
 		var ready bool
 		switch check.Type {
@@ -242,12 +742,50 @@ func IsReady(_ context.Context, cd resource.Composed, t v1.ComposedTemplate) (bo
 				return false, err
 			}
 			ready = !fieldpath.IsNotFound(err) && val == check.MatchInteger
+		case v1.ReadinessCheckMatchTrue, v1.ReadinessCheckMatchFalse:
+			val, err := paved.GetBool(check.FieldPath)
+			if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+				return false, err
+			}
+			ready = !fieldpath.IsNotFound(err) && val == (check.Type == v1.ReadinessCheckMatchTrue)
+		case v1.ReadinessCheckMatchCondition:
+			ready = matchCondition(u, check.MatchCondition)
 		default:
 			return false, errors.New(fmt.Sprintf("readiness check at index %d: an unknown type is chosen", i))
 		}
-		if !ready {
+		if anyOf && ready {
+			return true, nil
+		}
+		if !anyOf && !ready {
 			return false, nil
 		}
 	}
-	return true, nil
+	return !anyOf, nil
+}
+
+// matchCondition returns true if the supplied composed resource has the
+// supplied condition. A resource matches the Ready condition with status True
+// if no condition is supplied.
+func matchCondition(cd *composed.Unstructured, m *v1.MatchConditionReadinessCheck) bool {
+	ct, cs := xpv1.TypeReady, corev1.ConditionTrue
+	if m != nil && m.Type != "" {
+		ct = m.Type
+	}
+	if m != nil && m.Status != "" {
+		cs = m.Status
+	}
+
+	// GetCondition returns a condition with status Unknown if the resource
+	// has no such condition, so we look for the condition ourselves. A
+	// missing condition never matches.
+	conditioned := xpv1.ConditionedStatus{}
+	if err := fieldpath.Pave(cd.Object).GetValueInto("status", &conditioned); err != nil {
+		return false
+	}
+	for _, c := range conditioned.Conditions {
+		if c.Type == ct {
+			return c.Status == cs
+		}
+	}
+	return false
 }
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/dag"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+// Error strings.
+const (
+	errSortDependencies = "cannot order templates by their dependencies"
+
+	errFmtDependsOnUnnamed = "template at index %d must be named in order to depend on other templates"
+	errFmtDependsOnUnknown = "a template depends on unknown template %q"
+	errFmtDeleteInOrder    = "cannot delete composed resource %s %q"
+)
+
+// A templateNode is a named template in the dependency graph of a
+// Composition's templates.
+type templateNode struct {
+	name      string
+	dependsOn []string
+}
+
+// Identifier returns the name of the template.
+func (n *templateNode) Identifier() string {
+	return n.name
+}
+
+// Neighbors returns the templates this template depends on.
+func (n *templateNode) Neighbors() []dag.Node {
+	nodes := make([]dag.Node, len(n.dependsOn))
+	for i, d := range n.dependsOn {
+		nodes[i] = &templateNode{name: d}
+	}
+	return nodes
+}
+
+// AddNeighbors is a no-op. A template's dependencies are always declared
+// before it is added to the graph.
+func (n *templateNode) AddNeighbors(...dag.Node) error {
+	return nil
+}
+
+// hasDependencies returns true if any of the supplied templates depends on
+// another.
+func hasDependencies(ts []v1.ComposedTemplate) bool {
+	for _, t := range ts {
+		if len(t.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyOrder returns the position of each of the supplied named templates
+// in an order in which every template follows the templates it depends on. It
+// returns nil if no template depends on another.
+func dependencyOrder(ts []v1.ComposedTemplate) (map[string]int, error) {
+	if !hasDependencies(ts) {
+		return nil, nil
+	}
+
+	nodes := make([]dag.Node, 0, len(ts))
+	for i, t := range ts {
+		if t.Name == nil {
+			if len(t.DependsOn) > 0 {
+				return nil, errors.Errorf(errFmtDependsOnUnnamed, i)
+			}
+			continue
+		}
+		nodes = append(nodes, &templateNode{name: *t.Name, dependsOn: t.DependsOn})
+	}
+
+	d := dag.NewMapDag()
+	implied, err := d.Init(nodes)
+	if err != nil {
+		return nil, errors.Wrap(err, errSortDependencies)
+	}
+	if len(implied) > 0 {
+		return nil, errors.Errorf(errFmtDependsOnUnknown, implied[0].Identifier())
+	}
+	sorted, err := d.Sort()
+	if err != nil {
+		return nil, errors.Wrap(err, errSortDependencies)
+	}
+
+	order := make(map[string]int, len(sorted))
+	for i, name := range sorted {
+		order[name] = i
+	}
+	return order, nil
+}
+
+// waitingFor returns the names of the templates that the supplied template
+// depends on and that have composed resources that are not yet ready.
+func waitingFor(t v1.ComposedTemplate, unready map[string]bool) []string {
+	waiting := make([]string, 0)
+	for _, d := range t.DependsOn {
+		if unready[d] {
+			waiting = append(waiting, d)
+		}
+	}
+	return waiting
+}
+
+// deleteInOrder deletes the supplied observed composed resources, which must
+// correspond to the supplied references, in reverse dependency order. A
+// resource is not deleted while any resource composed from a template that
+// depends on its template still exists. Resources that are not controlled by
+// the supplied composite resource are neither deleted nor waited for, because
+// the deleter would never delete them. It returns true once none of the
+// observed resources that it controls exist.
+func deleteInOrder(ctx context.Context, d ComposedDeleter, cp resource.Composite, ts []v1.ComposedTemplate, refs []corev1.ObjectReference, observed []resource.Composed) (bool, error) {
+	dependents := map[string][]string{}
+	for _, t := range ts {
+		if t.Name == nil {
+			continue
+		}
+		for _, dep := range t.DependsOn {
+			dependents[dep] = append(dependents[dep], *t.Name)
+		}
+	}
+
+	exists := map[string]bool{}
+	remaining := false
+	for _, cd := range observed {
+		if cd == nil || !metav1.IsControlledBy(cd, cp) {
+			continue
+		}
+		remaining = true
+		exists[cd.GetAnnotations()[xcrd.AnnotationKeyCompositionResourceName]] = true
+	}
+
+	for i, cd := range observed {
+		if cd == nil || meta.WasDeleted(cd) || !metav1.IsControlledBy(cd, cp) {
+			continue
+		}
+		if anyExist(dependents[cd.GetAnnotations()[xcrd.AnnotationKeyCompositionResourceName]], exists) {
+			continue
+		}
+		if _, err := d.DeleteComposed(ctx, cp, refs[i]); err != nil {
+			return false, errors.Wrapf(err, errFmtDeleteInOrder, refs[i].Kind, refs[i].Name)
+		}
+	}
+
+	return !remaining, nil
+}
+
+func anyExist(names []string, exists map[string]bool) bool {
+	for _, n := range names {
+		if exists[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// nameOf returns the name of the supplied template, or an empty string if it
+// is unnamed.
+func nameOf(t v1.ComposedTemplate) string {
+	if t.Name == nil {
+		return ""
+	}
+	return *t.Name
+}
+
+// referenceOf returns a reference to the supplied composed resource that
+// identifies it by kind and name.
+func referenceOf(cd resource.Composed) corev1.ObjectReference {
+	gvk := cd.GetObjectKind().GroupVersionKind()
+	return corev1.ObjectReference{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Namespace: cd.GetNamespace(), Name: cd.GetName()}
+}
+
+// waitingMessage returns a message naming the supplied templates.
+func waitingMessage(names []string) string {
+	return "Waiting for dependencies to be ready: " + strings.Join(names, ", ")
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+func TestDependencyOrder(t *testing.T) {
+	type want struct {
+		before [][2]string
+		err    error
+	}
+
+	cases := map[string]struct {
+		reason string
+		ts     []v1.ComposedTemplate
+		want   want
+	}{
+		"NoDependencies": {
+			reason: "Templates that do not depend on each other need not be ordered",
+			ts:     []v1.ComposedTemplate{{Name: pointer.StringPtr("a")}, {}},
+		},
+		"Unnamed": {
+			reason: "Templates that depend on other templates must be named",
+			ts:     []v1.ComposedTemplate{{Name: pointer.StringPtr("a")}, {DependsOn: []string{"a"}}},
+			want: want{
+				err: errors.Errorf(errFmtDependsOnUnnamed, 1),
+			},
+		},
+		"UnknownTemplate": {
+			reason: "Templates may only depend on templates that exist",
+			ts:     []v1.ComposedTemplate{{Name: pointer.StringPtr("a"), DependsOn: []string{"b"}}},
+			want: want{
+				err: errors.Errorf(errFmtDependsOnUnknown, "b"),
+			},
+		},
+		"Cycle": {
+			reason: "Templates may not depend on each other cyclically",
+			ts:     []v1.ComposedTemplate{{Name: pointer.StringPtr("a"), DependsOn: []string{"a"}}},
+			want: want{
+				err: errors.Wrap(errors.New("detected cycle on: a"), errSortDependencies),
+			},
+		},
+		"Ordered": {
+			reason: "Templates should be ordered after the templates they depend on",
+			ts: []v1.ComposedTemplate{
+				{Name: pointer.StringPtr("a"), DependsOn: []string{"b", "c"}},
+				{Name: pointer.StringPtr("b"), DependsOn: []string{"c"}},
+				{Name: pointer.StringPtr("c")},
+				{},
+			},
+			want: want{
+				before: [][2]string{{"b", "a"}, {"c", "a"}, {"c", "b"}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			order, err := dependencyOrder(tc.ts)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ndependencyOrder(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if tc.want.before == nil && order != nil {
+				t.Errorf("\n%s\ndependencyOrder(...): want nil order, got %v", tc.reason, order)
+			}
+			for _, b := range tc.want.before {
+				if order[b[0]] >= order[b[1]] {
+					t.Errorf("\n%s\ndependencyOrder(...): want %q ordered before %q, got %v", tc.reason, b[0], b[1], order)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteInOrder(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	ts := []v1.ComposedTemplate{
+		{Name: pointer.StringPtr("bucket"), DependsOn: []string{"network"}},
+		{Name: pointer.StringPtr("network")},
+	}
+	bucketRef := corev1.ObjectReference{Kind: "Bucket", Name: "cool-bucket"}
+	networkRef := corev1.ObjectReference{Kind: "Network", Name: "cool-network"}
+	ctrl := true
+	cp := &fake.Composite{ObjectMeta: metav1.ObjectMeta{UID: "cool-uid"}}
+	from := func(template string) resource.Composed {
+		return &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+			Annotations:     map[string]string{xcrd.AnnotationKeyCompositionResourceName: template},
+			OwnerReferences: []metav1.OwnerReference{{UID: "cool-uid", Controller: &ctrl}},
+		}}
+	}
+
+	type args struct {
+		refs     []corev1.ObjectReference
+		observed []resource.Composed
+	}
+	type want struct {
+		deleted []corev1.ObjectReference
+		gone    bool
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		delErr error
+		want   want
+	}{
+		"Gone": {
+			reason: "We should report that composed resources are gone when none exist",
+			args: args{
+				refs:     []corev1.ObjectReference{bucketRef, networkRef},
+				observed: []resource.Composed{nil, nil},
+			},
+			want: want{
+				gone: true,
+			},
+		},
+		"WaitForDependents": {
+			reason: "We should not delete a resource while the resources that depend on it exist",
+			args: args{
+				refs:     []corev1.ObjectReference{bucketRef, networkRef},
+				observed: []resource.Composed{from("bucket"), from("network")},
+			},
+			want: want{
+				deleted: []corev1.ObjectReference{bucketRef},
+			},
+		},
+		"DependentsGone": {
+			reason: "We should delete a resource once the resources that depend on it are gone",
+			args: args{
+				refs:     []corev1.ObjectReference{bucketRef, networkRef},
+				observed: []resource.Composed{nil, from("network")},
+			},
+			want: want{
+				deleted: []corev1.ObjectReference{networkRef},
+			},
+		},
+		"NotControlled": {
+			reason: "We should neither delete nor wait for resources that are controlled by another resource",
+			args: args{
+				refs: []corev1.ObjectReference{bucketRef, networkRef},
+				observed: []resource.Composed{
+					&fake.Composed{ObjectMeta: metav1.ObjectMeta{
+						Annotations:     map[string]string{xcrd.AnnotationKeyCompositionResourceName: "bucket"},
+						OwnerReferences: []metav1.OwnerReference{{UID: "other-uid", Controller: &ctrl}},
+					}},
+					nil,
+				},
+			},
+			want: want{
+				gone: true,
+			},
+		},
+		"DeleteError": {
+			reason: "We should return any error encountered while deleting a composed resource",
+			args: args{
+				refs:     []corev1.ObjectReference{networkRef},
+				observed: []resource.Composed{from("network")},
+			},
+			delErr: errBoom,
+			want: want{
+				deleted: []corev1.ObjectReference{networkRef},
+				err:     errors.Wrapf(errBoom, errFmtDeleteInOrder, "Network", "cool-network"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var deleted []corev1.ObjectReference
+			d := ComposedDeleterFn(func(_ context.Context, _ resource.Composite, ref corev1.ObjectReference) (bool, error) {
+				deleted = append(deleted, ref)
+				return false, tc.delErr
+			})
+			gone, err := deleteInOrder(context.Background(), d, cp, ts, tc.args.refs, tc.args.observed)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ndeleteInOrder(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.gone, gone); diff != "" {
+				t.Errorf("\n%s\ndeleteInOrder(...): -want gone, +got gone:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.deleted, deleted); diff != "" {
+				t.Errorf("\n%s\ndeleteInOrder(...): -want deleted, +got deleted:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+// Error strings.
+const (
+	errListEnvConfigs = "cannot list EnvironmentConfigs"
+
+	errFmtGetEnvConfig       = "cannot get EnvironmentConfig %q"
+	errFmtUnmarshalEnvData   = "cannot unmarshal data key %q of EnvironmentConfig %q"
+	errFmtEnvSourceType      = "environment source at index %d has unsupported type %q"
+	errFmtEnvSourceRequired  = "environment source at index %d of type %s requires field %s"
+	errFmtEnvLabelType       = "label %q has unsupported type %q"
+	errFmtEnvLabelRequired   = "label %q of type %s requires field %s"
+	errFmtEnvLabelFieldValue = "cannot get value of label %q from composite resource field path %q"
+	errFmtEnvSource          = "cannot select EnvironmentConfigs for environment source at index %d"
+)
+
+// An APIEnvironmentFetcher fetches the EnvironmentConfigs that form a
+// Composition's environment from an API server.
+type APIEnvironmentFetcher struct {
+	client client.Reader
+}
+
+// NewAPIEnvironmentFetcher returns an EnvironmentFetcher that fetches
+// EnvironmentConfigs from an API server.
+func NewAPIEnvironmentFetcher(c client.Reader) *APIEnvironmentFetcher {
+	return &APIEnvironmentFetcher{client: c}
+}
+
+// FetchEnvironment fetches the EnvironmentConfigs selected by the supplied
+// environment configuration, and merges their data to form an environment. The
+// environment is empty if no configuration is supplied.
+func (f *APIEnvironmentFetcher) FetchEnvironment(ctx context.Context, cp resource.Composite, cfg *v1.EnvironmentConfiguration) (map[string]interface{}, error) {
+	env := map[string]interface{}{}
+	if cfg == nil {
+		return env, nil
+	}
+
+	for i, src := range cfg.EnvironmentConfigs {
+		ecs, err := f.selectEnvironmentConfigs(ctx, cp, i, src)
+		if err != nil {
+			return nil, err
+		}
+		for _, ec := range ecs {
+			if err := mergeEnvironment(env, ec); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return env, nil
+}
+
+func (f *APIEnvironmentFetcher) selectEnvironmentConfigs(ctx context.Context, cp resource.Composite, i int, src v1.EnvironmentSource) ([]v1alpha1.EnvironmentConfig, error) {
+	switch src.Type {
+	case v1.EnvironmentSourceTypeReference, "":
+		if src.Ref == nil {
+			return nil, errors.Errorf(errFmtEnvSourceRequired, i, v1.EnvironmentSourceTypeReference, "ref")
+		}
+		ec := v1alpha1.EnvironmentConfig{}
+		if err := f.client.Get(ctx, types.NamespacedName{Name: src.Ref.Name}, &ec); err != nil {
+			return nil, errors.Wrapf(err, errFmtGetEnvConfig, src.Ref.Name)
+		}
+		return []v1alpha1.EnvironmentConfig{ec}, nil
+
+	case v1.EnvironmentSourceTypeSelector:
+		if src.Selector == nil {
+			return nil, errors.Errorf(errFmtEnvSourceRequired, i, v1.EnvironmentSourceTypeSelector, "selector")
+		}
+		labels, err := environmentSelectorLabels(cp, src.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtEnvSource, i)
+		}
+		l := &v1alpha1.EnvironmentConfigList{}
+		if err := f.client.List(ctx, l, client.MatchingLabels(labels)); err != nil {
+			return nil, errors.Wrap(err, errListEnvConfigs)
+		}
+		// The API server makes no guarantees about the order of a list, so
+		// we sort by name to ensure the environment is stable.
+		sort.Slice(l.Items, func(a, b int) bool { return l.Items[a].GetName() < l.Items[b].GetName() })
+		return l.Items, nil
+	}
+
+	return nil, errors.Errorf(errFmtEnvSourceType, i, src.Type)
+}
+
+// environmentSelectorLabels returns the labels the supplied selector matches.
+func environmentSelectorLabels(cp resource.Composite, sel *v1.EnvironmentSourceSelector) (map[string]string, error) {
+	labels := make(map[string]string, len(sel.MatchLabels))
+	for _, m := range sel.MatchLabels {
+		switch m.Type {
+		case v1.EnvironmentSourceSelectorLabelMatcherTypeValue:
+			if m.Value == nil {
+				return nil, errors.Errorf(errFmtEnvLabelRequired, m.Key, m.Type, "value")
+			}
+			labels[m.Key] = *m.Value
+
+		case v1.EnvironmentSourceSelectorLabelMatcherTypeFromCompositeFieldPath, "":
+			if m.ValueFromFieldPath == nil {
+				return nil, errors.Errorf(errFmtEnvLabelRequired, m.Key, v1.EnvironmentSourceSelectorLabelMatcherTypeFromCompositeFieldPath, "valueFromFieldPath")
+			}
+			u, ok := cp.(interface{ UnstructuredContent() map[string]interface{} })
+			if !ok {
+				return nil, errors.Errorf(errFmtEnvLabelFieldValue, m.Key, *m.ValueFromFieldPath)
+			}
+			v, err := fieldpath.Pave(u.UnstructuredContent()).GetString(*m.ValueFromFieldPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, errFmtEnvLabelFieldValue, m.Key, *m.ValueFromFieldPath)
+			}
+			labels[m.Key] = v
+
+		default:
+			return nil, errors.Errorf(errFmtEnvLabelType, m.Key, m.Type)
+		}
+	}
+	return labels, nil
+}
+
+// mergeEnvironment merges the data of the supplied EnvironmentConfig into the
+// supplied environment. Objects are merged recursively, while any other value
+// replaces the existing value.
+func mergeEnvironment(env map[string]interface{}, ec v1alpha1.EnvironmentConfig) error {
+	for k, raw := range ec.Data {
+		v, err := unmarshalJSON(raw)
+		if err != nil {
+			return errors.Wrapf(err, errFmtUnmarshalEnvData, k, ec.GetName())
+		}
+		env[k] = mergeValue(env[k], v)
+	}
+	return nil
+}
+
+func unmarshalJSON(raw extv1.JSON) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal(raw.Raw, &v)
+	return v, err
+}
+
+func mergeValue(existing, v interface{}) interface{} {
+	e, eok := existing.(map[string]interface{})
+	m, mok := v.(map[string]interface{})
+	if !eok || !mok {
+		return v
+	}
+	for k, mv := range m {
+		e[k] = mergeValue(e[k], mv)
+	}
+	return e
+}
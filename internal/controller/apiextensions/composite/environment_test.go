@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+func TestFetchEnvironment(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	xr := composite.New()
+	_ = fieldpath.Pave(xr.Object).SetValue("spec.parameters.stage", "prod")
+
+	envConfig := func(name, data string) v1alpha1.EnvironmentConfig {
+		ec := v1alpha1.EnvironmentConfig{ObjectMeta: metav1.ObjectMeta{Name: name}, Data: map[string]extv1.JSON{}}
+		ec.Data["cool"] = extv1.JSON{Raw: []byte(data)}
+		return ec
+	}
+
+	type args struct {
+		kube client.Client
+		cp   resource.Composite
+		cfg  *v1.EnvironmentConfiguration
+	}
+	type want struct {
+		env map[string]interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoConfiguration": {
+			reason: "The environment should be empty if the Composition does not configure one.",
+			args: args{
+				cp: xr,
+			},
+			want: want{
+				env: map[string]interface{}{},
+			},
+		},
+		"MissingRef": {
+			reason: "We should return an error if a Reference source does not specify a ref.",
+			args: args{
+				cp: xr,
+				cfg: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{{Type: v1.EnvironmentSourceTypeReference}},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtEnvSourceRequired, 0, v1.EnvironmentSourceTypeReference, "ref"),
+			},
+		},
+		"GetError": {
+			reason: "We should return any error encountered while getting a referenced EnvironmentConfig.",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cp:   xr,
+				cfg: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{{Ref: &v1.EnvironmentSourceReference{Name: "cool-env"}}},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errBoom, errFmtGetEnvConfig, "cool-env"),
+			},
+		},
+		"MissingLabelValue": {
+			reason: "We should return an error if a label value cannot be read from the composite resource.",
+			args: args{
+				cp: xr,
+				cfg: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{{
+						Type: v1.EnvironmentSourceTypeSelector,
+						Selector: &v1.EnvironmentSourceSelector{MatchLabels: []v1.EnvironmentSourceSelectorLabelMatcher{{
+							Key:                "stage",
+							ValueFromFieldPath: pointer.StringPtr("spec.parameters.region"),
+						}}},
+					}},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errors.New("spec.parameters.region: no such field"), errFmtEnvLabelFieldValue, "stage", "spec.parameters.region"), errFmtEnvSource, 0),
+			},
+		},
+		"ListError": {
+			reason: "We should return any error encountered while listing selected EnvironmentConfigs.",
+			args: args{
+				kube: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				cp:   xr,
+				cfg: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{{
+						Type:     v1.EnvironmentSourceTypeSelector,
+						Selector: &v1.EnvironmentSourceSelector{},
+					}},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errListEnvConfigs),
+			},
+		},
+		"MergedEnvironment": {
+			reason: "We should merge the data of referenced and selected EnvironmentConfigs in order, preserving integers.",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						*obj.(*v1alpha1.EnvironmentConfig) = envConfig("a", `{"region":"us-east-1","vpc":{"id":"vpc-a","cidr":"10.0.0.0/16"},"replicas":3,"ratio":0.5}`)
+						return nil
+					}),
+					MockList: func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+						want := []client.ListOption{client.MatchingLabels{"stage": "prod", "cool": "true"}}
+						if diff := cmp.Diff(want, opts); diff != "" {
+							t.Errorf("List(...): -want, +got:\n%s", diff)
+						}
+						// Returned out of order to ensure we sort by name.
+						obj.(*v1alpha1.EnvironmentConfigList).Items = []v1alpha1.EnvironmentConfig{
+							envConfig("c", `{"vpc":{"id":"vpc-c"}}`),
+							envConfig("b", `{"region":"us-west-2","vpc":{"id":"vpc-b"}}`),
+						}
+						return nil
+					},
+				},
+				cp: xr,
+				cfg: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{
+						{Ref: &v1.EnvironmentSourceReference{Name: "a"}},
+						{
+							Type: v1.EnvironmentSourceTypeSelector,
+							Selector: &v1.EnvironmentSourceSelector{MatchLabels: []v1.EnvironmentSourceSelectorLabelMatcher{
+								{Key: "stage", ValueFromFieldPath: pointer.StringPtr("spec.parameters.stage")},
+								{Key: "cool", Type: v1.EnvironmentSourceSelectorLabelMatcherTypeValue, Value: pointer.StringPtr("true")},
+							}},
+						},
+					},
+				},
+			},
+			want: want{
+				env: map[string]interface{}{
+					"cool": map[string]interface{}{
+						"region": "us-west-2",
+						"vpc": map[string]interface{}{
+							"id":   "vpc-c",
+							"cidr": "10.0.0.0/16",
+						},
+						"replicas": int64(3),
+						"ratio":    0.5,
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := NewAPIEnvironmentFetcher(tc.args.kube)
+			env, err := f.FetchEnvironment(context.Background(), tc.args.cp, tc.args.cfg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetchEnvironment(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.env, env); diff != "" {
+				t.Errorf("\n%s\nFetchEnvironment(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
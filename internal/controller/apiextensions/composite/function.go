@@ -0,0 +1,395 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+// Default limits of Exec functions.
+const (
+	defaultFunctionTimeout   = 20 * time.Second
+	defaultFunctionMaxOutput = 4 << 20
+
+	// Only the start of a function's stderr is included in errors.
+	maxFunctionStderr = 4 << 10
+)
+
+// Error strings.
+const (
+	errConvertObserved   = "cannot convert observed resource to unstructured data"
+	errMarshalRequest    = "cannot marshal function request"
+	errUnmarshalResp     = "cannot unmarshal function response"
+	errNoExec            = "Exec functions must specify exec configuration"
+	errFunctionsDisabled = "composition functions are not enabled"
+	errReadOutput        = "cannot read function output"
+
+	errFmtRunFunction       = "cannot run function %q"
+	errFmtFunctionType      = "unsupported function type %q"
+	errFmtUnknownResource   = "function returned unknown resource %s %q"
+	errFmtCommandNotAllowed = "command %q is not within the function directory %q"
+	errFmtOutputTooLarge    = "function wrote more than %d bytes to stdout"
+)
+
+// A FunctionRequest is sent to each function in a Composition's function
+// pipeline. Exec functions read it as JSON from stdin.
+type FunctionRequest struct {
+	// Config is the optional configuration of the function.
+	Config *runtime.RawExtension `json:"config,omitempty"`
+
+	// Observed is the state of the composite resource and its composed
+	// resources, as observed at the start of the reconcile.
+	Observed FunctionState `json:"observed"`
+
+	// Desired is the state of the composite resource and its composed
+	// resources, as rendered by the Composition's templates and any previous
+	// functions in the pipeline.
+	Desired FunctionState `json:"desired"`
+}
+
+// A FunctionResponse is returned by each function in a Composition's function
+// pipeline. Exec functions write it as JSON to stdout.
+type FunctionResponse struct {
+	// Resources is the desired state of composed resources. Resources are
+	// identified by their apiVersion, kind, and name, which may not be
+	// changed. Resources that are omitted are left unchanged.
+	Resources []FunctionResource `json:"resources,omitempty"`
+}
+
+// FunctionState is the state of a composite resource and its composed
+// resources.
+type FunctionState struct {
+	// Composite resource.
+	Composite map[string]interface{} `json:"composite"`
+
+	// Resources composed by the composite resource.
+	Resources []FunctionResource `json:"resources,omitempty"`
+}
+
+// A FunctionResource is a composed resource.
+type FunctionResource struct {
+	// Name of the template the resource was composed from, if any.
+	Name string `json:"name,omitempty"`
+
+	// Key of the forEach item the resource was composed for, if any.
+	Key string `json:"key,omitempty"`
+
+	// Resource is the composed resource.
+	Resource map[string]interface{} `json:"resource"`
+}
+
+// A FunctionRunner runs a single composition function.
+type FunctionRunner interface {
+	RunFunction(ctx context.Context, fn v1.Function, req *FunctionRequest) (*FunctionResponse, error)
+}
+
+// A FunctionRunnerFn runs a single composition function.
+type FunctionRunnerFn func(ctx context.Context, fn v1.Function, req *FunctionRequest) (*FunctionResponse, error)
+
+// RunFunction runs the supplied function.
+func (fn FunctionRunnerFn) RunFunction(ctx context.Context, f v1.Function, req *FunctionRequest) (*FunctionResponse, error) {
+	return fn(ctx, f, req)
+}
+
+// RunNoFunction is a FunctionRunner that refuses to run any function. It is
+// used when composition functions are not enabled.
+func RunNoFunction(_ context.Context, _ v1.Function, _ *FunctionRequest) (*FunctionResponse, error) {
+	return nil, errors.New(errFunctionsDisabled)
+}
+
+// An ExecFunctionRunnerOption configures an ExecFunctionRunner.
+type ExecFunctionRunnerOption func(*ExecFunctionRunner)
+
+// WithMaxOutput specifies the maximum number of bytes an Exec function may
+// write to stdout.
+func WithMaxOutput(n int64) ExecFunctionRunnerOption {
+	return func(r *ExecFunctionRunner) {
+		r.maxOutput = n
+	}
+}
+
+// An ExecFunctionRunner runs Exec functions as external processes. It runs
+// only commands that are within its function directory, which is chosen by the
+// operator of Crossplane rather than by the authors of Compositions.
+type ExecFunctionRunner struct {
+	dir       string
+	maxOutput int64
+}
+
+// NewExecFunctionRunner returns a FunctionRunner that runs Exec functions
+// whose commands are within the supplied directory.
+func NewExecFunctionRunner(dir string, o ...ExecFunctionRunnerOption) *ExecFunctionRunner {
+	r := &ExecFunctionRunner{dir: dir, maxOutput: defaultFunctionMaxOutput}
+	for _, fn := range o {
+		fn(r)
+	}
+	return r
+}
+
+// RunFunction runs the supplied Exec function as an external process. The
+// request is written to the process's stdin, and the response read from its
+// stdout. A process that writes too much to stdout is killed.
+func (r *ExecFunctionRunner) RunFunction(ctx context.Context, fn v1.Function, req *FunctionRequest) (*FunctionResponse, error) {
+	if fn.Type != v1.FunctionTypeExec {
+		return nil, errors.Errorf(errFmtFunctionType, fn.Type)
+	}
+	if fn.Exec == nil {
+		return nil, errors.New(errNoExec)
+	}
+
+	command, err := r.command(fn.Exec.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, errMarshalRequest)
+	}
+
+	timeout := defaultFunctionTimeout
+	if fn.Timeout != nil {
+		timeout = fn.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stderr := &limitedBuffer{max: maxFunctionStderr}
+
+	// The command is confined to the function directory.
+	cmd := exec.CommandContext(ctx, command, fn.Exec.Args...) // nolint:gosec
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// We read one byte more than we allow so that we can tell whether the
+	// function wrote too much.
+	out, err := ioutil.ReadAll(io.LimitReader(stdout, r.maxOutput+1))
+	if err != nil {
+		cancel()
+		_ = cmd.Wait()
+		return nil, errors.Wrap(err, errReadOutput)
+	}
+	if int64(len(out)) > r.maxOutput {
+		cancel()
+		_ = cmd.Wait()
+		return nil, errors.Errorf(errFmtOutputTooLarge, r.maxOutput)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		// Functions are expected to explain why they failed on stderr.
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			err = errors.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	rsp := &FunctionResponse{}
+	return rsp, errors.Wrap(json.Unmarshal(out, rsp), errUnmarshalResp)
+}
+
+// command returns the path of the supplied command within the function
+// directory. Commands may be absolute, or relative to the function directory.
+// Symbolic links are resolved so that they cannot be used to escape it.
+func (r *ExecFunctionRunner) command(c string) (string, error) {
+	dir, err := filepath.Abs(r.dir)
+	if err != nil {
+		return "", errors.Errorf(errFmtCommandNotAllowed, c, r.dir)
+	}
+	p := c
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	if !within(dir, filepath.Clean(p)) {
+		return "", errors.Errorf(errFmtCommandNotAllowed, c, r.dir)
+	}
+
+	// Any error resolving the command means it does not exist, or cannot be
+	// run. We treat it as not allowed rather than leak details about it.
+	rdir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", errors.Errorf(errFmtCommandNotAllowed, c, r.dir)
+	}
+	rp, err := filepath.EvalSymlinks(p)
+	if err != nil || !within(rdir, rp) {
+		return "", errors.Errorf(errFmtCommandNotAllowed, c, r.dir)
+	}
+	return rp, nil
+}
+
+// within returns true if the supplied path is within, and not the same as, the
+// supplied directory. Both must be absolute and clean.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// A limitedBuffer is a buffer that discards anything written to it beyond its
+// maximum size.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+// Write the supplied bytes to the buffer, discarding any that do not fit.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	room := b.max - b.Len()
+	if room <= 0 {
+		return len(p), nil
+	}
+	if len(p) > room {
+		_, _ = b.Buffer.Write(p[:room])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// A FunctionPipelineRenderer renders the desired state of composed resources by
+// running them through a Composition's function pipeline.
+type FunctionPipelineRenderer struct {
+	fn FunctionRunner
+}
+
+// NewFunctionPipelineRenderer returns a PipelineRenderer that runs each
+// function using the supplied FunctionRunner.
+func NewFunctionPipelineRenderer(r FunctionRunner) *FunctionPipelineRenderer {
+	return &FunctionPipelineRenderer{fn: r}
+}
+
+// RenderPipeline runs the supplied functions in order, updating the supplied
+// desired composed resources with the desired state returned by each.
+func (r *FunctionPipelineRenderer) RenderPipeline(ctx context.Context, cp resource.Composite, fns []v1.Function, observed []resource.Composed, desired []*composed.Unstructured) error {
+	if len(fns) == 0 {
+		return nil
+	}
+
+	xr, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cp)
+	if err != nil {
+		return errors.Wrap(err, errConvertObserved)
+	}
+
+	req := &FunctionRequest{
+		Observed: FunctionState{Composite: xr, Resources: make([]FunctionResource, 0, len(observed))},
+		Desired:  FunctionState{Composite: xr},
+	}
+	for _, cd := range observed {
+		if cd == nil {
+			continue
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cd)
+		if err != nil {
+			return errors.Wrap(err, errConvertObserved)
+		}
+		req.Observed.Resources = append(req.Observed.Resources, functionResource(cd, u))
+	}
+
+	byID := make(map[resourceID]*composed.Unstructured, len(desired))
+	for _, cd := range desired {
+		byID[resourceIDOf(&cd.Unstructured)] = cd
+	}
+
+	for _, fn := range fns {
+		req.Config = fn.Config
+		req.Desired.Resources = make([]FunctionResource, len(desired))
+		for i, cd := range desired {
+			req.Desired.Resources[i] = functionResource(cd, cd.DeepCopy().UnstructuredContent())
+		}
+
+		rsp, err := r.fn.RunFunction(ctx, fn, req)
+		if err != nil {
+			return errors.Wrapf(err, errFmtRunFunction, fn.Name)
+		}
+
+		for _, res := range rsp.Resources {
+			u := &kunstructured.Unstructured{Object: res.Resource}
+			cd, ok := byID[resourceIDOf(u)]
+			if !ok {
+				return errors.Wrapf(errors.Errorf(errFmtUnknownResource, u.GetKind(), u.GetName()), errFmtRunFunction, fn.Name)
+			}
+
+			// Functions may not change which template or forEach item a
+			// resource is associated with.
+			keep := map[string]string{}
+			for _, k := range []string{xcrd.AnnotationKeyCompositionResourceName, xcrd.AnnotationKeyCompositionResourceKey} {
+				if v, ok := cd.GetAnnotations()[k]; ok {
+					keep[k] = v
+				}
+			}
+			cd.SetUnstructuredContent(res.Resource)
+			meta.AddAnnotations(cd, keep)
+		}
+	}
+
+	// Functions may not change which composite resource controls a resource,
+	// or the labels it inherits from the composite resource. The composite
+	// resource would otherwise lose track of the resources it composes.
+	for _, cd := range desired {
+		meta.AddLabels(cd, composedLabels(cp))
+		cd.SetOwnerReferences([]metav1.OwnerReference{controllerRef(cp)})
+	}
+
+	return nil
+}
+
+// A resourceID uniquely identifies a composed resource.
+type resourceID struct {
+	apiVersion string
+	kind       string
+	name       string
+}
+
+func resourceIDOf(u *kunstructured.Unstructured) resourceID {
+	return resourceID{apiVersion: u.GetAPIVersion(), kind: u.GetKind(), name: u.GetName()}
+}
+
+func functionResource(cd resource.Object, u map[string]interface{}) FunctionResource {
+	return FunctionResource{
+		Name:     cd.GetAnnotations()[xcrd.AnnotationKeyCompositionResourceName],
+		Key:      cd.GetAnnotations()[xcrd.AnnotationKeyCompositionResourceKey],
+		Resource: u,
+	}
+}
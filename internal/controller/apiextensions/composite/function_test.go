@@ -0,0 +1,386 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+// TestHelperFunction is not a real test. It is run as an Exec function by
+// TestExecFunctionRunner, which passes the desired behaviour as the last
+// argument.
+func TestHelperFunction(t *testing.T) {
+	mode := os.Args[len(os.Args)-1]
+	switch mode {
+	case "fail":
+		fmt.Fprint(os.Stderr, "function failed\n")
+		os.Exit(1)
+	case "garbage":
+		fmt.Fprint(os.Stdout, "garbage")
+		os.Exit(0)
+	case "flood":
+		for {
+			fmt.Fprint(os.Stdout, "flood")
+		}
+	case "label":
+		req := &FunctionRequest{}
+		if err := json.NewDecoder(os.Stdin).Decode(req); err != nil {
+			fmt.Fprint(os.Stderr, err)
+			os.Exit(1)
+		}
+		rsp := &FunctionResponse{Resources: req.Desired.Resources}
+		for _, r := range rsp.Resources {
+			u := &kunstructured.Unstructured{Object: r.Resource}
+			u.SetLabels(map[string]string{"function": "label"})
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(rsp)
+		os.Exit(0)
+	}
+}
+
+func helperFunction(mode string) v1.Function {
+	return v1.Function{
+		Name: "helper",
+		Type: v1.FunctionTypeExec,
+		Exec: &v1.ExecFunction{
+			Command: filepath.Base(os.Args[0]),
+			Args:    []string{"-test.run=TestHelperFunction", "--", mode},
+		},
+	}
+}
+
+func TestExecFunctionRunner(t *testing.T) {
+	// The test binary is run as a helper function, so its directory is the
+	// function directory.
+	bin, _ := filepath.Abs(os.Args[0])
+	dir := filepath.Dir(bin)
+
+	cd := map[string]interface{}{
+		"apiVersion": "example.org/v1",
+		"kind":       "Composed",
+		"metadata":   map[string]interface{}{"name": "cool-composed"},
+	}
+
+	type args struct {
+		fn  v1.Function
+		req *FunctionRequest
+	}
+	type want struct {
+		rsp *FunctionResponse
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"UnsupportedType": {
+			reason: "We should return an error if the function is not an Exec function.",
+			args: args{
+				fn:  v1.Function{Type: "Cool"},
+				req: &FunctionRequest{},
+			},
+			want: want{
+				err: errors.Errorf(errFmtFunctionType, "Cool"),
+			},
+		},
+		"MissingExec": {
+			reason: "We should return an error if an Exec function has no exec configuration.",
+			args: args{
+				fn:  v1.Function{Type: v1.FunctionTypeExec},
+				req: &FunctionRequest{},
+			},
+			want: want{
+				err: errors.New(errNoExec),
+			},
+		},
+		"CommandOutsideDirectory": {
+			reason: "We should return an error if an Exec function's command is not within the function directory.",
+			args: args{
+				fn: v1.Function{Type: v1.FunctionTypeExec, Exec: &v1.ExecFunction{Command: "/bin/sh"}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCommandNotAllowed, "/bin/sh", dir),
+			},
+		},
+		"CommandTraversesDirectory": {
+			reason: "We should return an error if an Exec function's command traverses out of the function directory.",
+			args: args{
+				fn: v1.Function{Type: v1.FunctionTypeExec, Exec: &v1.ExecFunction{Command: "../" + filepath.Base(bin)}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCommandNotAllowed, "../"+filepath.Base(bin), dir),
+			},
+		},
+		"CommandNotFound": {
+			reason: "We should return an error if an Exec function's command does not exist.",
+			args: args{
+				fn: v1.Function{Type: v1.FunctionTypeExec, Exec: &v1.ExecFunction{Command: "nonexistent"}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCommandNotAllowed, "nonexistent", dir),
+			},
+		},
+		"OutputTooLarge": {
+			reason: "We should return an error, and kill the function, if it writes more than the maximum output to stdout.",
+			args: args{
+				fn:  helperFunction("flood"),
+				req: &FunctionRequest{},
+			},
+			want: want{
+				err: errors.Errorf(errFmtOutputTooLarge, 1024),
+			},
+		},
+		"FunctionFailed": {
+			reason: "We should return an error including the function's stderr if it exits non-zero.",
+			args: args{
+				fn:  helperFunction("fail"),
+				req: &FunctionRequest{},
+			},
+			want: want{
+				err: errors.New("exit status 1: function failed"),
+			},
+		},
+		"InvalidResponse": {
+			reason: "We should return an error if the function does not write a valid response to stdout.",
+			args: args{
+				fn:  helperFunction("garbage"),
+				req: &FunctionRequest{},
+			},
+			want: want{
+				rsp: &FunctionResponse{},
+				err: errors.Wrap(errors.New("invalid character 'g' looking for beginning of value"), errUnmarshalResp),
+			},
+		},
+		"Success": {
+			reason: "We should return the response the function writes to stdout.",
+			args: args{
+				fn: helperFunction("label"),
+				req: &FunctionRequest{
+					Desired: FunctionState{Resources: []FunctionResource{{Name: "cool", Resource: cd}}},
+				},
+			},
+			want: want{
+				rsp: &FunctionResponse{Resources: []FunctionResource{{
+					Name: "cool",
+					Resource: map[string]interface{}{
+						"apiVersion": "example.org/v1",
+						"kind":       "Composed",
+						"metadata": map[string]interface{}{
+							"name":   "cool-composed",
+							"labels": map[string]interface{}{"function": "label"},
+						},
+					},
+				}}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewExecFunctionRunner(dir, WithMaxOutput(1024))
+			rsp, err := r.RunFunction(context.Background(), tc.args.fn, tc.args.req)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRunFunction(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.rsp, rsp); diff != "" {
+				t.Errorf("\n%s\nRunFunction(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderPipeline(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	xr := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XR"}))
+	xr.SetName("cool-xr")
+	xr.SetUID("cool-uid")
+	xr.SetLabels(map[string]string{xcrd.LabelKeyNamePrefixForComposed: "cool-xr"})
+
+	// A resource as rendered for the composite resource, which controls it.
+	owned := func(cd *composed.Unstructured) *composed.Unstructured {
+		meta.AddLabels(cd, map[string]string{
+			xcrd.LabelKeyNamePrefixForComposed: "cool-xr",
+			xcrd.LabelKeyClaimName:             "",
+			xcrd.LabelKeyClaimNamespace:        "",
+		})
+		cd.SetOwnerReferences([]metav1.OwnerReference{meta.AsController(meta.TypedReferenceTo(xr, xr.GetObjectKind().GroupVersionKind()))})
+		return cd
+	}
+
+	newComposed := func(name string, labels map[string]string) *composed.Unstructured {
+		cd := composed.New()
+		cd.SetAPIVersion("example.org/v1")
+		cd.SetKind("Composed")
+		cd.SetName(name)
+		cd.SetAnnotations(map[string]string{xcrd.AnnotationKeyCompositionResourceName: "cool"})
+		if labels != nil {
+			cd.SetLabels(labels)
+		}
+		return cd
+	}
+
+	// The resource a function wants, without the annotations that associate
+	// it with its template.
+	fromFunction := func() map[string]interface{} {
+		cd := newComposed("cool-composed", map[string]string{"function": "ran"})
+		cd.SetAnnotations(nil)
+		return cd.UnstructuredContent()
+	}
+
+	type args struct {
+		fn       FunctionRunner
+		fns      []v1.Function
+		observed []resource.Composed
+		desired  []*composed.Unstructured
+	}
+	type want struct {
+		desired []*composed.Unstructured
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoFunctions": {
+			reason: "We should not modify desired resources if there are no functions.",
+			args: args{
+				desired: []*composed.Unstructured{newComposed("cool-composed", nil)},
+			},
+			want: want{
+				desired: []*composed.Unstructured{newComposed("cool-composed", nil)},
+			},
+		},
+		"RunFunctionError": {
+			reason: "We should return any error encountered while running a function.",
+			args: args{
+				fn: FunctionRunnerFn(func(_ context.Context, _ v1.Function, _ *FunctionRequest) (*FunctionResponse, error) {
+					return nil, errBoom
+				}),
+				fns:     []v1.Function{{Name: "cool-fn"}},
+				desired: []*composed.Unstructured{newComposed("cool-composed", nil)},
+			},
+			want: want{
+				desired: []*composed.Unstructured{newComposed("cool-composed", nil)},
+				err:     errors.Wrapf(errBoom, errFmtRunFunction, "cool-fn"),
+			},
+		},
+		"UnknownResource": {
+			reason: "We should return an error if a function returns a resource that was not desired.",
+			args: args{
+				fn: FunctionRunnerFn(func(_ context.Context, _ v1.Function, _ *FunctionRequest) (*FunctionResponse, error) {
+					return &FunctionResponse{Resources: []FunctionResource{{
+						Resource: newComposed("new-composed", nil).UnstructuredContent(),
+					}}}, nil
+				}),
+				fns:     []v1.Function{{Name: "cool-fn"}},
+				desired: []*composed.Unstructured{newComposed("cool-composed", nil)},
+			},
+			want: want{
+				desired: []*composed.Unstructured{newComposed("cool-composed", nil)},
+				err:     errors.Wrapf(errors.Errorf(errFmtUnknownResource, "Composed", "new-composed"), errFmtRunFunction, "cool-fn"),
+			},
+		},
+		"Success": {
+			reason: "We should send each function the observed and desired state, and update desired resources with their responses.",
+			args: args{
+				fn: FunctionRunnerFn(func(_ context.Context, fn v1.Function, req *FunctionRequest) (*FunctionResponse, error) {
+					want := &FunctionRequest{
+						Config: fn.Config,
+						Observed: FunctionState{
+							Composite: xr.UnstructuredContent(),
+							Resources: []FunctionResource{{Name: "cool", Resource: newComposed("cool-composed", nil).UnstructuredContent()}},
+						},
+						Desired: FunctionState{
+							Composite: xr.UnstructuredContent(),
+							Resources: []FunctionResource{{Name: "cool", Resource: newComposed("cool-composed", nil).UnstructuredContent()}},
+						},
+					}
+					if fn.Name == "second" {
+						// The second function sees the output of the first.
+						want.Desired.Resources[0].Resource = newComposed("cool-composed", map[string]string{"function": "ran"}).UnstructuredContent()
+					}
+					if diff := cmp.Diff(want, req); diff != "" {
+						t.Errorf("RunFunction(...): -want, +got:\n%s", diff)
+					}
+					return &FunctionResponse{Resources: []FunctionResource{{Resource: fromFunction()}}}, nil
+				}),
+				fns: []v1.Function{
+					{Name: "first", Config: &runtime.RawExtension{Raw: []byte(`{"cool":true}`)}},
+					{Name: "second"},
+				},
+				observed: []resource.Composed{nil, newComposed("cool-composed", nil)},
+				desired:  []*composed.Unstructured{newComposed("cool-composed", nil)},
+			},
+			want: want{
+				desired: []*composed.Unstructured{owned(newComposed("cool-composed", map[string]string{"function": "ran"}))},
+			},
+		},
+		"RestoreOwnership": {
+			reason: "We should restore the controller reference and composite labels of a resource whose function removed or changed them.",
+			args: args{
+				fn: FunctionRunnerFn(func(_ context.Context, _ v1.Function, req *FunctionRequest) (*FunctionResponse, error) {
+					cd := &composed.Unstructured{Unstructured: kunstructured.Unstructured{Object: req.Desired.Resources[0].Resource}}
+					cd.SetOwnerReferences(nil)
+					cd.SetLabels(map[string]string{xcrd.LabelKeyNamePrefixForComposed: "other-xr", "function": "ran"})
+					return &FunctionResponse{Resources: []FunctionResource{{Resource: cd.UnstructuredContent()}}}, nil
+				}),
+				fns:     []v1.Function{{Name: "cool-fn"}},
+				desired: []*composed.Unstructured{owned(newComposed("cool-composed", nil))},
+			},
+			want: want{
+				desired: []*composed.Unstructured{owned(newComposed("cool-composed", map[string]string{"function": "ran"}))},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewFunctionPipelineRenderer(tc.args.fn)
+			err := r.RenderPipeline(context.Background(), xr, tc.args.fns, tc.args.observed, tc.args.desired)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRenderPipeline(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.desired, tc.args.desired); diff != "" {
+				t.Errorf("\n%s\nRenderPipeline(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -18,11 +18,16 @@ package composite
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -30,6 +35,7 @@ import (
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
@@ -39,9 +45,12 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
 const (
+	finalizer = "composite.apiextensions.crossplane.io"
+
 	shortWait = 30 * time.Second
 	longWait  = 1 * time.Minute
 	timeout   = 2 * time.Minute
@@ -54,12 +63,34 @@ const (
 	errUpdateStatus = "cannot update composite resource status"
 	errSelectComp   = "cannot select Composition"
 	errGetComp      = "cannot get Composition"
+	errFetchRev     = "cannot fetch Composition revision"
+	errRunPipeline  = "cannot run function pipeline"
+	errFetchEnv     = "cannot fetch environment"
 	errConfigure    = "cannot configure composite resource"
 	errPublish      = "cannot publish connection details"
 	errRenderCD     = "cannot render composed resource"
 	errRenderCR     = "cannot render composite resource"
-
-	errFmtRender = "cannot render composed resource at index %d"
+	errFetchSources = "cannot fetch patch sources"
+	errFetchCDs     = "cannot fetch composed resources"
+	errRemoveCD     = "cannot remove composed resource"
+	errSetStatuses  = "cannot set the status of composed resources"
+	errAddFinalizer = "cannot add composite resource finalizer"
+	errRemFinalizer = "cannot remove composite resource finalizer"
+	errOrphanCDs    = "cannot orphan composed resources"
+	errDeleteCDs    = "cannot delete composed resources"
+	errOrderCDs     = "cannot order composed resources"
+	errInvalidComp  = "invalid Composition"
+
+	errFmtRender    = "cannot render composed resource at index %d"
+	errFmtCondition = "cannot evaluate the condition of composed resource at index %d"
+	errFmtDelete    = "cannot delete composed resource at index %d"
+	errFmtForEach   = "cannot get the forEach items of composed resource at index %d"
+	errFmtRenderFE  = "cannot render composed resource at index %d for forEach item %q"
+	errFmtDeleteFE  = "cannot delete composed resource for forEach item %q"
+	errFmtRemove    = "cannot remove composed resource %s %q"
+	errFmtEnvPatch  = "cannot apply environment patch at index %d"
+
+	errFmtNotRemoved = "did not remove composed resource %s %q, which corresponds to no template, because it does not exist or is controlled by another resource"
 )
 
 // Event reasons.
@@ -67,6 +98,8 @@ const (
 	reasonResolve event.Reason = "SelectComposition"
 	reasonCompose event.Reason = "ComposeResources"
 	reasonPublish event.Reason = "PublishConnectionSecret"
+	reasonDelete  event.Reason = "DeleteCompositeResource"
+	reasonPaused  event.Reason = "ReconciliationPaused"
 )
 
 // ControllerName returns the recommended name for controllers that use this
@@ -113,6 +146,22 @@ func (fn CompositionSelectorFn) SelectComposition(ctx context.Context, cr resour
 	return fn(ctx, cr)
 }
 
+// A RevisionFetcher fetches the revision of a Composition that a composite
+// resource should be composed using.
+type RevisionFetcher interface {
+	FetchRevision(ctx context.Context, cr resource.Composite, comp *v1.Composition) (*v1.Composition, error)
+}
+
+// A RevisionFetcherFn fetches the revision of a Composition that a composite
+// resource should be composed using.
+type RevisionFetcherFn func(ctx context.Context, cr resource.Composite, comp *v1.Composition) (*v1.Composition, error)
+
+// FetchRevision of the supplied Composition for the supplied composite
+// resource.
+func (fn RevisionFetcherFn) FetchRevision(ctx context.Context, cr resource.Composite, comp *v1.Composition) (*v1.Composition, error) {
+	return fn(ctx, cr, comp)
+}
+
 // A Configurator configures a composite resource using its composition.
 type Configurator interface {
 	Configure(ctx context.Context, cr resource.Composite, cp *v1.Composition) error
@@ -128,16 +177,122 @@ func (fn ConfiguratorFn) Configure(ctx context.Context, cr resource.Composite, c
 
 // A Renderer is used to render a composed resource.
 type Renderer interface {
-	Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error
+	Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, src PatchSources) error
 }
 
 // A RendererFn may be used to render a composed resource.
-type RendererFn func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error
+type RendererFn func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, src PatchSources) error
+
+// Render the supplied composed resource using the supplied composite resource,
+// template, and patch sources as inputs.
+func (fn RendererFn) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, src PatchSources) error {
+	return fn(ctx, cp, cd, t, src)
+}
+
+// A PipelineRenderer renders the desired state of composed resources by running
+// them through a pipeline of functions.
+type PipelineRenderer interface {
+	RenderPipeline(ctx context.Context, cp resource.Composite, fns []v1.Function, observed []resource.Composed, desired []*composed.Unstructured) error
+}
+
+// A PipelineRendererFn renders the desired state of composed resources by
+// running them through a pipeline of functions.
+type PipelineRendererFn func(ctx context.Context, cp resource.Composite, fns []v1.Function, observed []resource.Composed, desired []*composed.Unstructured) error
+
+// RenderPipeline runs the supplied desired composed resources through the
+// supplied pipeline of functions.
+func (fn PipelineRendererFn) RenderPipeline(ctx context.Context, cp resource.Composite, fns []v1.Function, observed []resource.Composed, desired []*composed.Unstructured) error {
+	return fn(ctx, cp, fns, observed, desired)
+}
 
-// Render the supplied composed resource using the supplied composite resource
-// and template as inputs.
-func (fn RendererFn) Render(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-	return fn(ctx, cp, cd, t)
+// A PatchSourceFetcher fetches the sources, other than the composite resource,
+// that the patches of the supplied composed resource templates read from.
+type PatchSourceFetcher interface {
+	FetchPatchSources(ctx context.Context, ts []v1.ComposedTemplate, refs []corev1.ObjectReference) (PatchSources, error)
+}
+
+// A PatchSourceFetcherFn fetches the sources, other than the composite
+// resource, that the patches of the supplied composed resource templates read
+// from.
+type PatchSourceFetcherFn func(ctx context.Context, ts []v1.ComposedTemplate, refs []corev1.ObjectReference) (PatchSources, error)
+
+// FetchPatchSources calls the PatchSourceFetcherFn.
+func (fn PatchSourceFetcherFn) FetchPatchSources(ctx context.Context, ts []v1.ComposedTemplate, refs []corev1.ObjectReference) (PatchSources, error) {
+	return fn(ctx, ts, refs)
+}
+
+// An EnvironmentFetcher fetches the environment of a Composition.
+type EnvironmentFetcher interface {
+	FetchEnvironment(ctx context.Context, cr resource.Composite, cfg *v1.EnvironmentConfiguration) (map[string]interface{}, error)
+}
+
+// An EnvironmentFetcherFn fetches the environment of a Composition.
+type EnvironmentFetcherFn func(ctx context.Context, cr resource.Composite, cfg *v1.EnvironmentConfiguration) (map[string]interface{}, error)
+
+// FetchEnvironment calls the EnvironmentFetcherFn.
+func (fn EnvironmentFetcherFn) FetchEnvironment(ctx context.Context, cr resource.Composite, cfg *v1.EnvironmentConfiguration) (map[string]interface{}, error) {
+	return fn(ctx, cr, cfg)
+}
+
+// A ComposedFetcher fetches the composed resources referenced by a composite
+// resource.
+type ComposedFetcher interface {
+	FetchComposed(ctx context.Context, refs []corev1.ObjectReference) ([]resource.Composed, error)
+}
+
+// A ComposedFetcherFn fetches the composed resources referenced by a composite
+// resource.
+type ComposedFetcherFn func(ctx context.Context, refs []corev1.ObjectReference) ([]resource.Composed, error)
+
+// FetchComposed calls the ComposedFetcherFn.
+func (fn ComposedFetcherFn) FetchComposed(ctx context.Context, refs []corev1.ObjectReference) ([]resource.Composed, error) {
+	return fn(ctx, refs)
+}
+
+// A ComposedDeleter deletes composed resources that should no longer exist.
+// It returns true if it deleted the resource.
+type ComposedDeleter interface {
+	DeleteComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error)
+}
+
+// A ComposedDeleterFn deletes composed resources that should no longer exist.
+type ComposedDeleterFn func(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error)
+
+// DeleteComposed calls the ComposedDeleterFn.
+func (fn ComposedDeleterFn) DeleteComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error) {
+	return fn(ctx, cp, ref)
+}
+
+// A ComposedOrphaner orphans composed resources that should no longer be
+// controlled by their composite resource. It returns true if it orphaned the
+// resource.
+type ComposedOrphaner interface {
+	OrphanComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error)
+}
+
+// A ComposedOrphanerFn orphans composed resources that should no longer be
+// controlled by their composite resource.
+type ComposedOrphanerFn func(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error)
+
+// OrphanComposed calls the ComposedOrphanerFn.
+func (fn ComposedOrphanerFn) OrphanComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error) {
+	return fn(ctx, cp, ref)
+}
+
+// A ComposedReleaser releases composed resources from the control of their
+// composite resource without otherwise changing them. It returns true if it
+// released the resource.
+type ComposedReleaser interface {
+	ReleaseComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error)
+}
+
+// A ComposedReleaserFn releases composed resources from the control of their
+// composite resource.
+type ComposedReleaserFn func(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error)
+
+// ReleaseComposed calls the ComposedReleaserFn.
+func (fn ComposedReleaserFn) ReleaseComposed(ctx context.Context, cp resource.Composite, ref corev1.ObjectReference) (bool, error) {
+	return fn(ctx, cp, ref)
 }
 
 // ConnectionDetailsFetcher fetches the connection details of the Composed resource.
@@ -199,6 +354,62 @@ func WithRenderer(rd Renderer) ReconcilerOption {
 	}
 }
 
+// WithPipelineRenderer specifies how the Reconciler should run the desired
+// state of composed resources through a Composition's function pipeline.
+func WithPipelineRenderer(rd PipelineRenderer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.PipelineRenderer = rd
+	}
+}
+
+// WithPatchSourceFetcher specifies how the Reconciler should fetch the sources
+// that composed resource patches read from.
+func WithPatchSourceFetcher(f PatchSourceFetcher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.PatchSourceFetcher = f
+	}
+}
+
+// WithEnvironmentFetcher specifies how the Reconciler should fetch the
+// environment of a Composition.
+func WithEnvironmentFetcher(f EnvironmentFetcher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.EnvironmentFetcher = f
+	}
+}
+
+// WithComposedFetcher specifies how the Reconciler should fetch the composed
+// resources referenced by a composite resource.
+func WithComposedFetcher(f ComposedFetcher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.ComposedFetcher = f
+	}
+}
+
+// WithComposedDeleter specifies how the Reconciler should delete composed
+// resources that should no longer exist.
+func WithComposedDeleter(d ComposedDeleter) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.ComposedDeleter = d
+	}
+}
+
+// WithComposedOrphaner specifies how the Reconciler should orphan composed
+// resources that should no longer be controlled by their composite resource.
+func WithComposedOrphaner(o ComposedOrphaner) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.ComposedOrphaner = o
+	}
+}
+
+// WithComposedReleaser specifies how the Reconciler should release composed
+// resources that correspond to none of the templates of their Composition.
+func WithComposedReleaser(rl ComposedReleaser) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.ComposedReleaser = rl
+	}
+}
+
 // WithConnectionDetailsFetcher specifies how the Reconciler should fetch the
 // connection details of composed resources.
 func WithConnectionDetailsFetcher(f ConnectionDetailsFetcher) ReconcilerOption {
@@ -223,6 +434,14 @@ func WithCompositionSelector(p CompositionSelector) ReconcilerOption {
 	}
 }
 
+// WithRevisionFetcher specifies how the Reconciler should fetch the revision
+// of a Composition that a composite resource should be composed using.
+func WithRevisionFetcher(f RevisionFetcher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.RevisionFetcher = f
+	}
+}
+
 // WithConfigurator specifies how the Reconciler should configure
 // composite resources using their composition.
 func WithConfigurator(c Configurator) ReconcilerOption {
@@ -239,6 +458,14 @@ func WithConnectionPublisher(p ConnectionPublisher) ReconcilerOption {
 	}
 }
 
+// WithCompositeFinalizer specifies how the Reconciler should add and remove
+// finalizers to and from composite resources.
+func WithCompositeFinalizer(f resource.Finalizer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.Finalizer = f
+	}
+}
+
 // WithCompositeRenderer specifies how the Reconciler should render composite resources.
 func WithCompositeRenderer(rd Renderer) ReconcilerOption {
 	return func(r *Reconciler) {
@@ -247,7 +474,9 @@ func WithCompositeRenderer(rd Renderer) ReconcilerOption {
 }
 
 type compositeResource struct {
+	resource.Finalizer
 	CompositionSelector
+	RevisionFetcher
 	Configurator
 	ConnectionPublisher
 	Renderer
@@ -255,6 +484,13 @@ type compositeResource struct {
 
 type composedResource struct {
 	Renderer
+	PipelineRenderer
+	PatchSourceFetcher
+	EnvironmentFetcher
+	ComposedFetcher
+	ComposedDeleter
+	ComposedOrphaner
+	ComposedReleaser
 	ConnectionDetailsFetcher
 	ReadinessChecker
 }
@@ -274,7 +510,9 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 		newComposite: nc,
 
 		composite: compositeResource{
-			CompositionSelector: NewAPILabelSelectorResolver(kube),
+			Finalizer:           resource.NewAPIFinalizer(kube, finalizer),
+			CompositionSelector: NewAPILabelSelectorResolver(kube, event.NewNopRecorder()),
+			RevisionFetcher:     NewAPIRevisionFetcher(kube),
 			Configurator:        NewConfiguratorChain(NewAPINamingConfigurator(kube), NewAPIConfigurator(kube)),
 			ConnectionPublisher: NewAPIFilteredSecretPublisher(kube, []string{}),
 			Renderer:            RendererFn(RenderComposite),
@@ -282,6 +520,13 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 
 		composed: composedResource{
 			Renderer:                 NewAPIDryRunRenderer(kube),
+			PipelineRenderer:         NewFunctionPipelineRenderer(FunctionRunnerFn(RunNoFunction)),
+			PatchSourceFetcher:       NewAPIPatchSourceFetcher(kube),
+			EnvironmentFetcher:       NewAPIEnvironmentFetcher(kube),
+			ComposedFetcher:          NewAPIComposedFetcher(kube),
+			ComposedDeleter:          NewAPIComposedDeleter(kube),
+			ComposedOrphaner:         NewAPIComposedOrphaner(kube),
+			ComposedReleaser:         NewAPIComposedReleaser(kube),
 			ReadinessChecker:         ReadinessCheckerFn(IsReady),
 			ConnectionDetailsFetcher: NewAPIConnectionDetailsFetcher(kube),
 		},
@@ -296,6 +541,191 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 	return r
 }
 
+// A composedTarget is a composed resource that should exist, and the template
+// and patch sources it is rendered from.
+type composedTarget struct {
+	cd  *composed.Unstructured
+	t   v1.ComposedTemplate
+	src PatchSources
+}
+
+// A composedStatus is the observed status of a composed resource, as reported
+// in the status of its composite resource.
+type composedStatus struct {
+	APIVersion   string `json:"apiVersion"`
+	Kind         string `json:"kind"`
+	Name         string `json:"name"`
+	Template     string `json:"template,omitempty"`
+	Ready        bool   `json:"ready"`
+	ReadyReason  string `json:"readyReason,omitempty"`
+	SyncedReason string `json:"syncedReason,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// composedStatusOf returns the status of the supplied composed resource. The
+// message of its Synced condition is included when it is not synced, because
+// it typically describes the last error encountered reconciling it.
+func composedStatusOf(cd *composed.Unstructured, t v1.ComposedTemplate, ready bool) composedStatus {
+	s := composedStatus{
+		APIVersion:   cd.GetAPIVersion(),
+		Kind:         cd.GetKind(),
+		Name:         cd.GetName(),
+		Ready:        ready,
+		ReadyReason:  string(cd.GetCondition(xpv1.TypeReady).Reason),
+		SyncedReason: string(cd.GetCondition(xpv1.TypeSynced).Reason),
+	}
+	if t.Name != nil {
+		s.Template = *t.Name
+	}
+	if c := cd.GetCondition(xpv1.TypeSynced); c.Status == corev1.ConditionFalse {
+		s.Message = c.Message
+	}
+	return s
+}
+
+// blockedStatusOf returns the status of a composed resource that cannot be
+// applied because it could not be rendered.
+func blockedStatusOf(cd *composed.Unstructured, t v1.ComposedTemplate, err error) composedStatus {
+	s := composedStatusOf(cd, t, false)
+	s.Message = err.Error()
+	return s
+}
+
+// setComposedStatuses sets the status of the resources composed by the
+// supplied composite resource.
+func setComposedStatuses(cr resource.Composite, s []composedStatus) error {
+	u, ok := cr.(interface{ UnstructuredContent() map[string]interface{} })
+	if !ok {
+		return nil
+	}
+	return errors.Wrap(fieldpath.Pave(u.UnstructuredContent()).SetValue("status.resources", s), errSetStatuses)
+}
+
+// unreadyMessage returns a message naming the supplied unready resources.
+func unreadyMessage(unready []composedStatus) string {
+	names := make([]string, len(unready))
+	for i, s := range unready {
+		names[i] = s.Kind + "/" + s.Name
+	}
+	return "Unready resources: " + strings.Join(names, ", ")
+}
+
+// A forEachItemID identifies the resource composed for a forEach item.
+type forEachItemID struct {
+	template string
+	key      string
+}
+
+// composedRefs are the resource references of a composite resource, associated
+// with the templates of its Composition.
+type composedRefs struct {
+	// templates holds the reference to the resource composed by each
+	// template, in the order of the templates. A reference is empty if its
+	// template has not yet composed a resource, or is a forEach template.
+	templates []corev1.ObjectReference
+
+	// items holds the references to the resources composed for forEach
+	// items.
+	items map[forEachItemID]corev1.ObjectReference
+
+	// unmatched holds the references to resources that correspond to none of
+	// the templates, for example because their template was removed.
+	unmatched []corev1.ObjectReference
+}
+
+// associateRefs associates the supplied resource references with the supplied
+// templates. The observed resources must correspond to the references; an
+// observed resource is nil if it does not exist. Resources composed from named
+// templates are annotated with the name of their template, and are associated
+// by that name. Resources composed from unnamed templates, or composed before
+// their template was named, are associated by their position, as long as the
+// template at that position composes the same kind of resource.
+func associateRefs(ts []v1.ComposedTemplate, refs []corev1.ObjectReference, observed []resource.Composed) composedRefs {
+	a := composedRefs{
+		templates: make([]corev1.ObjectReference, len(ts)),
+		items:     map[forEachItemID]corev1.ObjectReference{},
+	}
+
+	named := map[string]int{}
+	forEach := map[string]bool{}
+	for i, t := range ts {
+		if t.Name == nil {
+			continue
+		}
+		if t.ForEach != nil {
+			forEach[*t.Name] = true
+			continue
+		}
+		named[*t.Name] = i
+	}
+
+	positional := make([]int, 0, len(refs))
+	for i, ref := range refs {
+		if i >= len(observed) || observed[i] == nil {
+			positional = append(positional, i)
+			continue
+		}
+		an := observed[i].GetAnnotations()
+		name, ok := an[xcrd.AnnotationKeyCompositionResourceName]
+		if !ok {
+			positional = append(positional, i)
+			continue
+		}
+		if key, ok := an[xcrd.AnnotationKeyCompositionResourceKey]; ok {
+			if !forEach[name] {
+				a.unmatched = append(a.unmatched, ref)
+				continue
+			}
+			a.items[forEachItemID{template: name, key: key}] = ref
+			continue
+		}
+		if j, ok := named[name]; ok && a.templates[j].Name == "" {
+			a.templates[j] = ref
+			continue
+		}
+		a.unmatched = append(a.unmatched, ref)
+	}
+
+	for _, i := range positional {
+		if i < len(ts) && ts[i].ForEach == nil && a.templates[i].Name == "" && composes(ts[i], refs[i]) {
+			a.templates[i] = refs[i]
+			continue
+		}
+		if refs[i].Name != "" {
+			a.unmatched = append(a.unmatched, refs[i])
+		}
+	}
+
+	return a
+}
+
+// validateTemplateNames returns an error if more than one of the supplied
+// templates has the same name.
+func validateTemplateNames(ts []v1.ComposedTemplate) error {
+	seen := map[string]bool{}
+	for _, t := range ts {
+		if t.Name == nil {
+			continue
+		}
+		if seen[*t.Name] {
+			return errors.Errorf(errFmtDuplicateTemplate, *t.Name)
+		}
+		seen[*t.Name] = true
+	}
+	return nil
+}
+
+// composes returns true if the supplied template composes the kind of resource
+// referred to by the supplied reference. The version of the resource is not
+// considered, so that a template may be updated to a new version of the kind of
+// resource it composes.
+func composes(t v1.ComposedTemplate, ref corev1.ObjectReference) bool {
+	// Any error decoding the base template is returned when it is rendered.
+	u := &kunstructured.Unstructured{}
+	_ = json.Unmarshal(t.Base.Raw, u)
+	return u.GroupVersionKind().GroupKind() == ref.GroupVersionKind().GroupKind()
+}
+
 // A Reconciler reconciles composite resources.
 type Reconciler struct {
 	client       resource.ClientApplicator
@@ -332,6 +762,92 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		"name", cr.GetName(),
 	)
 
+	// We don't touch a paused composite resource or its composed resources
+	// unless it is being deleted. We'll be queued again when the pause
+	// annotation is removed. We only record an event and update our status
+	// when we become paused, not each time we're reconciled while paused.
+	if cr.GetAnnotations()[xcrd.AnnotationKeyReconciliationPaused] == "true" && !meta.WasDeleted(cr) {
+		log.Debug("Reconciliation is paused")
+		if cr.GetCondition(xpv1.TypeSynced).Reason == v1.ReasonReconcilePaused {
+			return reconcile.Result{}, nil
+		}
+		r.record.Event(cr, event.Normal(reasonPaused, "Reconciliation is paused via the pause annotation"))
+		cr.SetConditions(v1.ReconcilePaused())
+		return reconcile.Result{}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+	}
+
+	// Reconciliation is no longer paused. Our status will be updated once
+	// we're done reconciling.
+	if cr.GetCondition(xpv1.TypeSynced).Reason == v1.ReasonReconcilePaused {
+		cr.SetConditions(xpv1.ReconcileSuccess())
+	}
+
+	// Composite resources with the Orphan deletion policy are finalized so that
+	// their composed resources may be orphaned before they are garbage
+	// collected. Those whose Composition has templates that depend on other
+	// templates are finalized so that their composed resources may be deleted
+	// in reverse dependency order. Others rely on garbage collection to delete
+	// their composed resources.
+	orphanOnDelete := getDeletionPolicy(cr) == xpv1.DeletionOrphan
+
+	if meta.WasDeleted(cr) {
+		log = log.WithValues("deletion-timestamp", cr.GetDeletionTimestamp())
+		switch {
+		case orphanOnDelete:
+			for _, ref := range cr.GetResourceReferences() {
+				if _, err := r.composed.OrphanComposed(ctx, cr, ref); err != nil {
+					log.Debug(errOrphanCDs, "error", err)
+					r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errOrphanCDs)))
+					return reconcile.Result{RequeueAfter: shortWait}, nil
+				}
+			}
+			r.record.Event(cr, event.Normal(reasonDelete, "Orphaned composed resources"))
+		case meta.FinalizerExists(cr, finalizer) && cr.GetCompositionReference() != nil:
+			// Composed resources are deleted all at once if the Composition
+			// no longer exists.
+			comp := &v1.Composition{}
+			err := r.client.Get(ctx, meta.NamespacedNameOf(cr.GetCompositionReference()), comp)
+			if resource.IgnoreNotFound(err) != nil {
+				log.Debug(errGetComp, "error", err)
+				r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errGetComp)))
+				return reconcile.Result{RequeueAfter: shortWait}, nil
+			}
+			// Composed resources are deleted in the order of the revision
+			// of the Composition that composed them.
+			if err == nil {
+				comp, err = r.composite.FetchRevision(ctx, cr, comp)
+				if err != nil {
+					log.Debug(errFetchRev, "error", err)
+					r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errFetchRev)))
+					return reconcile.Result{RequeueAfter: shortWait}, nil
+				}
+			}
+			observed, err := r.composed.FetchComposed(ctx, cr.GetResourceReferences())
+			if err != nil {
+				log.Debug(errFetchCDs, "error", err)
+				r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errFetchCDs)))
+				return reconcile.Result{RequeueAfter: shortWait}, nil
+			}
+			gone, err := deleteInOrder(ctx, r.composed.ComposedDeleter, cr, comp.Spec.Resources, cr.GetResourceReferences(), observed)
+			if err != nil {
+				log.Debug(errDeleteCDs, "error", err)
+				r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errDeleteCDs)))
+				return reconcile.Result{RequeueAfter: shortWait}, nil
+			}
+			if !gone {
+				log.Debug("Waiting for composed resources to be deleted", "requeue-after", time.Now().Add(shortWait))
+				r.record.Event(cr, event.Normal(reasonDelete, "Waiting for composed resources to be deleted"))
+				return reconcile.Result{RequeueAfter: shortWait}, nil
+			}
+		}
+		if err := r.composite.RemoveFinalizer(ctx, cr); err != nil {
+			log.Debug(errRemFinalizer, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errRemFinalizer)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		return reconcile.Result{Requeue: false}, nil
+	}
+
 	if err := r.composite.SelectComposition(ctx, cr); err != nil {
 		log.Debug(errSelectComp, "error", err)
 		r.record.Event(cr, event.Warning(reasonResolve, err))
@@ -348,6 +864,25 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
 
+	// A composite resource may be pinned to a revision that no longer
+	// exists, which only updating the composite resource can fix.
+	comp, err := r.composite.FetchRevision(ctx, cr, comp)
+	if err != nil {
+		log.Debug(errFetchRev, "error", err)
+		err = errors.Wrap(err, errFetchRev)
+		r.record.Event(cr, event.Warning(reasonCompose, err))
+		cr.SetConditions(xpv1.ReconcileError(err))
+		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+	}
+
+	if orphanOnDelete || hasDependencies(comp.Spec.Resources) {
+		if err := r.composite.AddFinalizer(ctx, cr); err != nil {
+			log.Debug(errAddFinalizer, "error", err)
+			r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errAddFinalizer)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+	}
+
 	if err := r.composite.Configure(ctx, cr, comp); err != nil {
 		log.Debug(errConfigure, "error", err)
 		r.record.Event(cr, event.Warning(reasonCompose, err))
@@ -360,19 +895,34 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		"composition-name", comp.GetName(),
 	)
 
+	// Resources are associated with named templates by name, so a Composition
+	// with duplicate template names could associate a resource with the wrong
+	// template.
+	if err := validateTemplateNames(comp.Spec.Resources); err != nil {
+		log.Debug(errInvalidComp, "error", err)
+		err = errors.Wrap(err, errInvalidComp)
+		r.record.Event(cr, event.Warning(reasonCompose, err))
+		cr.SetConditions(xpv1.ReconcileError(err))
+		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+	}
+
 	// TODO(muvaf): Since the composed reconciler returns only reference, it can
 	// be parallelized via go routines.
 
-	// In order to iterate over all composition targets, we create an empty ref
-	// array with the same length. Then copy the already provisioned ones into
-	// that array to not create new ones because composed reconciler assumes that
-	// if the reference is empty, it needs to create the resource.
+	existing := cr.GetResourceReferences()
+	observed, err := r.composed.FetchComposed(ctx, existing)
+	if err != nil {
+		log.Debug(errFetchCDs, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errFetchCDs)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
 
-	// TODO(negz): This approach means that the resources of a Composition are
-	// effectively append only. We may want to reconsider this per
-	// https://github.com/crossplane/crossplane/issues/1909
-	refs := make([]corev1.ObjectReference, len(comp.Spec.Resources))
-	copy(refs, cr.GetResourceReferences())
+	// We associate each existing resource with the template it was composed
+	// from. Templates that have not yet composed a resource have an empty
+	// reference, which tells the composed renderer to create one. Resources
+	// composed for forEach items are referenced after these references.
+	associated := associateRefs(comp.Spec.Resources, existing, observed)
+	refs, items := associated.templates, associated.items
 
 	// Inline PatchSets from Composition Spec before rendering
 	if err := comp.Spec.InlinePatchSets(); err != nil {
@@ -380,31 +930,266 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		r.record.Event(cr, event.Warning(reasonCompose, err))
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
+	comp.Spec.DefaultPatchPolicies()
 
-	cds := make([]*composed.Unstructured, len(refs))
+	// Resources are applied in an order in which each follows the resources
+	// it depends on, so that we know whether its dependencies are ready.
+	order, err := dependencyOrder(comp.Spec.Resources)
+	if err != nil {
+		log.Debug(errOrderCDs, "error", err)
+		err = errors.Wrap(err, errOrderCDs)
+		r.record.Event(cr, event.Warning(reasonCompose, err))
+		cr.SetConditions(xpv1.ReconcileError(err))
+		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+	}
+
+	// Patches may read from composed resources that we have already created.
+	src, err := r.composed.FetchPatchSources(ctx, comp.Spec.Resources, refs)
+	if err != nil {
+		log.Debug(errFetchSources, "error", err)
+		err = errors.Wrap(err, errFetchSources)
+		r.record.Event(cr, event.Warning(reasonCompose, err))
+		cr.SetConditions(xpv1.ReconcileError(err))
+		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+	}
+
+	// The environment is patched to and from the composite resource before
+	// any resources are composed, so that they may patch from the results.
+	env, err := r.composed.FetchEnvironment(ctx, cr, comp.Spec.Environment)
+	if err != nil {
+		log.Debug(errFetchEnv, "error", err)
+		err = errors.Wrap(err, errFetchEnv)
+		r.record.Event(cr, event.Warning(reasonCompose, err))
+		cr.SetConditions(xpv1.ReconcileError(err))
+		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+	}
+	if comp.Spec.Environment != nil {
+		for i, p := range comp.Spec.Environment.Patches {
+			if err := p.Apply(cr, &kunstructured.Unstructured{Object: env}); err != nil {
+				err = errors.Wrapf(err, errFmtEnvPatch, i)
+				log.Debug(errFetchEnv, "error", err)
+				r.record.Event(cr, event.Warning(reasonCompose, err))
+				cr.SetConditions(xpv1.ReconcileError(err))
+				return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+			}
+		}
+	}
+	src.Environment = env
+
+	targets := make([]composedTarget, 0, len(refs))
+	itemRefs := make([]corev1.ObjectReference, 0)
+	blocked := make([]composedStatus, 0)
 	for i := range refs {
+		t := comp.Spec.Resources[i]
+		ok, err := t.ConditionMet(cr)
+		if err != nil {
+			err = errors.Wrapf(err, errFmtCondition, i)
+			log.Debug(errRenderCD, "error", err, "index", i)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			cr.SetConditions(xpv1.ReconcileError(err))
+			return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+		}
+
+		if t.ForEach != nil {
+			// A forEach template composes a resource for each of its items
+			// rather than a resource of its own. No items are composed if its
+			// condition is not met.
+			refs[i] = corev1.ObjectReference{}
+			var fi []ForEachItem
+			if ok {
+				fi, err = ForEachItems(cr, t)
+			}
+			if err != nil {
+				err = errors.Wrapf(err, errFmtForEach, i)
+				log.Debug(errRenderCD, "error", err, "index", i)
+				r.record.Event(cr, event.Warning(reasonCompose, err))
+				cr.SetConditions(xpv1.ReconcileError(err))
+				return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+			}
+			for j := range fi {
+				id := forEachItemID{template: *t.Name, key: fi[j].Key}
+				cd := composed.New(composed.FromReference(items[id]))
+				delete(items, id)
+
+				isrc := src
+				isrc.Item = &fi[j]
+				err := r.composed.Render(ctx, cr, cd, t, isrc)
+				if v1.IsRequiredFieldPathNotFound(err) {
+					err = errors.Wrapf(err, errFmtRenderFE, i, fi[j].Key)
+					log.Debug(errRenderCD, "error", err, "index", i)
+					r.record.Event(cr, event.Warning(reasonCompose, err))
+					blocked = append(blocked, blockedStatusOf(cd, t, err))
+					if cd.GetName() != "" {
+						itemRefs = append(itemRefs, *meta.ReferenceTo(cd, cd.GetObjectKind().GroupVersionKind()))
+					}
+					continue
+				}
+				if err != nil {
+					err = errors.Wrapf(err, errFmtRenderFE, i, fi[j].Key)
+					log.Debug(errRenderCD, "error", err, "index", i)
+					r.record.Event(cr, event.Warning(reasonCompose, err))
+					cr.SetConditions(xpv1.ReconcileError(err))
+					return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+				}
+
+				// We annotate the resource last so that its patches cannot
+				// influence which item it is identified as.
+				meta.AddAnnotations(cd, map[string]string{
+					xcrd.AnnotationKeyCompositionResourceName: id.template,
+					xcrd.AnnotationKeyCompositionResourceKey:  id.key,
+				})
+				targets = append(targets, composedTarget{cd: cd, t: t, src: isrc})
+				itemRefs = append(itemRefs, *meta.ReferenceTo(cd, cd.GetObjectKind().GroupVersionKind()))
+			}
+			continue
+		}
+
+		if !ok {
+			// This resource should not be composed. Delete it if we composed
+			// it previously. We keep an empty reference so that references
+			// continue to correspond to the Composition's templates.
+			if _, err := r.composed.DeleteComposed(ctx, cr, refs[i]); err != nil {
+				err = errors.Wrapf(err, errFmtDelete, i)
+				log.Debug(errDeleteCD, "error", err, "index", i)
+				r.record.Event(cr, event.Warning(reasonCompose, err))
+				return reconcile.Result{RequeueAfter: shortWait}, nil
+			}
+			refs[i] = corev1.ObjectReference{}
+			continue
+		}
+
 		cd := composed.New(composed.FromReference(refs[i]))
-		if err := r.composed.Render(ctx, cr, cd, comp.Spec.Resources[i]); err != nil {
+		err = r.composed.Render(ctx, cr, cd, t, src)
+		if v1.IsRequiredFieldPathNotFound(err) {
+			// A patch requires a field path that does not exist yet, for
+			// example because it is populated by the status of another
+			// composed resource. We don't apply this resource until it does,
+			// but we continue to compose the others. Its reference, if any, is
+			// unchanged.
+			err = errors.Wrapf(err, errFmtRender, i)
 			log.Debug(errRenderCD, "error", err, "index", i)
-			r.record.Event(cr, event.Warning(reasonCompose, errors.Wrapf(err, errFmtRender, i)))
-			return reconcile.Result{RequeueAfter: shortWait}, nil
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			blocked = append(blocked, blockedStatusOf(cd, t, err))
+			continue
+		}
+		if err != nil {
+			err = errors.Wrapf(err, errFmtRender, i)
+			log.Debug(errRenderCD, "error", err, "index", i)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+
+			// Rendering may fail because the Composition is invalid for this
+			// composite resource, for example because a patch requires a field
+			// path that does not exist. We surface this as a condition so that
+			// it is visible without inspecting events.
+			cr.SetConditions(xpv1.ReconcileError(err))
+			return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+		}
+
+		// Resources composed from named templates are annotated with the name
+		// of their template, so that they remain associated with it if the
+		// templates of the Composition are reordered.
+		if t.Name != nil {
+			meta.AddAnnotations(cd, map[string]string{xcrd.AnnotationKeyCompositionResourceName: *t.Name})
 		}
 
-		cds[i] = cd
+		targets = append(targets, composedTarget{cd: cd, t: t, src: src})
 		refs[i] = *meta.ReferenceTo(cd, cd.GetObjectKind().GroupVersionKind())
 	}
 
-	cr.SetResourceReferences(refs)
+	// Functions run after all templates are rendered, so that they may
+	// operate on the desired state of every composed resource.
+	desired := make([]*composed.Unstructured, len(targets))
+	for i := range targets {
+		desired[i] = targets[i].cd
+	}
+	if err := r.composed.RenderPipeline(ctx, cr, comp.Spec.Functions, observed, desired); err != nil {
+		err = errors.Wrap(err, errRunPipeline)
+		log.Debug(errRenderCD, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, err))
+		cr.SetConditions(xpv1.ReconcileError(err))
+		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+	}
+
+	// Delete the resources composed for any forEach items that no longer
+	// exist.
+	for id, ref := range items {
+		if _, err := r.composed.DeleteComposed(ctx, cr, ref); err != nil {
+			err = errors.Wrapf(err, errFmtDeleteFE, id.key)
+			log.Debug(errDeleteCD, "error", err)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+	}
+
+	// Delete the resources that correspond to none of the templates, for
+	// example because their template was removed from the Composition. They
+	// are orphaned only if the Composition explicitly asks for it, in which
+	// case their deletion policy is left as is. We stop referencing these
+	// resources once they have been removed.
+	orphan := comp.Spec.RemovedResourcePolicy != nil && *comp.Spec.RemovedResourcePolicy == v1.RemovedResourcePolicyOrphan
+	for _, ref := range associated.unmatched {
+		remove, verb := r.composed.DeleteComposed, "Deleted"
+		if orphan {
+			remove, verb = r.composed.ReleaseComposed, "Orphaned"
+		}
+		removed, err := remove(ctx, cr, ref)
+		if err != nil {
+			err = errors.Wrapf(err, errFmtRemove, ref.Kind, ref.Name)
+			log.Debug(errRemoveCD, "error", err)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		if !removed {
+			// The resource no longer exists, or is controlled by another
+			// resource. Either way it is no longer ours to remove.
+			r.record.Event(cr, event.Warning(reasonCompose, errors.Errorf(errFmtNotRemoved, ref.Kind, ref.Name)))
+			continue
+		}
+		r.record.Event(cr, event.Normal(reasonCompose, fmt.Sprintf("%s composed resource %s %q, which corresponds to no template", verb, ref.Kind, ref.Name)))
+	}
+
+	cr.SetResourceReferences(append(refs, itemRefs...))
 	if err := r.client.Update(ctx, cr); err != nil {
 		log.Debug(errUpdate, "error", err)
 		r.record.Event(cr, event.Warning(reasonCompose, err))
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
 
+	if order != nil {
+		sort.SliceStable(targets, func(i, j int) bool {
+			return order[nameOf(targets[i].t)] < order[nameOf(targets[j].t)]
+		})
+	}
+
+	// A resource is not created until all of the resources composed from the
+	// templates it depends on are ready.
+	exists := map[corev1.ObjectReference]bool{}
+	for _, cd := range observed {
+		if cd != nil {
+			exists[referenceOf(cd)] = true
+		}
+	}
+	notReady := map[string]bool{}
+	for _, cs := range blocked {
+		if cs.Template != "" {
+			notReady[cs.Template] = true
+		}
+	}
+
 	conn := managed.ConnectionDetails{}
-	ready := 0
-	for i, cd := range cds {
-		if err := r.client.Apply(ctx, cd, resource.MustBeControllableBy(cr.GetUID())); err != nil {
+	statuses := make([]composedStatus, 0, len(targets)+len(blocked))
+	unready := make([]composedStatus, 0)
+	for _, tg := range targets {
+		if waiting := waitingFor(tg.t, notReady); len(waiting) > 0 && !exists[referenceOf(tg.cd)] {
+			notReady[nameOf(tg.t)] = true
+			cs := composedStatusOf(tg.cd, tg.t, false)
+			cs.Message = waitingMessage(waiting)
+			statuses = append(statuses, cs)
+			unready = append(unready, cs)
+			continue
+		}
+
+		if err := r.client.Apply(ctx, tg.cd, resource.MustBeControllableBy(cr.GetUID())); err != nil {
 			log.Debug(errApply, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
@@ -413,7 +1198,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		// Connection details are fetched in all cases in a best-effort mode,
 		// i.e. it doesn't return error if the secret does not exist or the
 		// resource does not publish a secret at all.
-		c, err := r.composed.FetchConnectionDetails(ctx, cd, comp.Spec.Resources[i])
+		c, err := r.composed.FetchConnectionDetails(ctx, tg.cd, tg.t)
 		if err != nil {
 			log.Debug(errFetchSecret, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
@@ -424,24 +1209,30 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			conn[key] = val
 		}
 
-		rdy, err := r.composed.IsReady(ctx, cd, comp.Spec.Resources[i])
+		rdy, err := r.composed.IsReady(ctx, tg.cd, tg.t)
 		if err != nil {
 			log.Debug(errReadiness, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
 		}
 
-		if rdy {
-			ready++
+		cs := composedStatusOf(tg.cd, tg.t, rdy)
+		statuses = append(statuses, cs)
+		if !rdy {
+			notReady[nameOf(tg.t)] = true
+			unready = append(unready, cs)
 		}
 
-		if err := r.composite.Render(ctx, cr, cd, comp.Spec.Resources[i]); err != nil {
+		if err := r.composite.Render(ctx, cr, tg.cd, tg.t, tg.src); err != nil {
 			log.Debug(errRenderCR, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
 		}
 	}
 
+	statuses = append(statuses, blocked...)
+	unready = append(unready, blocked...)
+
 	// We pass a deepcopy because the update method doesn't update status,
 	// but calling update resets any pending status changes.
 	updated := cr.DeepCopyObject().(client.Object)
@@ -476,15 +1267,19 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		r.record.Event(cr, event.Normal(reasonPublish, "Successfully published connection details"))
 	}
 
-	// TODO(muvaf):
-	// * Report which resources are not ready.
-	// * If a resource becomes Unavailable at some point, should we still report
-	//   it as Creating?
-	if ready != len(refs) {
-		cr.SetConditions(xpv1.Creating())
+	if err := setComposedStatuses(cr, statuses); err != nil {
+		log.Debug(errSetStatuses, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, err))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	// TODO(muvaf): If a resource becomes Unavailable at some point, should we
+	// still report it as Creating?
+	if len(unready) > 0 {
+		cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Creating().WithMessage(unreadyMessage(unready)))
 		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
 	}
 
-	cr.SetConditions(xpv1.Available())
+	cr.SetConditions(xpv1.ReconcileSuccess(), xpv1.Available())
 	return reconcile.Result{RequeueAfter: longWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
 }
@@ -24,22 +24,55 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
 func TestReconcile(t *testing.T) {
 	errBoom := errors.New("boom")
 	cd := managed.ConnectionDetails{"a": []byte("b")}
+	now := metav1.Now()
+	ref := corev1.ObjectReference{APIVersion: "example.org/v1", Kind: "Bucket", Name: "cool-bucket"}
+
+	// errRequired is returned by a patch that requires a missing field path.
+	required := v1.FromFieldPathPolicyRequired
+	rp := v1.Patch{
+		Type:          v1.PatchTypeFromCompositeFieldPath,
+		FromFieldPath: pointer.StringPtr("spec.missing"),
+		Policy:        &v1.PatchPolicy{FromFieldPath: &required},
+	}
+	errRequired := rp.Apply(composite.New(), composed.New())
+
+	// withDeletionPolicy returns a function that sets the deletion policy of
+	// a composite resource, and optionally marks it as deleted.
+	withDeletionPolicy := func(p xpv1.DeletionPolicy, deleted bool) func(obj client.Object) error {
+		return func(obj client.Object) error {
+			if cp, ok := obj.(*composite.Unstructured); ok {
+				_ = fieldpath.Pave(cp.Object).SetString("spec.deletionPolicy", string(p))
+				cp.SetResourceReferences([]corev1.ObjectReference{ref})
+				if deleted {
+					cp.SetDeletionTimestamp(&now)
+				}
+			}
+			return nil
+		}
+	}
 
 	type args struct {
 		mgr  manager.Manager
@@ -89,43 +122,107 @@ func TestReconcile(t *testing.T) {
 				err: errors.Wrap(errBoom, errGet),
 			},
 		},
-		"SelectCompositionError": {
-			reason: "We should requeue after a short wait if we encounter an error while selecting a composition.",
+		"ReconciliationPaused": {
+			reason: "We should not compose resources, but should report that reconciliation is paused, when the composite resource is annotated as paused.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil),
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								obj.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+								want.SetConditions(v1.ReconcilePaused())
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
 						},
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, _ resource.Composite) error {
-						return errBoom
+						t.Errorf("SelectComposition(...): unexpected call for a paused composite resource")
+						return nil
 					})),
 				},
 			},
 			want: want{
-				r: reconcile.Result{RequeueAfter: shortWait},
+				r: reconcile.Result{},
 			},
 		},
-		"GetCompositionError": {
-			reason: "We should requeue after a short wait if we encounter an error while getting a composition.",
+		"ReconciliationAlreadyPaused": {
+			reason: "We should not update our status when reconciliation of the composite resource is already reported as paused.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if _, ok := obj.(*v1.Composition); ok {
-									return errBoom
+								cr := obj.(*composite.Unstructured)
+								cr.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+								cr.SetConditions(v1.ReconcilePaused())
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								t.Errorf("StatusUpdate(...): unexpected call for an already paused composite resource")
+								return nil
+							}),
+						},
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"DeletePausedResource": {
+			reason: "We should finalize a deleted composite resource even if it is annotated as paused.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if err := withDeletionPolicy(xpv1.DeletionOrphan, true)(obj); err != nil {
+									return err
 								}
+								obj.SetAnnotations(map[string]string{xcrd.AnnotationKeyReconciliationPaused: "true"})
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								t.Errorf("StatusUpdate(...): unexpected call for a deleted composite resource")
 								return nil
 							}),
 						},
 					}),
-					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
-						cr.SetCompositionReference(&corev1.ObjectReference{})
-						return nil
+					WithComposedOrphaner(ComposedOrphanerFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
+						return true, nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{
+						RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil },
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"OrphanComposedOnDeleteError": {
+			reason: "We should requeue after a short wait if we encounter an error while orphaning the composed resources of a deleted composite resource.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, withDeletionPolicy(xpv1.DeletionOrphan, true)),
+						},
+					}),
+					WithComposedOrphaner(ComposedOrphanerFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
+						return false, errBoom
 					})),
 				},
 			},
@@ -133,22 +230,77 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"ConfigureCompositeError": {
-			reason: "We should requeue after a short wait if we encounter an error while configuring the composite resource.",
+		"RemoveFinalizerError": {
+			reason: "We should requeue after a short wait if we encounter an error while removing the finalizer of a deleted composite resource.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil),
+							MockGet: test.NewMockGetFn(nil, withDeletionPolicy(xpv1.DeletionDelete, true)),
 						},
 					}),
-					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
-						cr.SetCompositionReference(&corev1.ObjectReference{})
+					WithComposedOrphaner(ComposedOrphanerFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
+						t.Errorf("OrphanComposed(...): unexpected call when the deletion policy is Delete")
+						return true, nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{
+						RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error { return errBoom },
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"OrphanComposedOnDelete": {
+			reason: "We should orphan the composed resources of a deleted composite resource with the Orphan deletion policy, then remove its finalizer.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, withDeletionPolicy(xpv1.DeletionOrphan, true)),
+						},
+					}),
+					WithComposedOrphaner(ComposedOrphanerFn(func(_ context.Context, _ resource.Composite, got corev1.ObjectReference) (bool, error) {
+						if diff := cmp.Diff(ref, got); diff != "" {
+							t.Errorf("OrphanComposed(...): -want, +got:\n%s", diff)
+						}
+						return true, nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{
+						RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil },
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, _ resource.Composite) error {
+						t.Errorf("SelectComposition(...): unexpected call for a deleted composite resource")
 						return nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return errBoom
+				},
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"FetchRevisionOnDeleteError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching the Composition revision of a composite resource that is being deleted.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if o, ok := obj.(*composite.Unstructured); ok {
+									o.SetDeletionTimestamp(&now)
+									o.SetFinalizers([]string{finalizer})
+									o.SetCompositionReference(&corev1.ObjectReference{Name: "cool-composition"})
+								}
+								return nil
+							}),
+						},
+					}),
+					WithRevisionFetcher(RevisionFetcherFn(func(_ context.Context, _ resource.Composite, _ *v1.Composition) (*v1.Composition, error) {
+						return nil, errBoom
 					})),
 				},
 			},
@@ -156,76 +308,102 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"InlinePatchSetsError": {
-			reason: "We should requeue after a short wait if we encounter an error while inlining patchSets on a composition.",
+		"WaitForComposedDeletion": {
+			reason: "We should delete composed resources in the reverse dependency order of their Composition revision, and requeue after a short wait until they are gone.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{
-										Patches: []v1.Patch{{
-											Type:         v1.PatchTypePatchSet,
-											PatchSetName: pointer.StringPtr("nonexistent-patchset"),
-										}},
-									}}
+								switch o := obj.(type) {
+								case *composite.Unstructured:
+									o.SetDeletionTimestamp(&now)
+									o.SetFinalizers([]string{finalizer})
+									o.SetCompositionReference(&corev1.ObjectReference{Name: "cool-composition"})
+									o.SetResourceReferences([]corev1.ObjectReference{ref, {Kind: "Network", Name: "cool-network"}})
+								case *v1.Composition:
+									// The current Composition orders its
+									// templates differently from the
+									// revision the composite resource uses.
+									o.Spec.Resources = []v1.ComposedTemplate{
+										{Name: pointer.StringPtr("bucket")},
+										{Name: pointer.StringPtr("network"), DependsOn: []string{"bucket"}},
+									}
 								}
 								return nil
 							}),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
-						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
-						}),
 					}),
-					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
-						cr.SetCompositionReference(&corev1.ObjectReference{})
-						return nil
-					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return nil
+					WithRevisionFetcher(RevisionFetcherFn(func(_ context.Context, _ resource.Composite, comp *v1.Composition) (*v1.Composition, error) {
+						rev := comp.DeepCopy()
+						rev.Spec.Resources = []v1.ComposedTemplate{
+							{Name: pointer.StringPtr("bucket"), DependsOn: []string{"network"}},
+							{Name: pointer.StringPtr("network")},
+						}
+						return rev, nil
 					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return cd, nil
+					WithComposedFetcher(ComposedFetcherFn(func(_ context.Context, _ []corev1.ObjectReference) ([]resource.Composed, error) {
+						from := func(template string) resource.Composed {
+							return &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+								Annotations:     map[string]string{xcrd.AnnotationKeyCompositionResourceName: template},
+								OwnerReferences: []metav1.OwnerReference{{Controller: pointer.BoolPtr(true)}},
+							}}
+						}
+						return []resource.Composed{from("bucket"), from("network")}, nil
 					})),
-					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
-						// Our one resource is ready.
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite, got corev1.ObjectReference) (bool, error) {
+						if diff := cmp.Diff(ref, got); diff != "" {
+							t.Errorf("DeleteComposed(...): -want, +got:\n%s", diff)
+						}
 						return true, nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
+					WithCompositeFinalizer(resource.FinalizerFns{
+						RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error {
+							t.Errorf("RemoveFinalizer(...): unexpected call while composed resources exist")
+							return nil
+						},
+					}),
 				},
 			},
 			want: want{
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"RenderComposedError": {
-			reason: "We should requeue after a short wait if we encounter an error while rendering a composed resource.",
+		"AddFinalizerError": {
+			reason: "We should requeue after a short wait if we encounter an error while adding a finalizer to a composite resource with the Orphan deletion policy.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
+							MockGet: test.NewMockGetFn(nil, withDeletionPolicy(xpv1.DeletionOrphan, false)),
 						},
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithCompositeFinalizer(resource.FinalizerFns{
+						AddFinalizerFn: func(_ context.Context, _ resource.Object) error { return errBoom },
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"SelectCompositionError": {
+			reason: "We should requeue after a short wait if we encounter an error while selecting a composition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, _ resource.Composite) error {
 						return errBoom
 					})),
 				},
@@ -234,66 +412,56 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"UpdateCompositeError": {
-			reason: "We should requeue after a short wait if we encounter an error while updating our composite resource with references.",
+		"GetCompositionError": {
+			reason: "We should requeue after a short wait if we encounter an error while getting a composition.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								if _, ok := obj.(*v1.Composition); ok {
+									return errBoom
 								}
 								return nil
 							}),
-							MockUpdate: test.NewMockUpdateFn(errBoom),
 						},
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return nil
-					})),
 				},
 			},
 			want: want{
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"ApplyComposedError": {
-			reason: "We should requeue after a short wait if we encounter an error while applying a composed resource.",
+		"FetchRevisionError": {
+			reason: "We should report a reconcile error and requeue after a short wait if we encounter an error while fetching a composition revision.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+							MockGet: test.NewMockGetFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrap(errBoom, errFetchRev)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
 								}
 								return nil
 							}),
-							MockUpdate: test.NewMockUpdateFn(nil),
 						},
-						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return errBoom
-						}),
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return nil
+					WithRevisionFetcher(RevisionFetcherFn(func(_ context.Context, _ resource.Composite, _ *v1.Composition) (*v1.Composition, error) {
+						return nil, errBoom
 					})),
 				},
 			},
@@ -301,37 +469,22 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"FetchConnectionDetailsError": {
-			reason: "We should requeue after a short wait if we encounter an error while fetching a composed resource's connection details.",
+		"ConfigureCompositeError": {
+			reason: "We should requeue after a short wait if we encounter an error while configuring the composite resource.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
-							MockUpdate: test.NewMockUpdateFn(nil),
+							MockGet: test.NewMockGetFn(nil),
 						},
-						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
-						}),
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
 					})),
 					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return nil
-					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, errBoom
+						return errBoom
 					})),
 				},
 			},
@@ -339,25 +492,15 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"CheckReadinessError": {
-			reason: "We should requeue after a short wait if we encounter an error while checking whether a composed resource is ready.",
+		"FetchComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching composed resources.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
-								}
-								return nil
-							}),
-							MockUpdate:       test.NewMockUpdateFn(nil),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+							MockGet: test.NewMockGetFn(nil),
 						},
-						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
-						}),
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
@@ -366,14 +509,8 @@ func TestReconcile(t *testing.T) {
 					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return nil
-					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, nil
-					})),
-					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
-						return false, errBoom
+					WithComposedFetcher(ComposedFetcherFn(func(_ context.Context, _ []corev1.ObjectReference) ([]resource.Composed, error) {
+						return nil, errBoom
 					})),
 				},
 			},
@@ -381,8 +518,8 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"CompositeRenderError": {
-			reason: "We should requeue after a short wait if we encounter an error while rendering the Composite.",
+		"InlinePatchSetsError": {
+			reason: "We should requeue after a short wait if we encounter an error while inlining patchSets on a composition.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
@@ -390,11 +527,15 @@ func TestReconcile(t *testing.T) {
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
 								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+									comp.Spec.Resources = []v1.ComposedTemplate{{
+										Patches: []v1.Patch{{
+											Type:         v1.PatchTypePatchSet,
+											PatchSetName: pointer.StringPtr("nonexistent-patchset"),
+										}},
+									}}
 								}
 								return nil
 							}),
-							MockUpdate:       test.NewMockUpdateFn(nil),
 							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
@@ -405,10 +546,857 @@ func TestReconcile(t *testing.T) {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return cd, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						// Our one resource is ready.
+						return true, nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"FetchPatchSourcesError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching patch sources.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrap(errBoom, errFetchSources)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithPatchSourceFetcher(PatchSourceFetcherFn(func(_ context.Context, _ []v1.ComposedTemplate, _ []corev1.ObjectReference) (PatchSources, error) {
+						return PatchSources{}, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"FetchEnvironmentError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching the environment.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrap(errBoom, errFetchEnv)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithPatchSourceFetcher(PatchSourceFetcherFn(func(_ context.Context, _ []v1.ComposedTemplate, _ []corev1.ObjectReference) (PatchSources, error) {
+						return PatchSources{}, nil
+					})),
+					WithEnvironmentFetcher(EnvironmentFetcherFn(func(_ context.Context, _ resource.Composite, _ *v1.EnvironmentConfiguration) (map[string]interface{}, error) {
+						return nil, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RenderPipelineError": {
+			reason: "We should requeue after a short wait if we encounter an error while running the function pipeline.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrap(errBoom, errRunPipeline)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithPatchSourceFetcher(PatchSourceFetcherFn(func(_ context.Context, _ []v1.ComposedTemplate, _ []corev1.ObjectReference) (PatchSources, error) {
+						return PatchSources{}, nil
+					})),
+					WithPipelineRenderer(PipelineRendererFn(func(_ context.Context, _ resource.Composite, _ []v1.Function, _ []resource.Composed, _ []*composed.Unstructured) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RenderComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while rendering a composed resource.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrapf(errBoom, errFmtRender, 0)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"EvaluateConditionError": {
+			reason: "We should requeue after a short wait if we encounter an error while evaluating a composed resource's condition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{Condition: pointer.StringPtr("nope")}}
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								err := errors.Wrap(errors.New(`cannot evaluate expression: undefined variable "nope"`), "cannot evaluate condition")
+								want.SetConditions(xpv1.ReconcileError(errors.Wrapf(err, errFmtCondition, 0)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"DeleteComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while deleting a composed resource whose condition is not met.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{Condition: pointer.StringPtr("false")}}
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
+						return false, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"UpdateCompositeError": {
+			reason: "We should requeue after a short wait if we encounter an error while updating our composite resource with references.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(errBoom),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ApplyComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while applying a composed resource.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return errBoom
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"FetchConnectionDetailsError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching a composed resource's connection details.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CheckReadinessError": {
+			reason: "We should requeue after a short wait if we encounter an error while checking whether a composed resource is ready.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate:       test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return false, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeRenderError": {
+			reason: "We should requeue after a short wait if we encounter an error while rendering the Composite.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate:       test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return false, nil
+					})),
+					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeUpdateError": {
+			reason: "We should requeue after a short wait if we encounter an error while updating the Composite.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								// annotation will be set by mock composite render
+								if obj.GetAnnotations()["composite-rendered"] == "true" {
+									return errBoom
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						// use arbitrary annotation to track api-server requests
+						// made after composite render
+						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeUpdateEarlyExit": {
+			reason: "We should early exit to be immediately enqueued if the composite is updated by composed.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								// annotation will be set by mock composite render
+								if obj.GetAnnotations()["composite-rendered"] == "true" {
+									// Set composite resource version to indicate update was not a no-op.
+									obj.SetResourceVersion("1")
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						// use arbitrary annotation to track api-server requests
+						// made after composite render
+						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"PublishConnectionDetailsError": {
+			reason: "We should requeue after a short wait if we encounter an error while publishing connection details.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet:          test.NewMockGetFn(nil),
+							MockUpdate:       test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						return false, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ComposedResourcesNotReady": {
+			reason: "We should requeue after a short wait if any of our composed resources are not yet ready.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate:       test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						// Our one resource is not ready.
+						return false, nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ReportComposedResourceStatus": {
+			reason: "We should report the status of each composed resource, and name any unready resources in our Ready condition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{Name: pointer.StringPtr("bucket")}, {}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(*composite.Unstructured)
+								want := xpv1.Creating().WithMessage("Unready resources: Bucket/cool-bucket")
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want Ready condition, +got Ready condition:\n%s", diff)
+								}
+								wantResources := []interface{}{
+									map[string]interface{}{
+										"apiVersion":   "example.org/v1",
+										"kind":         "Bucket",
+										"name":         "cool-bucket",
+										"template":     "bucket",
+										"ready":        false,
+										"syncedReason": "ReconcileError",
+										"message":      "boom",
+									},
+									map[string]interface{}{
+										"apiVersion":  "example.org/v1",
+										"kind":        "Database",
+										"name":        "cool-database",
+										"ready":       true,
+										"readyReason": "Available",
+									},
+								}
+								if diff := cmp.Diff(wantResources, cr.Object["status"].(map[string]interface{})["resources"]); diff != "" {
+									t.Errorf("StatusUpdate(...): -want resources, +got resources:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						if t.Name != nil {
+							u.SetKind("Bucket")
+							u.SetName("cool-bucket")
+							u.SetConditions(xpv1.ReconcileError(errBoom))
+							return nil
+						}
+						u.SetKind("Database")
+						u.SetName("cool-database")
+						u.SetConditions(xpv1.Available())
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return t.Name == nil, nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"DuplicateTemplateNames": {
+			reason: "We should requeue after a short wait and report an error if more than one template of our Composition has the same name.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{
+										{Name: pointer.StringPtr("bucket")},
+										{Name: pointer.StringPtr("bucket")},
+									}
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrap(errors.Errorf(errFmtDuplicateTemplate, "bucket"), errInvalidComp)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"OrderComposedError": {
+			reason: "We should requeue after a short wait if the templates of our Composition cannot be ordered by their dependencies.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{DependsOn: []string{"network"}}}
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrap(errors.Errorf(errFmtDependsOnUnnamed, 0), errOrderCDs)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{
+						AddFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil },
+					}),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"WaitForDependencies": {
+			reason: "We should not create a composed resource until the resources it depends on are ready.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{
+										{Name: pointer.StringPtr("bucket"), DependsOn: []string{"network"}},
+										{Name: pointer.StringPtr("network")},
+									}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(*composite.Unstructured)
+								want := xpv1.Creating().WithMessage("Unready resources: Network/network, Bucket/bucket")
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want Ready condition, +got Ready condition:\n%s", diff)
+								}
+								wantResources := []interface{}{
+									map[string]interface{}{
+										"apiVersion": "example.org/v1",
+										"kind":       "Network",
+										"name":       "network",
+										"template":   "network",
+										"ready":      false,
+									},
+									map[string]interface{}{
+										"apiVersion": "example.org/v1",
+										"kind":       "Bucket",
+										"name":       "bucket",
+										"template":   "bucket",
+										"ready":      false,
+										"message":    "Waiting for dependencies to be ready: network",
+									},
+								}
+								if diff := cmp.Diff(wantResources, cr.Object["status"].(map[string]interface{})["resources"]); diff != "" {
+									t.Errorf("StatusUpdate(...): -want resources, +got resources:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if r.GetName() != "network" {
+								t.Errorf("Apply(...): unexpected call for %q, whose dependencies are not ready", r.GetName())
+							}
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{
+						AddFinalizerFn: func(_ context.Context, _ resource.Object) error { return nil },
+					}),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind(map[string]string{"bucket": "Bucket", "network": "Network"}[*t.Name])
+						u.SetName(*t.Name)
 						return nil
 					})),
 					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
@@ -417,8 +1405,96 @@ func TestReconcile(t *testing.T) {
 					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
 						return false, nil
 					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return errBoom
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RequiredFieldPathMissing": {
+			reason: "We should not apply a composed resource whose patch requires a missing field path, but should continue to compose the others.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{
+										{Name: pointer.StringPtr("bucket")},
+										{Name: pointer.StringPtr("network")},
+									}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(*composite.Unstructured)
+								want := xpv1.Creating().WithMessage("Unready resources: Bucket/bucket")
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want Ready condition, +got Ready condition:\n%s", diff)
+								}
+								wantResources := []interface{}{
+									map[string]interface{}{
+										"apiVersion": "example.org/v1",
+										"kind":       "Network",
+										"name":       "network",
+										"template":   "network",
+										"ready":      true,
+									},
+									map[string]interface{}{
+										"apiVersion": "example.org/v1",
+										"kind":       "Bucket",
+										"name":       "bucket",
+										"template":   "bucket",
+										"ready":      false,
+										"message":    errors.Wrapf(errRequired, errFmtRender, 0).Error(),
+									},
+								}
+								if diff := cmp.Diff(wantResources, cr.Object["status"].(map[string]interface{})["resources"]); diff != "" {
+									t.Errorf("StatusUpdate(...): -want resources, +got resources:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if r.GetName() != "network" {
+								t.Errorf("Apply(...): unexpected call for %q, whose patch requires a missing field path", r.GetName())
+							}
+							return nil
+						}),
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
+						u := cd.(*composed.Unstructured)
+						u.SetAPIVersion("example.org/v1")
+						u.SetKind(map[string]string{"bucket": "Bucket", "network": "Network"}[*t.Name])
+						u.SetName(*t.Name)
+						if *t.Name == "bucket" {
+							return errRequired
+						}
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
 					})),
 				},
 			},
@@ -426,8 +1502,8 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"CompositeUpdateError": {
-			reason: "We should requeue after a short wait if we encounter an error while updating the Composite.",
+		"ComposedResourcesReady": {
+			reason: "We should requeue after a long wait if all of our composed resources are ready.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
@@ -439,13 +1515,7 @@ func TestReconcile(t *testing.T) {
 								}
 								return nil
 							}),
-							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
-								// annotation will be set by mock composite render
-								if obj.GetAnnotations()["composite-rendered"] == "true" {
-									return errBoom
-								}
-								return nil
-							}),
+							MockUpdate:       test.NewMockUpdateFn(nil),
 							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
@@ -456,32 +1526,34 @@ func TestReconcile(t *testing.T) {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate, _ PatchSources) error {
 						return nil
 					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, nil
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return cd, nil
 					})),
 					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						// Our one resource is ready.
 						return true, nil
 					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						// use arbitrary annotation to track api-server requests
-						// made after composite render
-						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						want := cd
+						if diff := cmp.Diff(want, got); diff != "" {
+							t.Errorf("PublishConnection(...): -want, +got:\n%s", diff)
+						}
+						return true, nil
+					})),
 				},
 			},
 			want: want{
-				r: reconcile.Result{RequeueAfter: shortWait},
+				r: reconcile.Result{RequeueAfter: longWait},
 			},
 		},
-		"CompositeUpdateEarlyExit": {
-			reason: "We should early exit to be immediately enqueued if the composite is updated by composed.",
+		"ConditionNotMet": {
+			reason: "We should not compose, and should delete, resources whose condition is not met.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
@@ -489,75 +1561,113 @@ func TestReconcile(t *testing.T) {
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
 								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+									comp.Spec.Resources = []v1.ComposedTemplate{{Condition: pointer.StringPtr("false")}}
 								}
 								return nil
 							}),
-							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
-								// annotation will be set by mock composite render
-								if obj.GetAnnotations()["composite-rendered"] == "true" {
-									// Set composite resource version to indicate update was not a no-op.
-									obj.SetResourceVersion("1")
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetResourceReferences([]corev1.ObjectReference{{}})
+								want.SetConditions(xpv1.ReconcileSuccess(), xpv1.Available())
+								want.Object["status"].(map[string]interface{})["resources"] = []interface{}{}
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
 								}
 								return nil
 							}),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							t.Errorf("Apply(...): unexpected call for a resource whose condition is not met")
 							return nil
 						}),
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
+						cr.SetResourceReferences([]corev1.ObjectReference{{Name: "cool-resource"}})
 						return nil
 					})),
 					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						return nil
-					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, nil
+					WithComposedFetcher(ComposedFetcherFn(func(_ context.Context, refs []corev1.ObjectReference) ([]resource.Composed, error) {
+						return []resource.Composed{&fake.Composed{}}, nil
 					})),
-					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite, ref corev1.ObjectReference) (bool, error) {
+						if diff := cmp.Diff(corev1.ObjectReference{Name: "cool-resource"}, ref); diff != "" {
+							t.Errorf("DeleteComposed(...): -want, +got:\n%s", diff)
+						}
 						return true, nil
 					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-						// use arbitrary annotation to track api-server requests
-						// made after composite render
-						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: longWait},
+			},
+		},
+		"ForEachError": {
+			reason: "We should requeue after a short wait if we encounter an error while getting the items of a forEach template.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{ForEach: &v1.ForEach{FromFieldPath: "spec.dbs"}}}
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								want := composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind{}))
+								want.SetCompositionReference(&corev1.ObjectReference{})
+								want.SetConditions(xpv1.ReconcileError(errors.Wrapf(errors.New(errForEachName), errFmtForEach, 0)))
+								if diff := cmp.Diff(want, obj, test.EquateConditions()); diff != "" {
+									t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
 				},
 			},
 			want: want{
-				r: reconcile.Result{},
+				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"PublishConnectionDetailsError": {
-			reason: "We should requeue after a short wait if we encounter an error while publishing connection details.",
+		"RemoveComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while removing a resource that corresponds to no template.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet:          test.NewMockGetFn(nil),
-							MockUpdate:       test.NewMockUpdateFn(nil),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+							MockGet: test.NewMockGetFn(nil),
 						},
-						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
-						}),
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
+						cr.SetResourceReferences([]corev1.ObjectReference{{Kind: "Bucket", Name: "removed"}})
 						return nil
 					})),
 					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
-					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+					WithComposedFetcher(ComposedFetcherFn(func(_ context.Context, _ []corev1.ObjectReference) ([]resource.Composed, error) {
+						return []resource.Composed{&fake.Composed{}}, nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
 						return false, errBoom
 					})),
 				},
@@ -566,95 +1676,197 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
-		"ComposedResourcesNotReady": {
-			reason: "We should requeue after a short wait if any of our composed resources are not yet ready.",
+		"DeleteRemovedResource": {
+			reason: "We should delete and stop referencing resources that correspond to no template by default.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
-							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+							MockGet: test.NewMockGetFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								cr, ok := obj.(resource.Composite)
+								if !ok {
+									return nil
+								}
+								if diff := cmp.Diff([]corev1.ObjectReference{}, cr.GetResourceReferences()); diff != "" {
+									t.Errorf("Update(...): -want refs, +got refs:\n%s", diff)
 								}
 								return nil
 							}),
-							MockUpdate:       test.NewMockUpdateFn(nil),
 							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
-						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
-							return nil
-						}),
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
+						cr.SetResourceReferences([]corev1.ObjectReference{{Kind: "Bucket", Name: "removed"}})
 						return nil
 					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
-					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return nil, nil
+					WithComposedFetcher(ComposedFetcherFn(func(_ context.Context, _ []corev1.ObjectReference) ([]resource.Composed, error) {
+						return []resource.Composed{&fake.Composed{}}, nil
 					})),
-					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
-						// Our one resource is not ready.
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite, ref corev1.ObjectReference) (bool, error) {
+						if diff := cmp.Diff(corev1.ObjectReference{Kind: "Bucket", Name: "removed"}, ref); diff != "" {
+							t.Errorf("DeleteComposed(...): -want, +got:\n%s", diff)
+						}
+						return true, nil
+					})),
+					WithComposedReleaser(ComposedReleaserFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
+						t.Errorf("ReleaseComposed(...): unexpected call when the removed resource policy is not Orphan")
+						return true, nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
 						return false, nil
 					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: longWait},
+			},
+		},
+		"OrphanRemovedResource": {
+			reason: "We should orphan and stop referencing resources that correspond to no template when the Composition's removed resource policy is Orphan.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									p := v1.RemovedResourcePolicyOrphan
+									comp.Spec.RemovedResourcePolicy = &p
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								cr, ok := obj.(resource.Composite)
+								if !ok {
+									return nil
+								}
+								if diff := cmp.Diff([]corev1.ObjectReference{}, cr.GetResourceReferences()); diff != "" {
+									t.Errorf("Update(...): -want refs, +got refs:\n%s", diff)
+								}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						cr.SetResourceReferences([]corev1.ObjectReference{{Kind: "Bucket", Name: "removed"}})
+						return nil
+					})),
 					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
-					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+					WithComposedFetcher(ComposedFetcherFn(func(_ context.Context, _ []corev1.ObjectReference) ([]resource.Composed, error) {
+						return []resource.Composed{&fake.Composed{}}, nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
+						t.Errorf("DeleteComposed(...): unexpected call when the removed resource policy is Orphan")
+						return true, nil
+					})),
+					WithComposedOrphaner(ComposedOrphanerFn(func(_ context.Context, _ resource.Composite, _ corev1.ObjectReference) (bool, error) {
+						t.Errorf("OrphanComposed(...): unexpected call for a resource that corresponds to no template")
+						return true, nil
+					})),
+					WithComposedReleaser(ComposedReleaserFn(func(_ context.Context, _ resource.Composite, ref corev1.ObjectReference) (bool, error) {
+						if diff := cmp.Diff(corev1.ObjectReference{Kind: "Bucket", Name: "removed"}, ref); diff != "" {
+							t.Errorf("ReleaseComposed(...): -want, +got:\n%s", diff)
+						}
+						return true, nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
 						return false, nil
 					})),
 				},
 			},
 			want: want{
-				r: reconcile.Result{RequeueAfter: shortWait},
+				r: reconcile.Result{RequeueAfter: longWait},
 			},
 		},
-		"ComposedResourcesReady": {
-			reason: "We should requeue after a long wait if all of our composed resources are ready.",
+		"ForEach": {
+			reason: "We should compose a resource for each forEach item, and delete the resources of items that no longer exist.",
 			args: args{
 				mgr: &fake.Manager{},
 				opts: []ReconcilerOption{
 					WithClientApplicator(resource.ClientApplicator{
 						Client: &test.MockClient{
 							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-								if comp, ok := obj.(*v1.Composition); ok {
-									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								switch o := obj.(type) {
+								case *v1.Composition:
+									o.Spec.Resources = []v1.ComposedTemplate{{
+										Name:    pointer.StringPtr("db"),
+										ForEach: &v1.ForEach{FromFieldPath: "spec.dbs"},
+									}}
+								case *composite.Unstructured:
+									o.Object["spec"] = map[string]interface{}{"dbs": []interface{}{"new"}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+								cr, ok := obj.(resource.Composite)
+								if !ok {
+									return nil
+								}
+								want := []corev1.ObjectReference{{Name: "cool-new"}}
+								if diff := cmp.Diff(want, cr.GetResourceReferences()); diff != "" {
+									t.Errorf("Update(...): -want refs, +got refs:\n%s", diff)
 								}
 								return nil
 							}),
-							MockUpdate:       test.NewMockUpdateFn(nil),
 							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							want := map[string]string{
+								xcrd.AnnotationKeyCompositionResourceName: "db",
+								xcrd.AnnotationKeyCompositionResourceKey:  "new",
+							}
+							if diff := cmp.Diff(want, r.GetAnnotations()); diff != "" {
+								t.Errorf("Apply(...): -want annotations, +got annotations:\n%s", diff)
+							}
 							return nil
 						}),
 					}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
+						cr.SetResourceReferences([]corev1.ObjectReference{{Name: "cool-old"}})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
-					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithComposedFetcher(ComposedFetcherFn(func(_ context.Context, refs []corev1.ObjectReference) ([]resource.Composed, error) {
+						old := &fake.Composed{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+							xcrd.AnnotationKeyCompositionResourceName: "db",
+							xcrd.AnnotationKeyCompositionResourceKey:  "old",
+						}}}
+						return []resource.Composed{old}, nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, _ v1.ComposedTemplate, src PatchSources) error {
+						if diff := cmp.Diff(&ForEachItem{Key: "new", Value: "new"}, src.Item); diff != "" {
+							t.Errorf("Render(...): -want item, +got item:\n%s", diff)
+						}
+						cd.SetName("cool-" + src.Item.Key)
 						return nil
 					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite, ref corev1.ObjectReference) (bool, error) {
+						if diff := cmp.Diff(corev1.ObjectReference{Name: "cool-old"}, ref); diff != "" {
+							t.Errorf("DeleteComposed(...): -want, +got:\n%s", diff)
+						}
+						return true, nil
+					})),
 					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, _ resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
-						return cd, nil
+						return nil, nil
 					})),
 					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
-						// Our one resource is ready.
 						return true, nil
 					})),
-					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
-						return nil
-					})),
 					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, got managed.ConnectionDetails) (published bool, err error) {
-						want := cd
-						if diff := cmp.Diff(want, got); diff != "" {
-							t.Errorf("PublishConnection(...): -want, +got:\n%s", diff)
-						}
-						return true, nil
+						return false, nil
 					})),
 				},
 			},
@@ -678,3 +1890,188 @@ func TestReconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestAssociateRefs(t *testing.T) {
+	bucket := runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`)}
+	named := func(name string) resource.Composed {
+		return &fake.Composed{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			xcrd.AnnotationKeyCompositionResourceName: name,
+		}}}
+	}
+
+	type args struct {
+		ts       []v1.ComposedTemplate
+		refs     []corev1.ObjectReference
+		observed []resource.Composed
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   composedRefs
+	}{
+		"Positional": {
+			reason: "Resources of unnamed templates should be associated by position.",
+			args: args{
+				ts:       []v1.ComposedTemplate{{Base: bucket}},
+				refs:     []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"}},
+				observed: []resource.Composed{&fake.Composed{}},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"}},
+				items:     map[forEachItemID]corev1.ObjectReference{},
+			},
+		},
+		"PositionalNotFound": {
+			reason: "Resources that do not exist should be associated by position so that they are recreated.",
+			args: args{
+				ts:       []v1.ComposedTemplate{{Base: bucket}},
+				refs:     []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"}},
+				observed: []resource.Composed{nil},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"}},
+				items:     map[forEachItemID]corev1.ObjectReference{},
+			},
+		},
+		"PositionalVersionChanged": {
+			reason: "Resources should be associated by position with a template that composes a different version of the same kind of resource.",
+			args: args{
+				ts:       []v1.ComposedTemplate{{Base: bucket}},
+				refs:     []corev1.ObjectReference{{APIVersion: "example.org/v1beta1", Kind: "Bucket", Name: "a"}},
+				observed: []resource.Composed{&fake.Composed{}},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{APIVersion: "example.org/v1beta1", Kind: "Bucket", Name: "a"}},
+				items:     map[forEachItemID]corev1.ObjectReference{},
+			},
+		},
+		"PositionalGroupMismatch": {
+			reason: "Resources should not be associated by position with a template that composes the same kind of resource from a different API group.",
+			args: args{
+				ts:       []v1.ComposedTemplate{{Base: bucket}},
+				refs:     []corev1.ObjectReference{{APIVersion: "example.net/v1", Kind: "Bucket", Name: "a"}},
+				observed: []resource.Composed{&fake.Composed{}},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{}},
+				items:     map[forEachItemID]corev1.ObjectReference{},
+				unmatched: []corev1.ObjectReference{{APIVersion: "example.net/v1", Kind: "Bucket", Name: "a"}},
+			},
+		},
+		"PositionalKindMismatch": {
+			reason: "Resources should not be associated by position with a template that composes a different kind of resource.",
+			args: args{
+				ts:       []v1.ComposedTemplate{{Base: bucket}},
+				refs:     []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Database", Name: "a"}},
+				observed: []resource.Composed{&fake.Composed{}},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{}},
+				items:     map[forEachItemID]corev1.ObjectReference{},
+				unmatched: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Database", Name: "a"}},
+			},
+		},
+		"Named": {
+			reason: "Resources of named templates should be associated by name, regardless of their position.",
+			args: args{
+				ts: []v1.ComposedTemplate{
+					{Name: pointer.StringPtr("a"), Base: bucket},
+					{Name: pointer.StringPtr("b"), Base: bucket},
+				},
+				refs: []corev1.ObjectReference{
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "b"},
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"},
+				},
+				observed: []resource.Composed{named("b"), named("a")},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"},
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "b"},
+				},
+				items: map[forEachItemID]corev1.ObjectReference{},
+			},
+		},
+		"MigrateToNamed": {
+			reason: "Resources composed before their template was named should be associated by position.",
+			args: args{
+				ts: []v1.ComposedTemplate{
+					{Name: pointer.StringPtr("a"), Base: bucket},
+					{Name: pointer.StringPtr("b"), Base: bucket},
+				},
+				refs: []corev1.ObjectReference{
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"},
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "b"},
+				},
+				observed: []resource.Composed{&fake.Composed{}, named("b")},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a"},
+					{APIVersion: "example.org/v1", Kind: "Bucket", Name: "b"},
+				},
+				items: map[forEachItemID]corev1.ObjectReference{},
+			},
+		},
+		"UnknownName": {
+			reason: "Resources whose template no longer exists should be unmatched.",
+			args: args{
+				ts:       []v1.ComposedTemplate{{Name: pointer.StringPtr("a"), Base: bucket}},
+				refs:     []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "gone"}},
+				observed: []resource.Composed{named("gone")},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{}},
+				items:     map[forEachItemID]corev1.ObjectReference{},
+				unmatched: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "gone"}},
+			},
+		},
+		"UnknownForEachTemplate": {
+			reason: "Resources composed for the items of a forEach template that no longer exists should be unmatched.",
+			args: args{
+				ts:   []v1.ComposedTemplate{{Name: pointer.StringPtr("a"), Base: bucket}},
+				refs: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a-cool"}},
+				observed: []resource.Composed{&fake.Composed{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					xcrd.AnnotationKeyCompositionResourceName: "a",
+					xcrd.AnnotationKeyCompositionResourceKey:  "cool",
+				}}}},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{}},
+				items:     map[forEachItemID]corev1.ObjectReference{},
+				unmatched: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a-cool"}},
+			},
+		},
+		"ForEachItem": {
+			reason: "Resources composed for forEach items should be associated by template name and item key.",
+			args: args{
+				ts: []v1.ComposedTemplate{{
+					Name:    pointer.StringPtr("a"),
+					Base:    bucket,
+					ForEach: &v1.ForEach{FromFieldPath: "spec.buckets"},
+				}},
+				refs: []corev1.ObjectReference{{APIVersion: "example.org/v1", Kind: "Bucket", Name: "a-cool"}},
+				observed: []resource.Composed{&fake.Composed{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+					xcrd.AnnotationKeyCompositionResourceName: "a",
+					xcrd.AnnotationKeyCompositionResourceKey:  "cool",
+				}}}},
+			},
+			want: composedRefs{
+				templates: []corev1.ObjectReference{{}},
+				items: map[forEachItemID]corev1.ObjectReference{
+					{template: "a", key: "cool"}: {APIVersion: "example.org/v1", Kind: "Bucket", Name: "a-cool"},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := associateRefs(tc.args.ts, tc.args.refs, tc.args.observed)
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(composedRefs{}, forEachItemID{})); diff != "" {
+				t.Errorf("\n%s\nassociateRefs(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package composition creates composition revisions.
+package composition
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+const (
+	shortWait = 30 * time.Second
+
+	timeout        = 1 * time.Minute
+	maxConcurrency = 5
+)
+
+// Error strings.
+const (
+	errGet          = "cannot get Composition"
+	errListRevs     = "cannot list CompositionRevisions"
+	errCreateRev    = "cannot create CompositionRevision"
+	errUpdateStatus = "cannot update status of Composition"
+)
+
+// Event reasons.
+const (
+	reasonCreateRev event.Reason = "CreateRevision"
+)
+
+// Setup adds a controller that reconciles Compositions by creating a new
+// CompositionRevision for each revision of their spec.
+func Setup(mgr ctrl.Manager, log logging.Logger) error {
+	name := "revisions/" + strings.ToLower(v1.CompositionGroupKind)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1.Composition{}).
+		Owns(&v1.CompositionRevision{}).
+		WithOptions(kcontroller.Options{MaxConcurrentReconciles: maxConcurrency}).
+		Complete(NewReconciler(mgr,
+			WithLogger(log.WithValues("controller", name)),
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+// ReconcilerOption is used to configure the Reconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithLogger specifies how the Reconciler should log messages.
+func WithLogger(log logging.Logger) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.log = log
+	}
+}
+
+// WithRecorder specifies how the Reconciler should record Kubernetes events.
+func WithRecorder(er event.Recorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.record = er
+	}
+}
+
+// WithClient specifies how the Reconciler should interact with the Kubernetes
+// API.
+func WithClient(c client.Client) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.client = c
+	}
+}
+
+// NewReconciler returns a Reconciler of Compositions.
+func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{
+		client: mgr.GetClient(),
+		log:    logging.NewNopLogger(),
+		record: event.NewNopRecorder(),
+	}
+
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
+
+// A Reconciler reconciles Compositions.
+type Reconciler struct {
+	client client.Client
+
+	log    logging.Logger
+	record event.Recorder
+}
+
+// Reconcile a Composition by ensuring a CompositionRevision exists for its
+// current spec, and that it is the latest revision.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("request", req)
+	log.Debug("Reconciling")
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	comp := &v1.Composition{}
+	if err := r.client.Get(ctx, req.NamespacedName, comp); err != nil {
+		log.Debug(errGet, "error", err)
+		return reconcile.Result{}, errors.Wrap(resource.IgnoreNotFound(err), errGet)
+	}
+
+	log = log.WithValues(
+		"uid", comp.GetUID(),
+		"version", comp.GetResourceVersion(),
+		"name", comp.GetName(),
+	)
+
+	// Revisions are garbage collected along with their Composition, so there
+	// is nothing for us to do when it is being deleted.
+	if meta.WasDeleted(comp) {
+		return reconcile.Result{Requeue: false}, nil
+	}
+
+	rl := &v1.CompositionRevisionList{}
+	if err := r.client.List(ctx, rl, client.MatchingLabels{v1.LabelCompositionName: comp.GetName()}); err != nil {
+		log.Debug(errListRevs, "error", err)
+		r.record.Event(comp, event.Warning(reasonCreateRev, errors.Wrap(err, errListRevs)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	hash := comp.Hash()
+	var latest int64
+	var current *v1.CompositionRevision
+	for i := range rl.Items {
+		rev := &rl.Items[i]
+		if !metav1.IsControlledBy(rev, comp) {
+			continue
+		}
+		if rev.Spec.Revision > latest {
+			latest = rev.Spec.Revision
+		}
+		if rev.GetLabels()[v1.LabelCompositionSpecHash] != hash {
+			continue
+		}
+		if current == nil || rev.Spec.Revision > current.Spec.Revision {
+			current = rev
+		}
+	}
+
+	// Revisions are immutable. If the Composition's spec was reverted to that
+	// of an older revision we create a new revision of that spec, rather than
+	// renumbering the older one.
+	if current == nil || current.Spec.Revision != latest {
+		current = v1.NewCompositionRevision(comp, latest+1)
+		if err := r.client.Create(ctx, current); err != nil {
+			log.Debug(errCreateRev, "error", err)
+			r.record.Event(comp, event.Warning(reasonCreateRev, errors.Wrap(err, errCreateRev)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		log.Debug("Created CompositionRevision", "revision", current.Spec.Revision)
+		r.record.Event(comp, event.Normal(reasonCreateRev, "Created CompositionRevision "+current.GetName()))
+	}
+
+	if ref := comp.Status.LatestRevision; ref != nil && ref.Name == current.GetName() {
+		return reconcile.Result{Requeue: false}, nil
+	}
+
+	comp.Status.LatestRevision = &corev1.LocalObjectReference{Name: current.GetName()}
+	return reconcile.Result{Requeue: false}, errors.Wrap(r.client.Status().Update(ctx, comp), errUpdateStatus)
+}
@@ -0,0 +1,315 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	now := metav1.Now()
+
+	comp := &v1.Composition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cool-composition",
+			UID:  types.UID("definitely-a-uuid"),
+		},
+		Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XR"},
+		},
+	}
+
+	// A revision of a different spec.
+	old := comp.DeepCopy()
+	old.Spec.CompositeTypeRef.Kind = "OldXR"
+
+	type args struct {
+		mgr  manager.Manager
+		opts []ReconcilerOption
+	}
+	type want struct {
+		r   reconcile.Result
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"CompositionNotFound": {
+			reason: "We should not return an error if the Composition was not found.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"GetCompositionError": {
+			reason: "We should return any other error encountered while getting a Composition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r:   reconcile.Result{},
+				err: errors.Wrap(errBoom, errGet),
+			},
+		},
+		"CompositionDeleted": {
+			reason: "We should return without requeueing if the Composition is being deleted.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+							obj.SetDeletionTimestamp(&now)
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"ListRevisionsError": {
+			reason: "We should requeue after a short wait if we encounter an error listing CompositionRevisions.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet:  test.NewMockGetFn(nil),
+						MockList: test.NewMockListFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CreateRevisionError": {
+			reason: "We should requeue after a short wait if we encounter an error creating a CompositionRevision.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet:    test.NewMockGetFn(nil),
+						MockList:   test.NewMockListFn(nil),
+						MockCreate: test.NewMockCreateFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CreateRevision": {
+			reason: "We should create a new CompositionRevision when no revision matches the Composition's spec.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+							comp.DeepCopyInto(obj.(*v1.Composition))
+							return nil
+						}),
+						MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+							obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{*v1.NewCompositionRevision(old, 1)}
+							return nil
+						}),
+						MockCreate: test.NewMockCreateFn(nil, func(obj client.Object) error {
+							want := v1.NewCompositionRevision(comp, 2)
+							if diff := cmp.Diff(want, obj); diff != "" {
+								t.Errorf("Create(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+						MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+							want := &corev1.LocalObjectReference{Name: v1.NewCompositionRevision(comp, 2).GetName()}
+							if diff := cmp.Diff(want, obj.(*v1.Composition).Status.LatestRevision); diff != "" {
+								t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"RevertToRevision": {
+			reason: "We should create a new CompositionRevision, rather than update the existing one, when the Composition's spec is reverted to that of an older revision.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+							comp.DeepCopyInto(obj.(*v1.Composition))
+							return nil
+						}),
+						MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+							obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{
+								*v1.NewCompositionRevision(comp, 1),
+								*v1.NewCompositionRevision(old, 2),
+							}
+							return nil
+						}),
+						MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+							t.Errorf("Update(...): unexpected call to update an immutable CompositionRevision")
+							return nil
+						}),
+						MockCreate: test.NewMockCreateFn(nil, func(obj client.Object) error {
+							want := v1.NewCompositionRevision(comp, 3)
+							if diff := cmp.Diff(want, obj); diff != "" {
+								t.Errorf("Create(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+						MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+							want := &corev1.LocalObjectReference{Name: v1.NewCompositionRevision(comp, 3).GetName()}
+							if diff := cmp.Diff(want, obj.(*v1.Composition).Status.LatestRevision); diff != "" {
+								t.Errorf("StatusUpdate(...): -want, +got:\n%s", diff)
+							}
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"UpdateStatusError": {
+			reason: "We should return any error encountered while updating the Composition's status.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+							comp.DeepCopyInto(obj.(*v1.Composition))
+							return nil
+						}),
+						MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+							obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{*v1.NewCompositionRevision(comp, 1)}
+							return nil
+						}),
+						MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+					}),
+				},
+			},
+			want: want{
+				r:   reconcile.Result{Requeue: false},
+				err: errors.Wrap(errBoom, errUpdateStatus),
+			},
+		},
+		"UpToDate": {
+			reason: "We should do nothing if the latest CompositionRevision matches the Composition's spec.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+							comp.DeepCopyInto(obj.(*v1.Composition))
+							obj.(*v1.Composition).Status.LatestRevision = &corev1.LocalObjectReference{
+								Name: v1.NewCompositionRevision(comp, 1).GetName(),
+							}
+							return nil
+						}),
+						MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+							obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{*v1.NewCompositionRevision(comp, 1)}
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"RevertedUpToDate": {
+			reason: "We should do nothing if the latest of several CompositionRevisions that match the Composition's spec is the latest revision.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClient(&test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+							comp.DeepCopyInto(obj.(*v1.Composition))
+							obj.(*v1.Composition).Status.LatestRevision = &corev1.LocalObjectReference{
+								Name: v1.NewCompositionRevision(comp, 3).GetName(),
+							}
+							return nil
+						}),
+						MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+							obj.(*v1.CompositionRevisionList).Items = []v1.CompositionRevision{
+								*v1.NewCompositionRevision(comp, 1),
+								*v1.NewCompositionRevision(old, 2),
+								*v1.NewCompositionRevision(comp, 3),
+							}
+							return nil
+						}),
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReconciler(tc.args.mgr, tc.args.opts...)
+			got, err := r.Reconcile(context.Background(), reconcile.Request{})
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.r, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	kmeta "k8s.io/apimachinery/pkg/api/meta"
@@ -33,6 +34,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/crossplane/crossplane-runtime/pkg/controller"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -65,6 +67,7 @@ const (
 	errDeleteCRD       = "cannot delete composite resource CustomResourceDefinition"
 	errListCRs         = "cannot list defined composite resources"
 	errDeleteCRs       = "cannot delete defined composite resources"
+	errFetchComposed   = "cannot fetch composed resource types"
 )
 
 // Wait strings.
@@ -105,18 +108,21 @@ func (fn CRDRenderFn) Render(d *v1.CompositeResourceDefinition) (*extv1.CustomRe
 }
 
 // Setup adds a controller that reconciles CompositeResourceDefinitions by
-// defining a composite resource and starting a controller to reconcile it.
-func Setup(mgr ctrl.Manager, log logging.Logger) error {
+// defining a composite resource and starting a controller to reconcile it. The
+// supplied options configure the reconcilers of composite resources.
+func Setup(mgr ctrl.Manager, log logging.Logger, xo ...composite.ReconcilerOption) error {
 	name := "defined/" + strings.ToLower(v1.CompositeResourceDefinitionGroupKind)
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		For(&v1.CompositeResourceDefinition{}).
 		Owns(&extv1.CustomResourceDefinition{}).
+		Watches(&source.Kind{Type: &v1.Composition{}}, &EnqueueRequestForDefinitions{client: mgr.GetClient()}).
 		WithOptions(kcontroller.Options{MaxConcurrentReconciles: maxConcurrency}).
 		Complete(NewReconciler(mgr,
 			WithLogger(log.WithValues("controller", name)),
-			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name))),
+			WithCompositeReconcilerOptions(xo...)))
 }
 
 // ReconcilerOption is used to configure the Reconciler.
@@ -168,9 +174,26 @@ func WithClientApplicator(ca resource.ClientApplicator) ReconcilerOption {
 	}
 }
 
+// WithComposedTypeFetcher specifies how the Reconciler should fetch the types
+// of composed resource that its composite resource controllers should watch.
+func WithComposedTypeFetcher(f ComposedTypeFetcher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.ComposedTypeFetcher = f
+	}
+}
+
+// WithCompositeReconcilerOptions specifies additional options with which the
+// Reconciler should configure the reconcilers of composite resources.
+func WithCompositeReconcilerOptions(o ...composite.ReconcilerOption) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.xo = o
+	}
+}
+
 type definition struct {
 	CRDRenderer
 	ControllerEngine
+	ComposedTypeFetcher
 	resource.Finalizer
 }
 
@@ -187,9 +210,10 @@ func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
 		},
 
 		composite: definition{
-			CRDRenderer:      CRDRenderFn(xcrd.ForCompositeResource),
-			ControllerEngine: controller.NewEngine(mgr),
-			Finalizer:        resource.NewAPIFinalizer(kube, finalizer),
+			CRDRenderer:         CRDRenderFn(xcrd.ForCompositeResource),
+			ControllerEngine:    controller.NewEngine(mgr),
+			ComposedTypeFetcher: NewAPIComposedTypeFetcher(kube, mgr.GetRESTMapper()),
+			Finalizer:           resource.NewAPIFinalizer(kube, finalizer),
 		},
 
 		log:    logging.NewNopLogger(),
@@ -208,6 +232,7 @@ type Reconciler struct {
 	mgr    manager.Manager
 
 	composite definition
+	xo        []composite.ReconcilerOption
 
 	log    logging.Logger
 	record event.Recorder
@@ -361,6 +386,22 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		log.Debug("Composite resource controller encountered an error", "error", err)
 	}
 
+	composed, unknown, err := r.composite.FetchComposedTypes(ctx, d)
+	if err != nil {
+		log.Debug(errFetchComposed, "error", err)
+		r.record.Event(d, event.Warning(reasonEstablishXR, errors.Wrap(err, errFetchComposed)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	// A controller's watches can't be changed once it has started, so we
+	// restart it when the types of resource its composite resources may
+	// compose change.
+	if !equalTypeRefs(d.Status.Controllers.ComposedResourceTypeRefs, composed) && r.composite.IsRunning(composite.ControllerName(d.GetName())) {
+		r.composite.Stop(composite.ControllerName(d.GetName()))
+		log.Debug("Composed resource types changed; stopped composite resource controller")
+		r.record.Event(d, event.Normal(reasonEstablishXR, "Composed resource types changed; stopped composite resource controller"))
+	}
+
 	observed := d.Status.Controllers.CompositeResourceTypeRef
 	desired := v1.TypeReferenceTo(d.GetCompositeGroupVersionKind())
 	if observed.APIVersion != "" && observed != desired {
@@ -374,29 +415,62 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	}
 
 	recorder := r.record.WithAnnotations("controller", composite.ControllerName(d.GetName()))
-	o := kcontroller.Options{Reconciler: composite.NewReconciler(r.mgr,
-		resource.CompositeKind(d.GetCompositeGroupVersionKind()),
+	xo := append([]composite.ReconcilerOption{
 		composite.WithConnectionPublisher(composite.NewAPIFilteredSecretPublisher(r.client, d.GetConnectionSecretKeys())),
 		composite.WithCompositionSelector(composite.NewCompositionSelectorChain(
 			composite.NewEnforcedCompositionSelector(*d, recorder),
 			composite.NewAPIDefaultCompositionSelector(r.client, *meta.ReferenceTo(d, v1.CompositeResourceDefinitionGroupVersionKind), recorder),
-			composite.NewAPILabelSelectorResolver(r.client),
+			composite.NewAPILabelSelectorResolver(r.client, recorder),
 		)),
 		composite.WithLogger(log.WithValues("controller", composite.ControllerName(d.GetName()))),
 		composite.WithRecorder(recorder),
-	)}
+	}, r.xo...)
+	o := kcontroller.Options{Reconciler: composite.NewReconciler(r.mgr, resource.CompositeKind(d.GetCompositeGroupVersionKind()), xo...)}
 
 	u := &kunstructured.Unstructured{}
 	u.SetGroupVersionKind(d.GetCompositeGroupVersionKind())
 
-	if err := r.composite.Start(composite.ControllerName(d.GetName()), o, controller.For(u, &handler.EnqueueRequestForObject{})); err != nil {
+	// Composed resources and connection Secrets are watched so that changes
+	// to them (e.g. becoming ready) are reflected by their composite resource
+	// promptly, rather than the next time it is polled. Secrets are mapped to
+	// their composite resource via the composed resources that write them.
+	idx := NewConnectionSecretIndex(d.GetCompositeGroupVersionKind())
+	w := []controller.Watch{controller.For(u, &handler.EnqueueRequestForObject{})}
+	for _, ref := range composed {
+		cd := &kunstructured.Unstructured{}
+		cd.SetAPIVersion(ref.APIVersion)
+		cd.SetKind(ref.Kind)
+		w = append(w,
+			controller.For(cd, &handler.EnqueueRequestForOwner{OwnerType: u, IsController: true}),
+			controller.For(cd, idx),
+		)
+	}
+	if len(composed) > 0 {
+		w = append(w, controller.For(&corev1.Secret{}, NewEnqueueRequestForCompositeOfSecret(d.GetCompositeGroupVersionKind(), idx)))
+	}
+
+	if err := r.composite.Start(composite.ControllerName(d.GetName()), o, w...); err != nil {
 		log.Debug(errStartController, "error", err)
 		r.record.Event(d, event.Warning(reasonEstablishXR, errors.Wrap(err, errStartController)))
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
 
 	d.Status.Controllers.CompositeResourceTypeRef = v1.TypeReferenceTo(d.GetCompositeGroupVersionKind())
+	d.Status.Controllers.ComposedResourceTypeRefs = composed
 	d.Status.SetConditions(v1.WatchingComposite())
 	r.record.Event(d, event.Normal(reasonEstablishXR, "(Re)started composite resource controller"))
+
+	// Composed resources of types the API server does not yet serve can't be
+	// watched. We check again after a short wait, and restart the controller
+	// once they are served.
+	if len(unknown) > 0 {
+		names := make([]string, len(unknown))
+		for i, ref := range unknown {
+			names[i] = ref.APIVersion + ", Kind=" + ref.Kind
+		}
+		log.Debug("Cannot watch composed resource types that are not served", "types", names, "requeue-after", time.Now().Add(shortWait))
+		r.record.Event(d, event.Normal(reasonEstablishXR, "Cannot yet watch composed resource types that are not served", "types", strings.Join(names, "; ")))
+		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, d), errUpdateStatus)
+	}
 	return reconcile.Result{Requeue: false}, errors.Wrap(r.client.Status().Update(ctx, d), errUpdateStatus)
 }
@@ -42,9 +42,14 @@ import (
 
 type MockEngine struct {
 	ControllerEngine
-	MockStart func(name string, o kcontroller.Options, w ...controller.Watch) error
-	MockStop  func(name string)
-	MockErr   func(name string) error
+	MockIsRunning func(name string) bool
+	MockStart     func(name string, o kcontroller.Options, w ...controller.Watch) error
+	MockStop      func(name string)
+	MockErr       func(name string) error
+}
+
+func (m *MockEngine) IsRunning(name string) bool {
+	return m.MockIsRunning(name)
 }
 
 func (m *MockEngine) Start(name string, o kcontroller.Options, w ...controller.Watch) error {
@@ -539,6 +544,9 @@ func TestReconcile(t *testing.T) {
 					WithFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
 						return nil
 					}}),
+					WithComposedTypeFetcher(ComposedTypeFetcherFn(func(_ context.Context, _ *v1.CompositeResourceDefinition) ([]v1.TypeReference, []v1.TypeReference, error) {
+						return nil, nil, nil
+					})),
 					WithControllerEngine(&MockEngine{
 						MockErr:   func(_ string) error { return nil },
 						MockStart: func(_ string, _ kcontroller.Options, _ ...controller.Watch) error { return errBoom },
@@ -583,6 +591,9 @@ func TestReconcile(t *testing.T) {
 					WithFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
 						return nil
 					}}),
+					WithComposedTypeFetcher(ComposedTypeFetcherFn(func(_ context.Context, _ *v1.CompositeResourceDefinition) ([]v1.TypeReference, []v1.TypeReference, error) {
+						return nil, nil, nil
+					})),
 					WithControllerEngine(&MockEngine{
 						MockErr:   func(name string) error { return errBoom }, // This error should only be logged.
 						MockStart: func(_ string, _ kcontroller.Options, _ ...controller.Watch) error { return nil }},
@@ -640,6 +651,9 @@ func TestReconcile(t *testing.T) {
 					WithFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
 						return nil
 					}}),
+					WithComposedTypeFetcher(ComposedTypeFetcherFn(func(_ context.Context, _ *v1.CompositeResourceDefinition) ([]v1.TypeReference, []v1.TypeReference, error) {
+						return nil, nil, nil
+					})),
 					WithControllerEngine(&MockEngine{
 						MockErr:   func(name string) error { return nil },
 						MockStart: func(_ string, _ kcontroller.Options, _ ...controller.Watch) error { return nil },
@@ -651,6 +665,169 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{Requeue: false},
 			},
 		},
+		"FetchComposedTypesError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching the types of composed resource to watch.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+						},
+						Applicator: resource.ApplyFn(func(_ context.Context, _ client.Object, _ ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCRDRenderer(CRDRenderFn(func(_ *v1.CompositeResourceDefinition) (*extv1.CustomResourceDefinition, error) {
+						return &extv1.CustomResourceDefinition{
+							Status: extv1.CustomResourceDefinitionStatus{
+								Conditions: []extv1.CustomResourceDefinitionCondition{
+									{Type: extv1.Established, Status: extv1.ConditionTrue},
+								},
+							},
+						}, nil
+					})),
+					WithFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+					WithComposedTypeFetcher(ComposedTypeFetcherFn(func(_ context.Context, _ *v1.CompositeResourceDefinition) ([]v1.TypeReference, []v1.TypeReference, error) {
+						return nil, nil, errBoom
+					})),
+					WithControllerEngine(&MockEngine{
+						MockErr: func(_ string) error { return nil },
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"SuccessfulRestartComposedTypesChanged": {
+			reason: "We should stop and restart the controller with watches for each composed resource type and connection Secrets when the composed resource types change.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								d := obj.(*v1.CompositeResourceDefinition)
+								d.Status.Controllers.ComposedResourceTypeRefs = []v1.TypeReference{{APIVersion: "example.org/v1", Kind: "Old"}}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(o client.Object) error {
+								want := &v1.CompositeResourceDefinition{}
+								want.Status.Controllers.ComposedResourceTypeRefs = []v1.TypeReference{{APIVersion: "example.org/v1", Kind: "New"}}
+								want.Status.SetConditions(v1.WatchingComposite())
+
+								if diff := cmp.Diff(want, o); diff != "" {
+									t.Errorf("-want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(_ context.Context, _ client.Object, _ ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCRDRenderer(CRDRenderFn(func(_ *v1.CompositeResourceDefinition) (*extv1.CustomResourceDefinition, error) {
+						return &extv1.CustomResourceDefinition{
+							Status: extv1.CustomResourceDefinitionStatus{
+								Conditions: []extv1.CustomResourceDefinitionCondition{
+									{Type: extv1.Established, Status: extv1.ConditionTrue},
+								},
+							},
+						}, nil
+					})),
+					WithFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+					WithComposedTypeFetcher(ComposedTypeFetcherFn(func(_ context.Context, _ *v1.CompositeResourceDefinition) ([]v1.TypeReference, []v1.TypeReference, error) {
+						return []v1.TypeReference{{APIVersion: "example.org/v1", Kind: "New"}}, nil, nil
+					})),
+					WithControllerEngine(&MockEngine{
+						MockErr:       func(_ string) error { return nil },
+						MockIsRunning: func(_ string) bool { return true },
+						MockStop:      func(_ string) {},
+						MockStart: func(_ string, _ kcontroller.Options, w ...controller.Watch) error {
+							// We expect to watch the composite resource, the
+							// composed resource (to enqueue its composite
+							// resource and to index its connection Secret),
+							// and connection Secrets.
+							if len(w) != 4 {
+								t.Errorf("Start(...): want 4 watches, got %d", len(w))
+							}
+							return nil
+						},
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
+		"RequeueUnknownComposedTypes": {
+			reason: "We should start the controller with watches for the known composed resource types, and requeue after a short wait if any are unknown.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								d := obj.(*v1.CompositeResourceDefinition)
+								d.Status.Controllers.ComposedResourceTypeRefs = []v1.TypeReference{{APIVersion: "example.org/v1", Kind: "Old"}}
+								return nil
+							}),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(o client.Object) error {
+								want := &v1.CompositeResourceDefinition{}
+								want.Status.Controllers.ComposedResourceTypeRefs = []v1.TypeReference{{APIVersion: "example.org/v1", Kind: "New"}}
+								want.Status.SetConditions(v1.WatchingComposite())
+
+								if diff := cmp.Diff(want, o); diff != "" {
+									t.Errorf("-want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(_ context.Context, _ client.Object, _ ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCRDRenderer(CRDRenderFn(func(_ *v1.CompositeResourceDefinition) (*extv1.CustomResourceDefinition, error) {
+						return &extv1.CustomResourceDefinition{
+							Status: extv1.CustomResourceDefinitionStatus{
+								Conditions: []extv1.CustomResourceDefinitionCondition{
+									{Type: extv1.Established, Status: extv1.ConditionTrue},
+								},
+							},
+						}, nil
+					})),
+					WithFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+					WithComposedTypeFetcher(ComposedTypeFetcherFn(func(_ context.Context, _ *v1.CompositeResourceDefinition) ([]v1.TypeReference, []v1.TypeReference, error) {
+						return []v1.TypeReference{{APIVersion: "example.org/v1", Kind: "New"}}, []v1.TypeReference{{APIVersion: "example.org/v1", Kind: "Unknown"}}, nil
+					})),
+					WithControllerEngine(&MockEngine{
+						MockErr:       func(_ string) error { return nil },
+						MockIsRunning: func(_ string) bool { return true },
+						MockStop:      func(_ string) {},
+						MockStart: func(_ string, _ kcontroller.Options, w ...controller.Watch) error {
+							// We expect to watch the composite resource, the
+							// composed resource (to enqueue its composite
+							// resource and to index its connection Secret),
+							// and connection Secrets.
+							if len(w) != 4 {
+								t.Errorf("Start(...): want 4 watches, got %d", len(w))
+							}
+							return nil
+						},
+					}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
 	}
 
 	for name, tc := range cases {
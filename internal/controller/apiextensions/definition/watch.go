@@ -0,0 +1,369 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package definition
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+const (
+	errListCompositions = "cannot list Compositions"
+	errListRevisions    = "cannot list CompositionRevisions"
+)
+
+type adder interface {
+	Add(item interface{})
+}
+
+// A ComposedTypeFetcher fetches the types of resource that may be composed by
+// the composite resources a CompositeResourceDefinition defines. It returns the
+// types that are known to the API server, and those that are not.
+type ComposedTypeFetcher interface {
+	FetchComposedTypes(ctx context.Context, d *v1.CompositeResourceDefinition) (known, unknown []v1.TypeReference, err error)
+}
+
+// A ComposedTypeFetcherFn fetches the types of resource that may be composed by
+// the composite resources a CompositeResourceDefinition defines.
+type ComposedTypeFetcherFn func(ctx context.Context, d *v1.CompositeResourceDefinition) (known, unknown []v1.TypeReference, err error)
+
+// FetchComposedTypes fetches the types of resource that may be composed by the
+// composite resources the supplied CompositeResourceDefinition defines.
+func (fn ComposedTypeFetcherFn) FetchComposedTypes(ctx context.Context, d *v1.CompositeResourceDefinition) (known, unknown []v1.TypeReference, err error) {
+	return fn(ctx, d)
+}
+
+// An APIComposedTypeFetcher fetches composed resource types from the templates
+// of the Compositions and CompositionRevisions that are compatible with a
+// CompositeResourceDefinition.
+type APIComposedTypeFetcher struct {
+	client client.Reader
+	mapper kmeta.RESTMapper
+}
+
+// NewAPIComposedTypeFetcher returns a ComposedTypeFetcher that fetches composed
+// resource types from the templates of compatible Compositions and
+// CompositionRevisions. Types are known if the supplied RESTMapper knows them.
+func NewAPIComposedTypeFetcher(c client.Reader, m kmeta.RESTMapper) *APIComposedTypeFetcher {
+	return &APIComposedTypeFetcher{client: c, mapper: m}
+}
+
+// FetchComposedTypes returns the sorted, de-duplicated types of resource that
+// are composed by the Compositions and CompositionRevisions that are compatible
+// with the supplied CompositeResourceDefinition. Revisions are included because
+// composite resources may be pinned to a revision that composes types their
+// Composition no longer does.
+func (f *APIComposedTypeFetcher) FetchComposedTypes(ctx context.Context, d *v1.CompositeResourceDefinition) (known, unknown []v1.TypeReference, err error) {
+	cl := &v1.CompositionList{}
+	if err := f.client.List(ctx, cl); err != nil {
+		return nil, nil, errors.Wrap(err, errListCompositions)
+	}
+	rl := &v1.CompositionRevisionList{}
+	if err := f.client.List(ctx, rl); err != nil {
+		return nil, nil, errors.Wrap(err, errListRevisions)
+	}
+
+	specs := make([]v1.CompositionSpec, 0, len(cl.Items)+len(rl.Items))
+	for _, comp := range cl.Items {
+		specs = append(specs, comp.Spec)
+	}
+	for _, rev := range rl.Items {
+		specs = append(specs, rev.Spec.CompositionSpec)
+	}
+
+	xr := d.GetCompositeGroupVersionKind().GroupKind()
+	seen := map[v1.TypeReference]bool{}
+	for _, cs := range specs {
+		if groupKindOf(cs.CompositeTypeRef) != xr {
+			continue
+		}
+		for _, t := range cs.Resources {
+			ref := v1.TypeReference{}
+			// Templates with malformed bases are reported by the composite
+			// resource controller when it renders them.
+			if err := json.Unmarshal(t.Base.Raw, &ref); err != nil || ref.APIVersion == "" || ref.Kind == "" {
+				continue
+			}
+			seen[ref] = true
+		}
+	}
+
+	known = make([]v1.TypeReference, 0, len(seen))
+	unknown = make([]v1.TypeReference, 0)
+	for ref := range seen {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			continue
+		}
+		// The composite resource controller's cache would never sync if it
+		// watched a type that the API server does not serve, for example
+		// because the provider that defines it is not yet installed.
+		if _, err := f.mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: ref.Kind}, gv.Version); err != nil {
+			unknown = append(unknown, ref)
+			continue
+		}
+		known = append(known, ref)
+	}
+
+	sortTypeRefs(known)
+	sortTypeRefs(unknown)
+	return known, unknown, nil
+}
+
+func sortTypeRefs(refs []v1.TypeReference) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].APIVersion != refs[j].APIVersion {
+			return refs[i].APIVersion < refs[j].APIVersion
+		}
+		return refs[i].Kind < refs[j].Kind
+	})
+}
+
+func groupKindOf(ref v1.TypeReference) schema.GroupKind {
+	gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+	return schema.GroupKind{Group: gv.Group, Kind: ref.Kind}
+}
+
+func equalTypeRefs(a, b []v1.TypeReference) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// EnqueueRequestForDefinitions enqueues a request for each
+// CompositeResourceDefinition that defines the type of composite resource a
+// Composition is compatible with when that Composition changes.
+type EnqueueRequestForDefinitions struct {
+	client client.Reader
+}
+
+// Create enqueues a request for each CompositeResourceDefinition that is
+// compatible with the Composition.
+func (e *EnqueueRequestForDefinitions) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Update enqueues a request for each CompositeResourceDefinition that is
+// compatible with the Composition.
+func (e *EnqueueRequestForDefinitions) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.ObjectOld, q)
+	e.add(evt.ObjectNew, q)
+}
+
+// Delete enqueues a request for each CompositeResourceDefinition that is
+// compatible with the Composition.
+func (e *EnqueueRequestForDefinitions) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Generic enqueues a request for each CompositeResourceDefinition that is
+// compatible with the Composition.
+func (e *EnqueueRequestForDefinitions) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+func (e *EnqueueRequestForDefinitions) add(obj runtime.Object, queue adder) {
+	comp, ok := obj.(*v1.Composition)
+	if !ok {
+		return
+	}
+
+	l := &v1.CompositeResourceDefinitionList{}
+	if err := e.client.List(context.TODO(), l); err != nil {
+		// The CompositeResourceDefinitions will eventually be requeued, at
+		// which point they'll pick up any changes to the Composition.
+		return
+	}
+
+	gk := groupKindOf(comp.Spec.CompositeTypeRef)
+	for _, d := range l.Items {
+		if d.GetCompositeGroupVersionKind().GroupKind() != gk {
+			continue
+		}
+		queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: d.GetName()}})
+	}
+}
+
+// A ConnectionSecretIndex records the connection Secret that each composed
+// resource writes to, and the composite resource that controls it. It is
+// populated from the events of watched composed resources, so that a Secret
+// may be mapped to its composite resource without reading from an API
+// server.
+type ConnectionSecretIndex struct {
+	of schema.GroupKind
+
+	mx       sync.RWMutex
+	secrets  map[types.UID]types.NamespacedName
+	composed map[types.NamespacedName]types.NamespacedName
+}
+
+// NewConnectionSecretIndex returns an index of the connection Secrets written
+// by the composed resources of composite resources of the supplied kind. It is
+// also an EventHandler that indexes the composed resources it is sent events
+// for; it never enqueues requests.
+func NewConnectionSecretIndex(of schema.GroupVersionKind) *ConnectionSecretIndex {
+	return &ConnectionSecretIndex{
+		of:       of.GroupKind(),
+		secrets:  make(map[types.UID]types.NamespacedName),
+		composed: make(map[types.NamespacedName]types.NamespacedName),
+	}
+}
+
+// Create indexes the connection Secret of the composed resource.
+func (i *ConnectionSecretIndex) Create(evt event.CreateEvent, _ workqueue.RateLimitingInterface) {
+	i.index(evt.Object)
+}
+
+// Update indexes the connection Secret of the composed resource.
+func (i *ConnectionSecretIndex) Update(evt event.UpdateEvent, _ workqueue.RateLimitingInterface) {
+	i.index(evt.ObjectNew)
+}
+
+// Delete removes the composed resource from the index.
+func (i *ConnectionSecretIndex) Delete(evt event.DeleteEvent, _ workqueue.RateLimitingInterface) {
+	if evt.Object == nil {
+		return
+	}
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	i.remove(evt.Object.GetUID())
+}
+
+// Generic indexes the connection Secret of the composed resource.
+func (i *ConnectionSecretIndex) Generic(evt event.GenericEvent, _ workqueue.RateLimitingInterface) {
+	i.index(evt.Object)
+}
+
+// CompositeOf returns the composite resource whose composed resource writes
+// the supplied connection Secret, if any.
+func (i *ConnectionSecretIndex) CompositeOf(secret types.NamespacedName) (types.NamespacedName, bool) {
+	i.mx.RLock()
+	defer i.mx.RUnlock()
+	xr, ok := i.composed[secret]
+	return xr, ok
+}
+
+func (i *ConnectionSecretIndex) index(obj client.Object) {
+	u, ok := obj.(*kunstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	i.remove(u.GetUID())
+
+	xr := metav1.GetControllerOf(u)
+	if xr == nil || groupKindOf(v1.TypeReference{APIVersion: xr.APIVersion, Kind: xr.Kind}) != i.of {
+		return
+	}
+	name, _, _ := kunstructured.NestedString(u.Object, "spec", "writeConnectionSecretToRef", "name")
+	if name == "" {
+		return
+	}
+	namespace, _, _ := kunstructured.NestedString(u.Object, "spec", "writeConnectionSecretToRef", "namespace")
+
+	s := types.NamespacedName{Namespace: namespace, Name: name}
+	i.secrets[u.GetUID()] = s
+	// Composite resources are cluster scoped.
+	i.composed[s] = types.NamespacedName{Name: xr.Name}
+}
+
+// remove must be called with the lock held.
+func (i *ConnectionSecretIndex) remove(uid types.UID) {
+	s, ok := i.secrets[uid]
+	if !ok {
+		return
+	}
+	delete(i.secrets, uid)
+	delete(i.composed, s)
+}
+
+// EnqueueRequestForCompositeOfSecret enqueues a request for the composite
+// resource that a connection Secret belongs to when that Secret changes. A
+// Secret belongs to a composite resource if the composite resource controls
+// it, or if one of its composed resources writes to it.
+type EnqueueRequestForCompositeOfSecret struct {
+	of    schema.GroupKind
+	index *ConnectionSecretIndex
+}
+
+// NewEnqueueRequestForCompositeOfSecret returns an EventHandler that enqueues
+// a request for the composite resource of the supplied kind that a connection
+// Secret belongs to. Secrets written by composed resources are mapped to their
+// composite resource using the supplied index.
+func NewEnqueueRequestForCompositeOfSecret(of schema.GroupVersionKind, i *ConnectionSecretIndex) *EnqueueRequestForCompositeOfSecret {
+	return &EnqueueRequestForCompositeOfSecret{of: of.GroupKind(), index: i}
+}
+
+// Create enqueues a request for the composite resource the Secret belongs to.
+func (e *EnqueueRequestForCompositeOfSecret) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Update enqueues a request for the composite resource the Secret belongs to.
+func (e *EnqueueRequestForCompositeOfSecret) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.ObjectNew, q)
+}
+
+// Delete enqueues a request for the composite resource the Secret belongs to.
+func (e *EnqueueRequestForCompositeOfSecret) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+// Generic enqueues a request for the composite resource the Secret belongs to.
+func (e *EnqueueRequestForCompositeOfSecret) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.add(evt.Object, q)
+}
+
+func (e *EnqueueRequestForCompositeOfSecret) add(obj client.Object, queue adder) {
+	if obj == nil {
+		return
+	}
+
+	if ref := metav1.GetControllerOf(obj); ref != nil && groupKindOf(v1.TypeReference{APIVersion: ref.APIVersion, Kind: ref.Kind}) == e.of {
+		// Composite resources are cluster scoped.
+		queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: ref.Name}})
+		return
+	}
+
+	if xr, ok := e.index.CompositeOf(types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}); ok {
+		queue.Add(reconcile.Request{NamespacedName: xr})
+	}
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package definition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kmeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+var (
+	_ handler.EventHandler = &EnqueueRequestForDefinitions{}
+	_ handler.EventHandler = &EnqueueRequestForCompositeOfSecret{}
+	_ handler.EventHandler = &ConnectionSecretIndex{}
+	_ ComposedTypeFetcher  = &APIComposedTypeFetcher{}
+)
+
+type addFn func(item interface{})
+
+func (fn addFn) Add(item interface{}) {
+	fn(item)
+}
+
+func TestFetchComposedTypes(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	d := &v1.CompositeResourceDefinition{
+		Spec: v1.CompositeResourceDefinitionSpec{
+			Group: "example.org",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "XDatabase"},
+			Versions: []v1.CompositeResourceDefinitionVersion{
+				{Name: "v1", Referenceable: true},
+			},
+		},
+	}
+
+	mapper := kmeta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Bucket"}, kmeta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Subnet"}, kmeta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "Instance"}, kmeta.RESTScopeRoot)
+
+	type args struct {
+		client client.Reader
+		d      *v1.CompositeResourceDefinition
+	}
+	type want struct {
+		known   []v1.TypeReference
+		unknown []v1.TypeReference
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ListCompositionsError": {
+			reason: "We should return any error encountered while listing Compositions.",
+			args: args{
+				client: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				d:      d,
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errListCompositions),
+			},
+		},
+		"ListRevisionsError": {
+			reason: "We should return any error encountered while listing CompositionRevisions.",
+			args: args{
+				client: &test.MockClient{MockList: func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+					if _, ok := obj.(*v1.CompositionRevisionList); ok {
+						return errBoom
+					}
+					return nil
+				}},
+				d: d,
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errListRevisions),
+			},
+		},
+		"Success": {
+			reason: "We should return the sorted, de-duplicated types of resource composed by compatible Compositions and CompositionRevisions, separating unknown types.",
+			args: args{
+				client: &test.MockClient{MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+					if l, ok := obj.(*v1.CompositionRevisionList); ok {
+						// A revision may compose types its Composition
+						// no longer does.
+						l.Items = []v1.CompositionRevision{{
+							Spec: v1.CompositionRevisionSpec{CompositionSpec: v1.CompositionSpec{
+								CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XDatabase"},
+								Resources: []v1.ComposedTemplate{
+									{Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Subnet"}`)}},
+								},
+							}},
+						}}
+						return nil
+					}
+					l := obj.(*v1.CompositionList)
+					l.Items = []v1.Composition{
+						{
+							Spec: v1.CompositionSpec{
+								CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XDatabase"},
+								Resources: []v1.ComposedTemplate{
+									{Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Instance"}`)}},
+									{Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Bucket"}`)}},
+									{Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Unknown"}`)}},
+									{Base: runtime.RawExtension{Raw: []byte(`{`)}},
+								},
+							},
+						},
+						{
+							Spec: v1.CompositionSpec{
+								CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1alpha1", Kind: "XDatabase"},
+								Resources: []v1.ComposedTemplate{
+									{Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Instance"}`)}},
+								},
+							},
+						},
+						{
+							Spec: v1.CompositionSpec{
+								CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XNetwork"},
+								Resources: []v1.ComposedTemplate{
+									{Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Network"}`)}},
+								},
+							},
+						},
+					}
+					return nil
+				})},
+				d: d,
+			},
+			want: want{
+				known: []v1.TypeReference{
+					{APIVersion: "example.org/v1", Kind: "Bucket"},
+					{APIVersion: "example.org/v1", Kind: "Instance"},
+					{APIVersion: "example.org/v1", Kind: "Subnet"},
+				},
+				unknown: []v1.TypeReference{
+					{APIVersion: "example.org/v1", Kind: "Unknown"},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := NewAPIComposedTypeFetcher(tc.args.client, mapper)
+			known, unknown, err := f.FetchComposedTypes(context.Background(), tc.args.d)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetchComposedTypes(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.known, known); diff != "" {
+				t.Errorf("\n%s\nFetchComposedTypes(...): -want known, +got known:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.unknown, unknown); diff != "" {
+				t.Errorf("\n%s\nFetchComposedTypes(...): -want unknown, +got unknown:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAddDefinitions(t *testing.T) {
+	errBoom := errors.New("boom")
+	name := "xdatabases.example.org"
+
+	comp := &v1.Composition{
+		Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XDatabase"},
+		},
+	}
+
+	cases := map[string]struct {
+		obj    runtime.Object
+		client client.Reader
+		queue  adder
+	}{
+		"ObjectIsNotAComposition": {
+			queue: addFn(func(_ interface{}) { t.Errorf("queue.Add() called unexpectedly") }),
+		},
+		"ListError": {
+			obj:    comp,
+			client: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			queue:  addFn(func(_ interface{}) { t.Errorf("queue.Add() called unexpectedly") }),
+		},
+		"SuccessfulEnqueue": {
+			obj: comp,
+			client: &test.MockClient{MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+				l := obj.(*v1.CompositeResourceDefinitionList)
+				l.Items = []v1.CompositeResourceDefinition{
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: name},
+						Spec: v1.CompositeResourceDefinitionSpec{
+							Group:    "example.org",
+							Names:    extv1.CustomResourceDefinitionNames{Kind: "XDatabase"},
+							Versions: []v1.CompositeResourceDefinitionVersion{{Name: "v1alpha1", Referenceable: true}},
+						},
+					},
+					{
+						ObjectMeta: metav1.ObjectMeta{Name: "xnetworks.example.org"},
+						Spec: v1.CompositeResourceDefinitionSpec{
+							Group:    "example.org",
+							Names:    extv1.CustomResourceDefinitionNames{Kind: "XNetwork"},
+							Versions: []v1.CompositeResourceDefinitionVersion{{Name: "v1", Referenceable: true}},
+						},
+					},
+				}
+				return nil
+			})},
+			queue: addFn(func(got interface{}) {
+				want := reconcile.Request{NamespacedName: types.NamespacedName{Name: name}}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("-want, +got:\n%s\n", diff)
+				}
+			}),
+		},
+	}
+
+	for _, tc := range cases {
+		e := &EnqueueRequestForDefinitions{client: tc.client}
+		e.add(tc.obj, tc.queue)
+	}
+}
+
+func TestAddCompositeOfSecret(t *testing.T) {
+	ctrl := true
+	of := schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "XDatabase"}
+
+	composed := func(uid types.UID, ownerKind, secret string) *kunstructured.Unstructured {
+		cd := &kunstructured.Unstructured{}
+		cd.SetAPIVersion("example.org/v1")
+		cd.SetKind("Instance")
+		cd.SetUID(uid)
+		cd.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "example.org/v1", Kind: ownerKind, Name: "cool-xr", Controller: &ctrl}})
+		_ = kunstructured.SetNestedField(cd.Object, secret, "spec", "writeConnectionSecretToRef", "name")
+		_ = kunstructured.SetNestedField(cd.Object, "crossplane-system", "spec", "writeConnectionSecretToRef", "namespace")
+		return cd
+	}
+	secret := func(name string, owners ...metav1.OwnerReference) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "crossplane-system", Name: name, OwnerReferences: owners}}
+	}
+	xr := reconcile.Request{NamespacedName: types.NamespacedName{Name: "cool-xr"}}
+
+	cases := map[string]struct {
+		reason  string
+		created []client.Object
+		deleted []client.Object
+		secret  client.Object
+		want    []interface{}
+	}{
+		"UnknownSecret": {
+			reason: "We should not enqueue a request for a Secret that no composed resource writes",
+			secret: secret("unknown"),
+		},
+		"ControlledByComposite": {
+			reason: "We should enqueue a request for the composite resource that controls a Secret",
+			secret: secret("xr-secret", metav1.OwnerReference{APIVersion: "example.org/v1", Kind: "XDatabase", Name: "cool-xr", Controller: &ctrl}),
+			want:   []interface{}{xr},
+		},
+		"WrittenByComposedResource": {
+			reason:  "We should enqueue a request for the composite resource whose composed resource writes a Secret",
+			created: []client.Object{composed("cd-uid", "XDatabase", "cd-secret")},
+			secret:  secret("cd-secret"),
+			want:    []interface{}{xr},
+		},
+		"WrittenByComposedResourceOfAnotherKind": {
+			reason:  "We should not enqueue a request for a Secret written by a resource that is controlled by another kind of composite resource",
+			created: []client.Object{composed("cd-uid", "XNetwork", "cd-secret")},
+			secret:  secret("cd-secret"),
+		},
+		"ComposedResourceDeleted": {
+			reason:  "We should not enqueue a request for a Secret written by a composed resource that no longer exists",
+			created: []client.Object{composed("cd-uid", "XDatabase", "cd-secret")},
+			deleted: []client.Object{composed("cd-uid", "XDatabase", "cd-secret")},
+			secret:  secret("cd-secret"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			idx := NewConnectionSecretIndex(of)
+			for _, obj := range tc.created {
+				idx.Create(event.CreateEvent{Object: obj}, nil)
+			}
+			for _, obj := range tc.deleted {
+				idx.Delete(event.DeleteEvent{Object: obj}, nil)
+			}
+
+			got := make([]interface{}, 0)
+			e := NewEnqueueRequestForCompositeOfSecret(of, idx)
+			e.add(tc.secret, addFn(func(item interface{}) { got = append(got, item) }))
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nqueue.Add(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
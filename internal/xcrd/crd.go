@@ -24,6 +24,7 @@ package xcrd
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/pkg/errors"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -94,6 +95,11 @@ func ForCompositeResource(xrd *v1.CompositeResourceDefinition) (*extv1.CustomRes
 		for k, v := range CompositeResourceSpecProps() {
 			specProps.Properties[k] = v
 		}
+		if dp := xrd.Spec.DefaultCompositeDeletionPolicy; dp != nil {
+			prop := specProps.Properties["deletionPolicy"]
+			prop.Default = &extv1.JSON{Raw: []byte(strconv.Quote(string(*dp)))}
+			specProps.Properties["deletionPolicy"] = prop
+		}
 		crd.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["spec"] = specProps
 
 		statusP, statusRequired, err := getProps("status", vr.Schema)
@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
@@ -79,6 +80,7 @@ func TestForCompositeResource(t *testing.T) {
 	listKind := "CoolCompositeList"
 	singular := "coolcomposite"
 	plural := "coolcomposites"
+	orphan := xpv1.DeletionOrphan
 
 	schema := `
 {
@@ -132,6 +134,7 @@ func TestForCompositeResource(t *testing.T) {
 				Kind:     kind,
 				ListKind: listKind,
 			},
+			DefaultCompositeDeletionPolicy: &orphan,
 			Versions: []v1.CompositeResourceDefinitionVersion{{
 				Name:          version,
 				Referenceable: true,
@@ -225,8 +228,7 @@ func TestForCompositeResource(t *testing.T) {
 										},
 									},
 									"compositionSelector": {
-										Type:     "object",
-										Required: []string{"matchLabels"},
+										Type: "object",
 										Properties: map[string]extv1.JSONSchemaProps{
 											"matchLabels": {
 												Type: "object",
@@ -235,6 +237,47 @@ func TestForCompositeResource(t *testing.T) {
 													Schema: &extv1.JSONSchemaProps{Type: "string"},
 												},
 											},
+											"matchExpressions": {
+												Type: "array",
+												Items: &extv1.JSONSchemaPropsOrArray{
+													Schema: &extv1.JSONSchemaProps{
+														Type:     "object",
+														Required: []string{"key", "operator"},
+														Properties: map[string]extv1.JSONSchemaProps{
+															"key":      {Type: "string"},
+															"operator": {Type: "string"},
+															"values": {
+																Type: "array",
+																Items: &extv1.JSONSchemaPropsOrArray{
+																	Schema: &extv1.JSONSchemaProps{Type: "string"},
+																},
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+									"deletionPolicy": {
+										Type:    "string",
+										Default: &extv1.JSON{Raw: []byte(`"Orphan"`)},
+										Enum: []extv1.JSON{
+											{Raw: []byte(`"Delete"`)},
+											{Raw: []byte(`"Orphan"`)},
+										},
+									},
+									"compositionRevisionRef": {
+										Type:     "object",
+										Required: []string{"name"},
+										Properties: map[string]extv1.JSONSchemaProps{
+											"name": {Type: "string"},
+										},
+									},
+									"compositionUpdatePolicy": {
+										Type: "string",
+										Enum: []extv1.JSON{
+											{Raw: []byte(`"Automatic"`)},
+											{Raw: []byte(`"Manual"`)},
 										},
 									},
 									"claimRef": {
@@ -257,7 +300,6 @@ func TestForCompositeResource(t *testing.T) {
 													"name":       {Type: "string"},
 													"kind":       {Type: "string"},
 												},
-												Required: []string{"apiVersion", "kind", "name"},
 											},
 										},
 									},
@@ -300,6 +342,26 @@ func TestForCompositeResource(t *testing.T) {
 											"lastPublishedTime": {Type: "string", Format: "date-time"},
 										},
 									},
+									"resources": {
+										Description: "Resources composed by the resource, and their status.",
+										Type:        "array",
+										Items: &extv1.JSONSchemaPropsOrArray{
+											Schema: &extv1.JSONSchemaProps{
+												Type:     "object",
+												Required: []string{"apiVersion", "kind", "name", "ready"},
+												Properties: map[string]extv1.JSONSchemaProps{
+													"apiVersion":   {Type: "string"},
+													"kind":         {Type: "string"},
+													"name":         {Type: "string"},
+													"template":     {Type: "string"},
+													"ready":        {Type: "boolean"},
+													"readyReason":  {Type: "string"},
+													"syncedReason": {Type: "string"},
+													"message":      {Type: "string"},
+												},
+											},
+										},
+									},
 								},
 							},
 						},
@@ -584,8 +646,7 @@ func TestForCompositeResourceClaim(t *testing.T) {
 											},
 										},
 										"compositionSelector": {
-											Type:     "object",
-											Required: []string{"matchLabels"},
+											Type: "object",
 											Properties: map[string]extv1.JSONSchemaProps{
 												"matchLabels": {
 													Type: "object",
@@ -594,6 +655,39 @@ func TestForCompositeResourceClaim(t *testing.T) {
 														Schema: &extv1.JSONSchemaProps{Type: "string"},
 													},
 												},
+												"matchExpressions": {
+													Type: "array",
+													Items: &extv1.JSONSchemaPropsOrArray{
+														Schema: &extv1.JSONSchemaProps{
+															Type:     "object",
+															Required: []string{"key", "operator"},
+															Properties: map[string]extv1.JSONSchemaProps{
+																"key":      {Type: "string"},
+																"operator": {Type: "string"},
+																"values": {
+																	Type: "array",
+																	Items: &extv1.JSONSchemaPropsOrArray{
+																		Schema: &extv1.JSONSchemaProps{Type: "string"},
+																	},
+																},
+															},
+														},
+													},
+												},
+											},
+										},
+										"compositionRevisionRef": {
+											Type:     "object",
+											Required: []string{"name"},
+											Properties: map[string]extv1.JSONSchemaProps{
+												"name": {Type: "string"},
+											},
+										},
+										"compositionUpdatePolicy": {
+											Type: "string",
+											Enum: []extv1.JSON{
+												{Raw: []byte(`"Automatic"`)},
+												{Raw: []byte(`"Manual"`)},
 											},
 										},
 										"resourceRef": {
@@ -643,6 +737,26 @@ func TestForCompositeResourceClaim(t *testing.T) {
 												"lastPublishedTime": {Type: "string", Format: "date-time"},
 											},
 										},
+										"resources": {
+											Description: "Resources composed by the resource, and their status.",
+											Type:        "array",
+											Items: &extv1.JSONSchemaPropsOrArray{
+												Schema: &extv1.JSONSchemaProps{
+													Type:     "object",
+													Required: []string{"apiVersion", "kind", "name", "ready"},
+													Properties: map[string]extv1.JSONSchemaProps{
+														"apiVersion":   {Type: "string"},
+														"kind":         {Type: "string"},
+														"name":         {Type: "string"},
+														"template":     {Type: "string"},
+														"ready":        {Type: "boolean"},
+														"readyReason":  {Type: "string"},
+														"syncedReason": {Type: "string"},
+														"message":      {Type: "string"},
+													},
+												},
+											},
+										},
 									},
 								},
 							},
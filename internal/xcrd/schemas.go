@@ -25,9 +25,24 @@ const (
 	LabelKeyClaimNamespace        = "crossplane.io/claim-namespace"
 )
 
+// Annotation keys.
+const (
+	// AnnotationKeyCompositionResourceName is the name of the Composition
+	// template that a composed resource was rendered from.
+	AnnotationKeyCompositionResourceName = "crossplane.io/composition-resource-name"
+
+	// AnnotationKeyCompositionResourceKey is the key of the forEach item that
+	// a composed resource was rendered for.
+	AnnotationKeyCompositionResourceKey = "crossplane.io/composition-resource-key"
+
+	// AnnotationKeyReconciliationPaused pauses reconciliation of a composite
+	// resource or claim when its value is "true".
+	AnnotationKeyReconciliationPaused = "crossplane.io/paused"
+)
+
 // KeepClaimSpecProps is the list of XRC spec properties to keep
 // when translating an XRC into an XR.
-var KeepClaimSpecProps = []string{"compositionRef", "compositionSelector"}
+var KeepClaimSpecProps = []string{"compositionRef", "compositionSelector", "compositionRevisionRef", "compositionUpdatePolicy"}
 
 // TODO(negz): Add descriptions to schema fields.
 
@@ -74,8 +89,7 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 			},
 		},
 		"compositionSelector": {
-			Type:     "object",
-			Required: []string{"matchLabels"},
+			Type: "object",
 			Properties: map[string]extv1.JSONSchemaProps{
 				"matchLabels": {
 					Type: "object",
@@ -84,6 +98,46 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 						Schema: &extv1.JSONSchemaProps{Type: "string"},
 					},
 				},
+				"matchExpressions": {
+					Type: "array",
+					Items: &extv1.JSONSchemaPropsOrArray{
+						Schema: &extv1.JSONSchemaProps{
+							Type:     "object",
+							Required: []string{"key", "operator"},
+							Properties: map[string]extv1.JSONSchemaProps{
+								"key":      {Type: "string"},
+								"operator": {Type: "string"},
+								"values": {
+									Type: "array",
+									Items: &extv1.JSONSchemaPropsOrArray{
+										Schema: &extv1.JSONSchemaProps{Type: "string"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"compositionRevisionRef": {
+			Type:     "object",
+			Required: []string{"name"},
+			Properties: map[string]extv1.JSONSchemaProps{
+				"name": {Type: "string"},
+			},
+		},
+		"compositionUpdatePolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Automatic"`)},
+				{Raw: []byte(`"Manual"`)},
+			},
+		},
+		"deletionPolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Delete"`)},
+				{Raw: []byte(`"Orphan"`)},
 			},
 		},
 		"claimRef": {
@@ -96,6 +150,9 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 				"name":       {Type: "string"},
 			},
 		},
+		// A resource reference is empty if the Composition template it
+		// corresponds to is not currently composed, for example because
+		// the template's condition is not met.
 		"resourceRefs": {
 			Type: "array",
 			Items: &extv1.JSONSchemaPropsOrArray{
@@ -106,7 +163,6 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 						"name":       {Type: "string"},
 						"kind":       {Type: "string"},
 					},
-					Required: []string{"apiVersion", "kind", "name"},
 				},
 			},
 		},
@@ -134,8 +190,7 @@ func CompositeResourceClaimSpecProps() map[string]extv1.JSONSchemaProps {
 			},
 		},
 		"compositionSelector": {
-			Type:     "object",
-			Required: []string{"matchLabels"},
+			Type: "object",
 			Properties: map[string]extv1.JSONSchemaProps{
 				"matchLabels": {
 					Type: "object",
@@ -144,6 +199,39 @@ func CompositeResourceClaimSpecProps() map[string]extv1.JSONSchemaProps {
 						Schema: &extv1.JSONSchemaProps{Type: "string"},
 					},
 				},
+				"matchExpressions": {
+					Type: "array",
+					Items: &extv1.JSONSchemaPropsOrArray{
+						Schema: &extv1.JSONSchemaProps{
+							Type:     "object",
+							Required: []string{"key", "operator"},
+							Properties: map[string]extv1.JSONSchemaProps{
+								"key":      {Type: "string"},
+								"operator": {Type: "string"},
+								"values": {
+									Type: "array",
+									Items: &extv1.JSONSchemaPropsOrArray{
+										Schema: &extv1.JSONSchemaProps{Type: "string"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"compositionRevisionRef": {
+			Type:     "object",
+			Required: []string{"name"},
+			Properties: map[string]extv1.JSONSchemaProps{
+				"name": {Type: "string"},
+			},
+		},
+		"compositionUpdatePolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Automatic"`)},
+				{Raw: []byte(`"Manual"`)},
 			},
 		},
 		"resourceRef": {
@@ -193,6 +281,26 @@ func CompositeResourceStatusProps() map[string]extv1.JSONSchemaProps {
 				"lastPublishedTime": {Type: "string", Format: "date-time"},
 			},
 		},
+		"resources": {
+			Description: "Resources composed by the resource, and their status.",
+			Type:        "array",
+			Items: &extv1.JSONSchemaPropsOrArray{
+				Schema: &extv1.JSONSchemaProps{
+					Type:     "object",
+					Required: []string{"apiVersion", "kind", "name", "ready"},
+					Properties: map[string]extv1.JSONSchemaProps{
+						"apiVersion":   {Type: "string"},
+						"kind":         {Type: "string"},
+						"name":         {Type: "string"},
+						"template":     {Type: "string"},
+						"ready":        {Type: "boolean"},
+						"readyReason":  {Type: "string"},
+						"syncedReason": {Type: "string"},
+						"message":      {Type: "string"},
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -0,0 +1,553 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expression
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errDivideByZero  = "cannot divide by zero"
+	errIntOverflow   = "integer overflow"
+	errFloatOverflow = "floating point overflow"
+
+	errFmtCostLimit         = "expression exceeded cost limit of %d"
+	errFmtUndefinedVariable = "undefined variable %q"
+	errFmtNoSuchKey         = "no such key %q"
+	errFmtOutOfRange        = "index %d is out of range"
+	errFmtCannotSelect      = "cannot select field %q from %s"
+	errFmtCannotIndex       = "cannot index %s with %s"
+	errFmtKeyNotString      = "object keys must be strings, not %s"
+	errFmtNotBool           = "operator %s requires booleans, not %s"
+	errFmtUnaryType         = "operator %s does not support %s"
+	errFmtBinaryTypes       = "operator %s does not support %s and %s"
+)
+
+// An evaluator evaluates the nodes of an expression, tracking the cost of
+// doing so.
+type evaluator struct {
+	vars  map[string]interface{}
+	cost  int64
+	limit int64
+}
+
+// charge the supplied cost to the evaluation, returning an error if doing so
+// exceeds the evaluation's cost limit.
+func (e *evaluator) charge(cost int) error {
+	e.cost += int64(cost)
+	if e.cost > e.limit {
+		return errors.Errorf(errFmtCostLimit, e.limit)
+	}
+	return nil
+}
+
+func (n literal) eval(e *evaluator) (interface{}, error) {
+	return n.value, e.charge(1)
+}
+
+func (n variable) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1); err != nil {
+		return nil, err
+	}
+	v, ok := e.vars[n.name]
+	if !ok {
+		return nil, errors.Errorf(errFmtUndefinedVariable, n.name)
+	}
+	return normalize(v), nil
+}
+
+func (n list) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1 + len(n.items)); err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(n.items))
+	for i := range n.items {
+		v, err := n.items[i].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (n object) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1 + len(n.keys)); err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{}, len(n.keys))
+	for i := range n.keys {
+		k, err := n.keys[i].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		ks, ok := k.(string)
+		if !ok {
+			return nil, errors.Errorf(errFmtKeyNotString, typeName(k))
+		}
+		v, err := n.values[i].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		out[ks] = v
+	}
+	return out, nil
+}
+
+func (n member) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1); err != nil {
+		return nil, err
+	}
+	o, err := n.object.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := o.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf(errFmtCannotSelect, n.name, typeName(o))
+	}
+	v, ok := m[n.name]
+	if !ok {
+		return nil, errors.Errorf(errFmtNoSuchKey, n.name)
+	}
+	return normalize(v), nil
+}
+
+func (n index) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1); err != nil {
+		return nil, err
+	}
+	o, err := n.object.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	i, err := n.index.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	v, found, err := lookup(o, i)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if k, ok := i.(string); ok {
+			return nil, errors.Errorf(errFmtNoSuchKey, k)
+		}
+		return nil, errors.Errorf(errFmtOutOfRange, i)
+	}
+	return v, nil
+}
+
+// lookup the supplied key or index of the supplied map or list, returning
+// whether it was found.
+func lookup(o, i interface{}) (interface{}, bool, error) {
+	switch ot := o.(type) {
+	case map[string]interface{}:
+		k, ok := i.(string)
+		if !ok {
+			return nil, false, errors.Errorf(errFmtCannotIndex, typeName(o), typeName(i))
+		}
+		v, ok := ot[k]
+		return normalize(v), ok, nil
+	case []interface{}:
+		idx, ok := i.(int64)
+		if !ok {
+			return nil, false, errors.Errorf(errFmtCannotIndex, typeName(o), typeName(i))
+		}
+		if idx < 0 || idx >= int64(len(ot)) {
+			return nil, false, nil
+		}
+		return normalize(ot[idx]), true, nil
+	}
+	return nil, false, errors.Errorf(errFmtCannotIndex, typeName(o), typeName(i))
+}
+
+// find the value of the supplied selection, returning whether it exists.
+// Unlike evaluating the selection, finding a field or index that does not
+// exist - at any level - is not an error.
+func (e *evaluator) find(n node) (interface{}, bool, error) {
+	if err := e.charge(1); err != nil {
+		return nil, false, err
+	}
+	switch s := n.(type) {
+	case variable:
+		v, ok := e.vars[s.name]
+		return normalize(v), ok, nil
+	case member:
+		o, ok, err := e.find(s.object)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			return nil, false, nil
+		}
+		v, ok := m[s.name]
+		return normalize(v), ok, nil
+	case index:
+		o, ok, err := e.find(s.object)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		i, err := s.index.eval(e)
+		if err != nil {
+			return nil, false, err
+		}
+		return lookup(o, i)
+	}
+	v, err := n.eval(e)
+	return v, err == nil, err
+}
+
+func (n unary) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1); err != nil {
+		return nil, err
+	}
+	v, err := n.operand.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, errors.Errorf(errFmtNotBool, n.op, typeName(v))
+		}
+		return !b, nil
+	case "-":
+		switch vt := v.(type) {
+		case int64:
+			if vt == math.MinInt64 {
+				return nil, errors.New(errIntOverflow)
+			}
+			return -vt, nil
+		case float64:
+			return -vt, nil
+		}
+	}
+	return nil, errors.Errorf(errFmtUnaryType, n.op, typeName(v))
+}
+
+func (n conditional) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1); err != nil {
+		return nil, err
+	}
+	c, err := n.condition.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := c.(bool)
+	if !ok {
+		return nil, errors.Errorf(errFmtNotBool, "?", typeName(c))
+	}
+	if b {
+		return n.then.eval(e)
+	}
+	return n.otherwise.eval(e)
+}
+
+func (n binary) eval(e *evaluator) (interface{}, error) { // nolint:gocyclo
+	// The cyclomatic complexity here comes from the switch on the operator -
+	// each case is simple.
+	if err := e.charge(1); err != nil {
+		return nil, err
+	}
+	l, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// Boolean operators short circuit, so we must not evaluate the right
+	// hand side until we know we need it.
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, errors.Errorf(errFmtNotBool, n.op, typeName(l))
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+		r, err := n.right.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, errors.Errorf(errFmtNotBool, n.op, typeName(r))
+		}
+		return rb, nil
+	}
+
+	r, err := n.right.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, l, r)
+	case "in":
+		return in(e, l, r)
+	case "+":
+		return add(e, l, r)
+	}
+	return arithmetic(n.op, l, r)
+}
+
+func equal(l, r interface{}) bool {
+	if lf, rf, ok := floats(l, r); ok {
+		return lf == rf
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+func compare(op string, l, r interface{}) (interface{}, error) {
+	c := 0
+	switch {
+	case isInts(l, r):
+		li, ri := l.(int64), r.(int64)
+		c = order(li < ri, li > ri)
+	case isNumbers(l, r):
+		lf, rf, _ := floats(l, r)
+		c = order(lf < rf, lf > rf)
+	case isStrings(l, r):
+		c = strings.Compare(l.(string), r.(string))
+	default:
+		return nil, errors.Errorf(errFmtBinaryTypes, op, typeName(l), typeName(r))
+	}
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+func order(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func in(e *evaluator, l, r interface{}) (interface{}, error) {
+	switch rt := r.(type) {
+	case []interface{}:
+		if err := e.charge(len(rt)); err != nil {
+			return nil, err
+		}
+		for _, v := range rt {
+			if equal(l, normalize(v)) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		if k, ok := l.(string); ok {
+			_, found := rt[k]
+			return found, nil
+		}
+	case string:
+		if s, ok := l.(string); ok {
+			return strings.Contains(rt, s), e.charge(len(rt))
+		}
+	}
+	return nil, errors.Errorf(errFmtBinaryTypes, "in", typeName(l), typeName(r))
+}
+
+func add(e *evaluator, l, r interface{}) (interface{}, error) {
+	switch {
+	case isStrings(l, r):
+		ls, rs := l.(string), r.(string)
+		if err := e.charge(len(ls) + len(rs)); err != nil {
+			return nil, err
+		}
+		return ls + rs, nil
+	case isLists(l, r):
+		ll, rl := l.([]interface{}), r.([]interface{})
+		if err := e.charge(len(ll) + len(rl)); err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, 0, len(ll)+len(rl))
+		return append(append(out, ll...), rl...), nil
+	}
+	return arithmetic("+", l, r)
+}
+
+func arithmetic(op string, l, r interface{}) (interface{}, error) { // nolint:gocyclo
+	// The cyclomatic complexity here comes from checking for overflow and
+	// division by zero - each operation is simple.
+	if isInts(l, r) {
+		li, ri := l.(int64), r.(int64)
+		switch op {
+		case "+":
+			o := li + ri
+			if (ri > 0 && o < li) || (ri < 0 && o > li) {
+				return nil, errors.New(errIntOverflow)
+			}
+			return o, nil
+		case "-":
+			o := li - ri
+			if (ri > 0 && o > li) || (ri < 0 && o < li) {
+				return nil, errors.New(errIntOverflow)
+			}
+			return o, nil
+		case "*":
+			o := li * ri
+			if li != 0 && (o/li != ri || (li == -1 && ri == math.MinInt64)) {
+				return nil, errors.New(errIntOverflow)
+			}
+			return o, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, errors.New(errDivideByZero)
+			}
+			if li == math.MinInt64 && ri == -1 {
+				return nil, errors.New(errIntOverflow)
+			}
+			if op == "/" {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		}
+	}
+
+	lf, rf, ok := floats(l, r)
+	if !ok || op == "%" {
+		return nil, errors.Errorf(errFmtBinaryTypes, op, typeName(l), typeName(r))
+	}
+	var o float64
+	switch op {
+	case "+":
+		o = lf + rf
+	case "-":
+		o = lf - rf
+	case "*":
+		o = lf * rf
+	case "/":
+		if rf == 0 {
+			return nil, errors.New(errDivideByZero)
+		}
+		o = lf / rf
+	default:
+		return nil, errors.Errorf(errFmtBinaryTypes, op, typeName(l), typeName(r))
+	}
+	if math.IsInf(o, 0) || math.IsNaN(o) {
+		return nil, errors.New(errFloatOverflow)
+	}
+	return o, nil
+}
+
+// normalize numbers to either int64 or float64.
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int8:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint8:
+		return int64(n)
+	case uint16:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+// floats returns the supplied values as floats, if they are both numbers.
+func floats(l, r interface{}) (float64, float64, bool) {
+	lf, lok := float(l)
+	rf, rok := float(r)
+	return lf, rf, lok && rok
+}
+
+func float(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func isInts(l, r interface{}) bool {
+	_, lok := l.(int64)
+	_, rok := r.(int64)
+	return lok && rok
+}
+
+func isNumbers(l, r interface{}) bool {
+	_, _, ok := floats(l, r)
+	return ok
+}
+
+func isStrings(l, r interface{}) bool {
+	_, lok := l.(string)
+	_, rok := r.(string)
+	return lok && rok
+}
+
+func isLists(l, r interface{}) bool {
+	_, lok := l.([]interface{})
+	_, rok := r.([]interface{})
+	return lok && rok
+}
+
+// typeName returns the name of the supplied value's type, as it would be
+// described by the expression language.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package expression implements a small, side-effect free expression
+// language. Expressions support literals, arithmetic, comparisons, boolean
+// logic, conditionals, list and map access, and a fixed set of functions.
+// They cannot loop, recurse, or access anything but the variables they are
+// evaluated with, and both their size and the cost of evaluating them are
+// bounded. Evaluating an expression is therefore deterministic and always
+// terminates quickly, regardless of its input.
+//
+// An example expression:
+//
+//	has(input.size) && input.size == "large" ? 3 * composite.spec.replicas : 1
+package expression
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxLength is the maximum length of an expression, in bytes.
+	MaxLength = 4096
+
+	// MaxDepth is the maximum nesting depth of an expression.
+	MaxDepth = 32
+
+	// DefaultCostLimit is the default limit on the cost of evaluating an
+	// expression. Each step of evaluation costs one unit, and producing a
+	// string, list or object costs an additional unit per byte or element.
+	DefaultCostLimit = 100000
+)
+
+const (
+	errFmtTooLong = "expression is longer than %d bytes"
+	errParse      = "cannot parse expression"
+	errEvaluate   = "cannot evaluate expression"
+)
+
+// An Expression that may be evaluated.
+type Expression struct {
+	root node
+}
+
+// Parse the supplied expression.
+func Parse(expr string) (*Expression, error) {
+	if len(expr) > MaxLength {
+		return nil, errors.Errorf(errFmtTooLong, MaxLength)
+	}
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, errors.Wrap(err, errParse)
+	}
+	p := &parser{tokens: tokens, maxDepth: MaxDepth}
+	root, err := p.parse()
+	if err != nil {
+		return nil, errors.Wrap(err, errParse)
+	}
+	return &Expression{root: root}, nil
+}
+
+// An EvaluateOption configures how an expression is evaluated.
+type EvaluateOption func(e *evaluator)
+
+// WithCostLimit limits the cost of evaluating an expression. Evaluation fails
+// if the limit is exceeded. The DefaultCostLimit is used if this option is
+// not supplied.
+func WithCostLimit(limit int64) EvaluateOption {
+	return func(e *evaluator) {
+		e.limit = limit
+	}
+}
+
+// Evaluate the expression. The supplied variables may be referenced by name
+// within the expression. Variables are expected to be of the types produced
+// by unmarshalling JSON into an interface{} - i.e. strings, booleans, nil,
+// numbers, []interface{} and map[string]interface{}.
+func (x *Expression) Evaluate(vars map[string]interface{}, o ...EvaluateOption) (interface{}, error) {
+	e := &evaluator{vars: vars, limit: DefaultCostLimit}
+	for _, fn := range o {
+		fn(e)
+	}
+	out, err := x.root.eval(e)
+	return out, errors.Wrap(err, errEvaluate)
+}
+
+// Evaluate parses and evaluates the supplied expression.
+func Evaluate(expr string, vars map[string]interface{}, o ...EvaluateOption) (interface{}, error) {
+	x, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return x.Evaluate(vars, o...)
+}
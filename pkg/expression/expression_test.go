@@ -0,0 +1,329 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expression
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		expr   string
+		want   error
+	}{
+		"Valid": {
+			reason: "A well formed expression should parse",
+			expr:   `has(input.a) ? [1, 2.5, "three", {"four": true}][0] : -input.b * (2 + 3) % 4`,
+		},
+		"TooLong": {
+			reason: "Expressions longer than MaxLength should be rejected",
+			expr:   strings.Repeat("1", MaxLength+1),
+			want:   errors.Errorf(errFmtTooLong, MaxLength),
+		},
+		"TooDeep": {
+			reason: "Expressions nested deeper than MaxDepth should be rejected",
+			expr:   strings.Repeat("(", MaxDepth) + "1" + strings.Repeat(")", MaxDepth),
+			want:   errors.Wrap(errors.Errorf(errFmtTooDeep, MaxDepth), errParse),
+		},
+		"UnexpectedCharacter": {
+			reason: "Characters that are not part of the language should be rejected",
+			expr:   "input & 1",
+			want:   errors.Wrap(errors.Errorf(errFmtUnexpectedChar, '&', 6), errParse),
+		},
+		"UnterminatedString": {
+			reason: "Strings must be terminated",
+			expr:   `"abc`,
+			want:   errors.Wrap(errors.Errorf(errFmtUnterminatedString, 0), errParse),
+		},
+		"InvalidEscape": {
+			reason: "Only supported escape sequences are allowed",
+			expr:   `"a\qb"`,
+			want:   errors.Wrap(errors.Errorf(errFmtInvalidEscape, `\q`, 2), errParse),
+		},
+		"MissingOperand": {
+			reason: "Binary operators require a right hand operand",
+			expr:   "1 +",
+			want:   errors.Wrap(errors.Errorf(errFmtUnexpectedToken, "end of expression", 3), errParse),
+		},
+		"TrailingTokens": {
+			reason: "An expression must be fully consumed",
+			expr:   "1 2",
+			want:   errors.Wrap(errors.Errorf(errFmtUnexpectedToken, `"2"`, 2), errParse),
+		},
+		"UnclosedParen": {
+			reason: "Parentheses must be closed",
+			expr:   "(1 + 2",
+			want:   errors.Wrap(errors.Errorf(errFmtExpectedToken, ")", 6), errParse),
+		},
+		"UnknownFunction": {
+			reason: "Only known functions may be called",
+			expr:   "exec('rm -rf /')",
+			want:   errors.Wrap(errors.Errorf(errFmtUnknownFunction, "exec", 0), errParse),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(tc.expr)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nParse(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	vars := map[string]interface{}{
+		"input": "eu-west-1",
+		"composite": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"ratio":    0.5,
+				"size":     "large",
+				"enabled":  true,
+				"zones":    []interface{}{"a", "b", "c"},
+				"tags":     map[string]interface{}{"team": "platform"},
+				"optional": nil,
+			},
+		},
+	}
+
+	type args struct {
+		expr string
+		vars map[string]interface{}
+		o    []EvaluateOption
+	}
+	type want struct {
+		out interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Literals": {
+			reason: "Literals should evaluate to JSON compatible values",
+			args:   args{expr: `[1, 2.5, "s", 'q', true, null, {"k": "v"}]`},
+			want: want{out: []interface{}{
+				int64(1), 2.5, "s", "q", true, nil, map[string]interface{}{"k": "v"},
+			}},
+		},
+		"Variable": {
+			reason: "Variables should be resolved by name",
+			args:   args{expr: "input", vars: vars},
+			want:   want{out: "eu-west-1"},
+		},
+		"NormalizedVariable": {
+			reason: "Integer variables should be normalized to int64",
+			args:   args{expr: "input + 1", vars: map[string]interface{}{"input": 41}},
+			want:   want{out: int64(42)},
+		},
+		"UndefinedVariable": {
+			reason: "Referencing an undefined variable should return an error",
+			args:   args{expr: "nope", vars: vars},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtUndefinedVariable, "nope"), errEvaluate)},
+		},
+		"Member": {
+			reason: "Fields of maps should be selectable",
+			args:   args{expr: "composite.spec.tags.team", vars: vars},
+			want:   want{out: "platform"},
+		},
+		"MissingMember": {
+			reason: "Selecting a missing field should return an error",
+			args:   args{expr: "composite.spec.nope", vars: vars},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtNoSuchKey, "nope"), errEvaluate)},
+		},
+		"SelectFromNonMap": {
+			reason: "Selecting a field of a value that is not a map should return an error",
+			args:   args{expr: "input.nope", vars: vars},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtCannotSelect, "nope", "string"), errEvaluate)},
+		},
+		"Index": {
+			reason: "Lists should be indexable by integer and maps by string",
+			args:   args{expr: `composite.spec.zones[1] + composite["spec"]["tags"]["team"]`, vars: vars},
+			want:   want{out: "bplatform"},
+		},
+		"IndexOutOfRange": {
+			reason: "Indexing beyond the end of a list should return an error",
+			args:   args{expr: "composite.spec.zones[3]", vars: vars},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtOutOfRange, int64(3)), errEvaluate)},
+		},
+		"Has": {
+			reason: "has() should report whether a selection exists at any depth",
+			args:   args{expr: "[has(composite.spec.size), has(composite.spec.nope.deeper), has(composite.spec.zones[5]), has(composite.spec.optional)]", vars: vars},
+			want:   want{out: []interface{}{true, false, false, true}},
+		},
+		"HasRequiresSelection": {
+			reason: "has() should only accept a selection",
+			args:   args{expr: "has(input)", vars: vars},
+			want:   want{err: errors.Wrap(errors.New(errHasArgument), errEvaluate)},
+		},
+		"Conditional": {
+			reason: "Conditionals should evaluate only the chosen branch",
+			args:   args{expr: `composite.spec.size == "large" ? composite.spec.replicas * 2 : composite.spec.nope`, vars: vars},
+			want:   want{out: int64(6)},
+		},
+		"ConditionalNotBool": {
+			reason: "Conditions must be boolean",
+			args:   args{expr: "1 ? 2 : 3"},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtNotBool, "?", "int"), errEvaluate)},
+		},
+		"ShortCircuit": {
+			reason: "Boolean operators should not evaluate their right hand side unless necessary",
+			args:   args{expr: "(false && nope) || (true || nope)"},
+			want:   want{out: true},
+		},
+		"Not": {
+			reason: "The not operator should negate booleans",
+			args:   args{expr: "!composite.spec.enabled", vars: vars},
+			want:   want{out: false},
+		},
+		"Precedence": {
+			reason: "Multiplication should bind tighter than addition, and comparison looser still",
+			args:   args{expr: "1 + 2 * 3 == 7 && -2 - 1 < 0"},
+			want:   want{out: true},
+		},
+		"IntegerArithmetic": {
+			reason: "Integer arithmetic should remain integer",
+			args:   args{expr: "[7 / 2, 7 % 2, 7 - 10]"},
+			want:   want{out: []interface{}{int64(3), int64(1), int64(-3)}},
+		},
+		"FloatArithmetic": {
+			reason: "Arithmetic involving a float should produce a float",
+			args:   args{expr: "composite.spec.replicas * composite.spec.ratio", vars: vars},
+			want:   want{out: 1.5},
+		},
+		"DivideByZero": {
+			reason: "Dividing by zero should return an error",
+			args:   args{expr: "1 / 0"},
+			want:   want{err: errors.Wrap(errors.New(errDivideByZero), errEvaluate)},
+		},
+		"IntegerOverflow": {
+			reason: "Integer overflow should return an error rather than wrap",
+			args:   args{expr: "9223372036854775807 + 1"},
+			want:   want{err: errors.Wrap(errors.New(errIntOverflow), errEvaluate)},
+		},
+		"MixedTypes": {
+			reason: "Arithmetic on incompatible types should return an error",
+			args:   args{expr: `"a" - 1`},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtBinaryTypes, "-", "string", "int"), errEvaluate)},
+		},
+		"Equality": {
+			reason: "Numbers should compare equal regardless of type, and other values by content",
+			args:   args{expr: `[1 == 1.0, [1, "a"] == [1, "a"], {"a": 1} != {"a": 2}, null == null, "a" == 1]`},
+			want:   want{out: []interface{}{true, true, true, true, false}},
+		},
+		"Comparison": {
+			reason: "Numbers and strings should be ordered",
+			args:   args{expr: `[1 < 1.5, 2 >= 2, "a" < "b", "b" <= "a"]`},
+			want:   want{out: []interface{}{true, true, true, false}},
+		},
+		"In": {
+			reason: "The in operator should test membership of lists, maps and strings",
+			args:   args{expr: `["b" in composite.spec.zones, "team" in composite.spec.tags, "west" in input, "d" in composite.spec.zones]`, vars: vars},
+			want:   want{out: []interface{}{true, true, true, false}},
+		},
+		"Concatenate": {
+			reason: "Strings and lists should be concatenated",
+			args:   args{expr: `[input + "-db", composite.spec.zones + ["d"]]`, vars: vars},
+			want:   want{out: []interface{}{"eu-west-1-db", []interface{}{"a", "b", "c", "d"}}},
+		},
+		"StringFunctions": {
+			reason: "String functions should transform strings",
+			args: args{
+				expr: `[upper(input), lower("ABC"), trim(" a "), trimPrefix(input, "eu-"), trimSuffix(input, "-1"), replace(input, "-", "_"), substring(input, 3), substring(input, 0, 2)]`,
+				vars: vars,
+			},
+			want: want{out: []interface{}{"EU-WEST-1", "abc", "a", "west-1", "eu-west", "eu_west_1", "west-1", "eu"}},
+		},
+		"StringPredicates": {
+			reason: "String predicates should test strings",
+			args:   args{expr: `[contains(input, "west"), startsWith(input, "us"), endsWith(input, "-1"), matches(input, "^[a-z]{2}-[a-z]+-[0-9]$")]`, vars: vars},
+			want:   want{out: []interface{}{true, false, true, true}},
+		},
+		"SplitAndJoin": {
+			reason: "Strings should be split into lists and lists joined into strings",
+			args:   args{expr: `[split(input, "-"), join(composite.spec.zones, ",")]`, vars: vars},
+			want:   want{out: []interface{}{[]interface{}{"eu", "west", "1"}, "a,b,c"}},
+		},
+		"Size": {
+			reason: "size() should return the length of strings, lists and maps",
+			args:   args{expr: `[size("héllo"), size(composite.spec.zones), size(composite.spec.tags)]`, vars: vars},
+			want:   want{out: []interface{}{int64(5), int64(3), int64(1)}},
+		},
+		"Conversions": {
+			reason: "Values should be convertible between strings and numbers",
+			args:   args{expr: `[string(3), string(1.5), string(true), int("42"), int(2.9), double("0.25"), double(2)]`},
+			want:   want{out: []interface{}{"3", "1.5", "true", int64(42), int64(2), 0.25, float64(2)}},
+		},
+		"InvalidConversion": {
+			reason: "Converting a string that is not a number should return an error",
+			args:   args{expr: `int("four")`},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtConvert, `"four"`, "int"), errEvaluate)},
+		},
+		"MinMax": {
+			reason: "min() and max() should return the smallest and largest of their arguments",
+			args:   args{expr: "[min(3, 1.5, 2), max(composite.spec.replicas, 1)]", vars: vars},
+			want:   want{out: []interface{}{1.5, int64(3)}},
+		},
+		"WrongArgumentCount": {
+			reason: "Calling a function with the wrong number of arguments should return an error",
+			args:   args{expr: `replace("a", "b")`},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtArgCount, "replace", "3 arguments"), errEvaluate)},
+		},
+		"WrongArgumentType": {
+			reason: "Calling a function with the wrong type of argument should return an error",
+			args:   args{expr: `upper(1)`},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtArgType, 1, "upper", "string", "int"), errEvaluate)},
+		},
+		"ObjectKeyNotString": {
+			reason: "Object keys must be strings",
+			args:   args{expr: `{1: "a"}`},
+			want:   want{err: errors.Wrap(errors.Errorf(errFmtKeyNotString, "int"), errEvaluate)},
+		},
+		"CostLimit": {
+			reason: "Evaluation should stop once the cost limit is exceeded",
+			args: args{
+				expr: `replace(replace(replace(input, "", input), "", input), "", input)`,
+				vars: vars,
+				o:    []EvaluateOption{WithCostLimit(1000)},
+			},
+			want: want{err: errors.Wrap(errors.Errorf(errFmtCostLimit, 1000), errEvaluate)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := Evaluate(tc.args.expr, tc.args.vars, tc.args.o...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nEvaluate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.out, got); diff != "" {
+				t.Errorf("\n%s\nEvaluate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,390 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expression
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// has is not a regular function; its argument is a selection that is not
+// evaluated, but checked for existence.
+const fnHas = "has"
+
+const (
+	errHasArgument = "has() requires a single field or index selection"
+
+	errFmtArgCount      = "%s() requires %s"
+	errFmtArgType       = "argument %d of %s() must be a %s, not %s"
+	errFmtConvert       = "cannot convert %s to %s"
+	errFmtSubstring     = "substring range [%d:%d] is out of bounds for a string of length %d"
+	errFmtCompileRegexp = "cannot compile regexp %q"
+)
+
+// A function takes evaluated arguments and returns a value.
+type function struct {
+	// The minimum and maximum number of arguments. A maximum of -1 permits
+	// any number of arguments.
+	min, max int
+	call     func(e *evaluator, args []interface{}) (interface{}, error)
+}
+
+// arity describes the number of arguments the function requires.
+func (f function) arity() string {
+	switch {
+	case f.max == -1:
+		return fmt.Sprintf("at least %d arguments", f.min)
+	case f.min == f.max && f.min == 1:
+		return "1 argument"
+	case f.min == f.max:
+		return fmt.Sprintf("%d arguments", f.min)
+	}
+	return fmt.Sprintf("%d to %d arguments", f.min, f.max)
+}
+
+var functions = map[string]function{
+	"size":       {min: 1, max: 1, call: size},
+	"string":     {min: 1, max: 1, call: toString},
+	"int":        {min: 1, max: 1, call: toInt},
+	"double":     {min: 1, max: 1, call: toDouble},
+	"lower":      {min: 1, max: 1, call: stringFn("lower", strings.ToLower)},
+	"upper":      {min: 1, max: 1, call: stringFn("upper", strings.ToUpper)},
+	"trim":       {min: 1, max: 1, call: stringFn("trim", strings.TrimSpace)},
+	"trimPrefix": {min: 2, max: 2, call: stringsFn("trimPrefix", strings.TrimPrefix)},
+	"trimSuffix": {min: 2, max: 2, call: stringsFn("trimSuffix", strings.TrimSuffix)},
+	"contains":   {min: 2, max: 2, call: predicateFn("contains", strings.Contains)},
+	"startsWith": {min: 2, max: 2, call: predicateFn("startsWith", strings.HasPrefix)},
+	"endsWith":   {min: 2, max: 2, call: predicateFn("endsWith", strings.HasSuffix)},
+	"replace":    {min: 3, max: 3, call: replace},
+	"split":      {min: 2, max: 2, call: split},
+	"join":       {min: 2, max: 2, call: join},
+	"substring":  {min: 2, max: 3, call: substring},
+	"matches":    {min: 2, max: 2, call: matches},
+	"min":        {min: 1, max: -1, call: extreme("min", "<")},
+	"max":        {min: 1, max: -1, call: extreme("max", ">")},
+}
+
+func (n call) eval(e *evaluator) (interface{}, error) {
+	if err := e.charge(1); err != nil {
+		return nil, err
+	}
+
+	if n.fn == fnHas {
+		if len(n.args) != 1 {
+			return nil, errors.New(errHasArgument)
+		}
+		switch n.args[0].(type) {
+		case member, index:
+		default:
+			return nil, errors.New(errHasArgument)
+		}
+		_, found, err := e.find(n.args[0])
+		return found, err
+	}
+
+	f := functions[n.fn]
+	if len(n.args) < f.min || (f.max != -1 && len(n.args) > f.max) {
+		return nil, errors.Errorf(errFmtArgCount, n.fn, f.arity())
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i := range n.args {
+		v, err := n.args[i].eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return f.call(e, args)
+}
+
+// str returns the argument at the supplied index of a call to the supplied
+// function as a string.
+func str(fn string, args []interface{}, i int) (string, error) {
+	s, ok := args[i].(string)
+	if !ok {
+		return "", errors.Errorf(errFmtArgType, i+1, fn, "string", typeName(args[i]))
+	}
+	return s, nil
+}
+
+func integer(fn string, args []interface{}, i int) (int64, error) {
+	n, ok := args[i].(int64)
+	if !ok {
+		return 0, errors.Errorf(errFmtArgType, i+1, fn, "int", typeName(args[i]))
+	}
+	return n, nil
+}
+
+func size(_ *evaluator, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return int64(len(v)), nil
+	case map[string]interface{}:
+		return int64(len(v)), nil
+	}
+	return nil, errors.Errorf(errFmtArgType, 1, "size", "string, list or map", typeName(args[0]))
+}
+
+func toString(e *evaluator, args []interface{}) (interface{}, error) {
+	var s string
+	switch v := args[0].(type) {
+	case string:
+		s = v
+	case bool:
+		s = strconv.FormatBool(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, errors.Errorf(errFmtConvert, typeName(v), "string")
+	}
+	return s, e.charge(len(s))
+}
+
+func toInt(_ *evaluator, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return nil, errors.New(errIntOverflow)
+		}
+		return int64(v), nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf(errFmtConvert, strconv.Quote(v), "int")
+		}
+		return i, nil
+	}
+	return nil, errors.Errorf(errFmtConvert, typeName(args[0]), "int")
+}
+
+func toDouble(_ *evaluator, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, errors.Errorf(errFmtConvert, strconv.Quote(v), "double")
+		}
+		return f, nil
+	}
+	return nil, errors.Errorf(errFmtConvert, typeName(args[0]), "double")
+}
+
+// stringFn adapts a function that transforms a string.
+func stringFn(name string, fn func(string) string) func(e *evaluator, args []interface{}) (interface{}, error) {
+	return func(e *evaluator, args []interface{}) (interface{}, error) {
+		s, err := str(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.charge(len(s)); err != nil {
+			return nil, err
+		}
+		return fn(s), nil
+	}
+}
+
+// stringsFn adapts a function that transforms a string given another string.
+func stringsFn(name string, fn func(string, string) string) func(e *evaluator, args []interface{}) (interface{}, error) {
+	return func(e *evaluator, args []interface{}) (interface{}, error) {
+		s, err := str(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		a, err := str(name, args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.charge(len(s)); err != nil {
+			return nil, err
+		}
+		return fn(s, a), nil
+	}
+}
+
+// predicateFn adapts a function that tests a string given another string.
+func predicateFn(name string, fn func(string, string) bool) func(e *evaluator, args []interface{}) (interface{}, error) {
+	return func(e *evaluator, args []interface{}) (interface{}, error) {
+		s, err := str(name, args, 0)
+		if err != nil {
+			return nil, err
+		}
+		a, err := str(name, args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.charge(len(s)); err != nil {
+			return nil, err
+		}
+		return fn(s, a), nil
+	}
+}
+
+func replace(e *evaluator, args []interface{}) (interface{}, error) {
+	s, err := str("replace", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	old, err := str("replace", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	nw, err := str("replace", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Charge for the size of the result before producing it, so that a
+	// replacement cannot allocate an unbounded amount of memory.
+	n := strings.Count(s, old)
+	if err := e.charge(len(s) + n*len(nw)); err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(s, old, nw), nil
+}
+
+func split(e *evaluator, args []interface{}) (interface{}, error) {
+	s, err := str("split", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := str("split", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.charge(2 * len(s)); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(s, sep)
+	out := make([]interface{}, len(parts))
+	for i := range parts {
+		out[i] = parts[i]
+	}
+	return out, nil
+}
+
+func join(e *evaluator, args []interface{}) (interface{}, error) {
+	l, ok := args[0].([]interface{})
+	if !ok {
+		return nil, errors.Errorf(errFmtArgType, 1, "join", "list", typeName(args[0]))
+	}
+	sep, err := str("join", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(l))
+	total := len(sep) * len(l)
+	for i := range l {
+		s, ok := l[i].(string)
+		if !ok {
+			return nil, errors.Errorf(errFmtArgType, 1, "join", "list of strings", "list containing "+typeName(normalize(l[i])))
+		}
+		parts[i] = s
+		total += len(s)
+	}
+	if err := e.charge(total); err != nil {
+		return nil, err
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func substring(e *evaluator, args []interface{}) (interface{}, error) {
+	s, err := str("substring", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.charge(len(s)); err != nil {
+		return nil, err
+	}
+	r := []rune(s)
+	start, err := integer("substring", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	end := int64(len(r))
+	if len(args) == 3 {
+		if end, err = integer("substring", args, 2); err != nil {
+			return nil, err
+		}
+	}
+	if start < 0 || end < start || end > int64(len(r)) {
+		return nil, errors.Errorf(errFmtSubstring, start, end, len(r))
+	}
+	return string(r[start:end]), nil
+}
+
+func matches(e *evaluator, args []interface{}) (interface{}, error) {
+	s, err := str("matches", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	re, err := str("matches", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Go's regular expressions are guaranteed to run in time linear in the
+	// size of their input.
+	if err := e.charge(len(s) + len(re)); err != nil {
+		return nil, err
+	}
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtCompileRegexp, re)
+	}
+	return r.MatchString(s), nil
+}
+
+// extreme returns a function that returns the minimum or maximum of its
+// numeric arguments.
+func extreme(fn, op string) func(e *evaluator, args []interface{}) (interface{}, error) {
+	return func(e *evaluator, args []interface{}) (interface{}, error) {
+		if err := e.charge(len(args)); err != nil {
+			return nil, err
+		}
+		out := args[0]
+		for i := range args {
+			if _, ok := float(args[i]); !ok {
+				return nil, errors.Errorf(errFmtArgType, i+1, fn, "number", typeName(args[i]))
+			}
+			better, err := compare(op, args[i], out)
+			if err != nil {
+				return nil, err
+			}
+			if better.(bool) {
+				out = args[i]
+			}
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expression
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	errFmtUnexpectedChar     = "unexpected character %q at position %d"
+	errFmtUnterminatedString = "unterminated string starting at position %d"
+	errFmtInvalidEscape      = "invalid escape sequence %q at position %d"
+	errFmtInvalidNumber      = "invalid number %q at position %d"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	pos   int
+	value interface{}
+}
+
+// Operators are matched longest first.
+var operators = []string{
+	"||", "&&", "==", "!=", "<=", ">=",
+	"<", ">", "+", "-", "*", "/", "%", "!", "?", ":", ".", ",", "(", ")", "[", "]", "{", "}",
+}
+
+// lex splits the supplied expression into tokens. The final token is always
+// of kind tokenEOF.
+func lex(expr string) ([]token, error) { // nolint:gocyclo
+	// The cyclomatic complexity here comes from the switch on the first
+	// character of each token - each case is simple.
+	tokens := make([]token, 0)
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			t, n, err := lexString(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = n
+		case isDigit(c):
+			t, n, err := lexNumber(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = n
+		case isIdentStart(c):
+			start := i
+			for i < len(expr) && (isIdentStart(expr[i]) || isDigit(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errors.Errorf(errFmtUnexpectedChar, c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lexString lexes a quoted string starting at the supplied position. It
+// returns the token and the position immediately after the closing quote.
+func lexString(expr string, start int) (token, int, error) {
+	quote := expr[start]
+	b := &strings.Builder{}
+	for i := start + 1; i < len(expr); i++ {
+		c := expr[i]
+		switch c {
+		case quote:
+			return token{kind: tokenString, text: expr[start : i+1], pos: start, value: b.String()}, i + 1, nil
+		case '\\':
+			if i+1 >= len(expr) {
+				return token{}, 0, errors.Errorf(errFmtUnterminatedString, start)
+			}
+			i++
+			switch expr[i] {
+			case '\\', '"', '\'':
+				b.WriteByte(expr[i])
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				return token{}, 0, errors.Errorf(errFmtInvalidEscape, expr[i-1:i+1], i-1)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{}, 0, errors.Errorf(errFmtUnterminatedString, start)
+}
+
+// lexNumber lexes an integer or floating point number starting at the
+// supplied position. It returns the token and the position immediately after
+// the number.
+func lexNumber(expr string, start int) (token, int, error) {
+	i := start
+	isFloat := false
+	digits := func() {
+		for i < len(expr) && isDigit(expr[i]) {
+			i++
+		}
+	}
+	digits()
+	if i+1 < len(expr) && expr[i] == '.' && isDigit(expr[i+1]) {
+		isFloat = true
+		i++
+		digits()
+	}
+	if i < len(expr) && (expr[i] == 'e' || expr[i] == 'E') {
+		isFloat = true
+		i++
+		if i < len(expr) && (expr[i] == '+' || expr[i] == '-') {
+			i++
+		}
+		digits()
+	}
+
+	text := expr[start:i]
+	if isFloat {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return token{}, 0, errors.Errorf(errFmtInvalidNumber, text, start)
+		}
+		return token{kind: tokenFloat, text: text, pos: start, value: f}, i, nil
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return token{}, 0, errors.Errorf(errFmtInvalidNumber, text, start)
+	}
+	return token{kind: tokenInt, text: text, pos: start, value: n}, i, nil
+}
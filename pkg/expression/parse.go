@@ -0,0 +1,347 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expression
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	errFmtUnexpectedToken = "unexpected %s at position %d"
+	errFmtExpectedToken   = "expected %q at position %d"
+	errFmtTooDeep         = "expression is nested more than %d levels deep"
+	errFmtUnknownFunction = "unknown function %q at position %d"
+)
+
+// A node of an expression's abstract syntax tree.
+type node interface {
+	eval(e *evaluator) (interface{}, error)
+}
+
+type literal struct{ value interface{} }
+
+type variable struct{ name string }
+
+type list struct{ items []node }
+
+type object struct{ keys, values []node }
+
+type member struct {
+	object node
+	name   string
+}
+
+type index struct{ object, index node }
+
+type unary struct {
+	op      string
+	operand node
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+type conditional struct{ condition, then, otherwise node }
+
+type call struct {
+	fn   string
+	args []node
+}
+
+// A parser is a recursive descent parser. Each parse method corresponds to a
+// level of operator precedence, from lowest to highest:
+//
+//	c ? a : b
+//	||
+//	&&
+//	== != < <= > >= in
+//	+ -
+//	* / %
+//	! - (unary)
+//	a.b a[b] (selection)
+type parser struct {
+	tokens   []token
+	pos      int
+	depth    int
+	maxDepth int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the supplied operator or keyword.
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenOperator || t.kind == tokenIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return errors.Errorf(errFmtExpectedToken, text, p.peek().pos)
+	}
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return errors.Errorf(errFmtUnexpectedToken, "end of expression", t.pos)
+	}
+	return errors.Errorf(errFmtUnexpectedToken, "\""+t.text+"\"", t.pos)
+}
+
+func (p *parser) parse() (node, error) {
+	n, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+func (p *parser) parseConditional() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > p.maxDepth {
+		return nil, errors.Errorf(errFmtTooDeep, p.maxDepth)
+	}
+
+	c, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return c, nil
+	}
+	t, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	f, err := p.parseConditional()
+	if err != nil {
+		return nil, err
+	}
+	return conditional{condition: c, then: t, otherwise: f}, nil
+}
+
+// Binary operators, from lowest to highest precedence.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, o := range precedence[level] {
+			if p.accept(o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	for _, op := range []string{"!", "-"} {
+		if !p.accept(op) {
+			continue
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > p.maxDepth {
+			return nil, errors.Errorf(errFmtTooDeep, p.maxDepth)
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: op, operand: n}, nil
+	}
+	return p.parseSelection()
+}
+
+func (p *parser) parseSelection() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			t := p.next()
+			if t.kind != tokenIdent {
+				p.pos--
+				return nil, p.unexpected()
+			}
+			n = member{object: n, name: t.text}
+		case p.accept("["):
+			i, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = index{object: n, index: i}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) { // nolint:gocyclo
+	// The cyclomatic complexity here comes from the switch on the kind of
+	// primary expression - each case is simple.
+	t := p.peek()
+	switch t.kind {
+	case tokenInt, tokenFloat, tokenString:
+		p.next()
+		return literal{value: t.value}, nil
+	case tokenIdent:
+		p.next()
+		switch t.text {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		case "null":
+			return literal{value: nil}, nil
+		case "in":
+			p.pos--
+			return nil, p.unexpected()
+		}
+		if !p.accept("(") {
+			return variable{name: t.text}, nil
+		}
+		if _, ok := functions[t.text]; !ok && t.text != fnHas {
+			return nil, errors.Errorf(errFmtUnknownFunction, t.text, t.pos)
+		}
+		args, err := p.parseList(")")
+		if err != nil {
+			return nil, err
+		}
+		return call{fn: t.text, args: args}, nil
+	case tokenOperator:
+		switch {
+		case p.accept("("):
+			n, err := p.parseConditional()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case p.accept("["):
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return list{items: items}, nil
+		case p.accept("{"):
+			return p.parseObject()
+		}
+	case tokenEOF:
+	}
+	return nil, p.unexpected()
+}
+
+// parseList parses a comma separated list of expressions terminated by the
+// supplied closing token. The opening token must already be consumed.
+func (p *parser) parseList(closing string) ([]node, error) {
+	items := make([]node, 0)
+	if p.accept(closing) {
+		return items, nil
+	}
+	for {
+		n, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, n)
+		if p.accept(closing) {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseObject parses the key value pairs of an object literal. The opening
+// brace must already be consumed.
+func (p *parser) parseObject() (node, error) {
+	o := object{keys: make([]node, 0), values: make([]node, 0)}
+	if p.accept("}") {
+		return o, nil
+	}
+	for {
+		k, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.parseConditional()
+		if err != nil {
+			return nil, err
+		}
+		o.keys = append(o.keys, k)
+		o.values = append(o.values, v)
+		if p.accept("}") {
+			return o, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}